@@ -3,17 +3,30 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/OnlyF0uR/solana-monitor/internal/enrich"
 	"github.com/OnlyF0uR/solana-monitor/internal/hooks"
 	"github.com/OnlyF0uR/solana-monitor/internal/hooks/discord_hook"
+	"github.com/OnlyF0uR/solana-monitor/internal/hooks/storage_hook"
 	"github.com/OnlyF0uR/solana-monitor/internal/hooks/telegram_hook"
+	"github.com/OnlyF0uR/solana-monitor/internal/track"
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
+	"github.com/OnlyF0uR/solana-monitor/pkg/meteora"
 	"github.com/OnlyF0uR/solana-monitor/pkg/openbook"
+	"github.com/OnlyF0uR/solana-monitor/pkg/orca"
 	"github.com/OnlyF0uR/solana-monitor/pkg/raydium"
 	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs"
+	"github.com/OnlyF0uR/solana-monitor/pkg/storage"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
 	"github.com/fatih/color"
 	"github.com/gagliardetto/solana-go"
 	"github.com/joho/godotenv"
@@ -38,83 +51,377 @@ func main() {
 	// Load RPCs
 	rpcList := strings.Split(os.Getenv("SOLANA_RPC_URLS"), ";")
 	rpcs.Initialise(rpcList)
+	if v := utils.StI64(os.Getenv("TX_VISIBILITY_DEADLINE")); v > 0 {
+		utils.TransactionFetchPolicy.VisibilityDeadline = time.Duration(v) * time.Second
+	}
+
+	// Ingestion source, logsSubscribe over websocket by default. Several websocket
+	// endpoints are raced against each other.
+	var source ingest.Source
+	var race *ingest.RaceSource
+	if os.Getenv("INGEST_SOURCE") == "geyser" {
+		geyser, err := ingest.NewGeyserSource(os.Getenv("GEYSER_ENDPOINT"), os.Getenv("GEYSER_TOKEN"))
+		if err != nil {
+			fmt.Printf("Invalid geyser configuration: %v\n", err)
+			return
+		}
+		source = geyser
+	} else if wsList := strings.Split(os.Getenv("SOLANA_WS_URL"), ";"); len(wsList) > 1 {
+		race = newRaceSource(wsList)
+		source = race
+	} else {
+		source = ingest.NewLogsSource(os.Getenv("SOLANA_WS_URL"))
+	}
+
+	// Openbook markets seen by previous runs
+	if err := openbook.InitialiseCache(openbook.CacheOptionsFromEnv()); err != nil {
+		fmt.Printf("Failed to load openbook cache: %v\n", err)
+		return
+	}
+
+	// Signatures delivered recently, shared by all sources and the backfill
+	dedup, err := newDedup()
+	if err != nil {
+		fmt.Printf("Failed to load dedup signatures: %v\n", err)
+		return
+	}
+
+	// Root context, cancelled on SIGINT/SIGTERM. It stops the ingestion.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Processing, enrichment and hooks run on a context that outlives the root
+	// context by the grace period, so in-flight messages can drain.
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+
+	gracePeriod := 15 * time.Second
+	if v := utils.StI64(os.Getenv("SHUTDOWN_GRACE_PERIOD")); v >= 0 {
+		gracePeriod = time.Duration(v) * time.Second
+	}
 
-	wsUrl := os.Getenv("SOLANA_WS_URL")
+	// Storage before the ingestion, it keeps the backfill checkpoints
+	var store *storage.Store
+	if os.Getenv("ENABLE_STORAGE_HOOK") == "1" {
+		store = storage_hook.Initialise(workCtx)
+	}
+
+	// Replay what was missed while the monitor was down or reconnecting
+	if os.Getenv("ENABLE_BACKFILL") == "1" {
+		source = newBackfillSource(source, store, dedup)
+	}
+	source = ingest.NewDedupSource(source, dedup)
 
 	// Channels for processing
-	raydiumProcessingCh := make(chan solana.Signature)
-	openbookProcessingCh := make(chan solana.Signature)
+	raydiumProcessingCh := make(chan ingest.Event)
+	openbookProcessingCh := make(chan ingest.Event)
+	meteoraProcessingCh := make(chan ingest.Event)
+	orcaProcessingCh := make(chan ingest.Event)
+	// Channels for enrichment
+	poolEnrichCh := make(chan *events.PoolEvent)
 	// Channels for hooks
-	raydiumHookCh := make(chan *raydium.RaydiumInfo)
-	openbookHookCh := make(chan *openbook.OpenbookInfo)
+	poolHookCh := make(chan *enrich.EnrichedPoolEvent)
+	marketHookCh := make(chan *events.MarketEvent)
+	updateHookCh := make(chan *events.PoolUpdate)
 
-	var wg sync.WaitGroup
-	wg.Add(6) // 2 incoming, 2 processing, 2 hooks
+	// Every stage closes the channel of the next stage once it has drained its own
+	var raydiumAbandoned, openbookAbandoned, meteoraAbandoned, orcaAbandoned int
 
 	go func() {
-		for {
-			ctx := context.Background()
+		restartLoop(ctx, "Raydium", func() error {
+			return raydium.Start(ctx, source, raydiumProcessingCh)
+		})
+		close(raydiumProcessingCh)
+	}()
 
-			err := raydium.Start(ctx, wsUrl, raydiumProcessingCh)
-			if err != nil {
-				fmt.Printf("raydium.Start error, restarting: %v\n", err)
-			}
+	go func() {
+		restartLoop(ctx, "Openbook", func() error {
+			return openbook.Start(ctx, source, openbookProcessingCh)
+		})
+		close(openbookProcessingCh)
+	}()
 
-			time.Sleep(3 * time.Second)
+	go func() {
+		restartLoop(ctx, "Meteora", func() error {
+			return meteora.Start(ctx, source, meteoraProcessingCh)
+		})
+		close(meteoraProcessingCh)
+	}()
 
-			fmt.Println("Raydium is restarting...")
-		}
-		// fmt.Println("Raydium out...")
-		// wg.Done() // Signal completion of this goroutine
+	go func() {
+		restartLoop(ctx, "Orca", func() error {
+			return orca.Start(ctx, source, orcaProcessingCh)
+		})
+		close(orcaProcessingCh)
 	}()
 
+	// The pools of every venue share the enrichment and hooks
+	var enrichWg sync.WaitGroup
+	enrichWg.Add(3)
+
 	go func() {
-		for {
-			ctx := context.Background()
-
-			err := openbook.Start(ctx, wsUrl, openbookProcessingCh)
-			if err != nil {
-				fmt.Printf("openbook.Start error, restarting: %v\n", err)
-				// if !strings.Contains(err.Error(), "EOF") {
-				// 	break
-				// }
-			}
+		raydiumAbandoned = raydium.ProcessMessages(workCtx, raydiumProcessingCh, poolEnrichCh)
+		enrichWg.Done()
+	}()
 
-			time.Sleep(3 * time.Second)
+	go func() {
+		meteoraAbandoned = meteora.ProcessMessages(workCtx, meteoraProcessingCh, poolEnrichCh)
+		enrichWg.Done()
+	}()
 
-			fmt.Println("Openbook is restarting...")
-		}
-		// fmt.Println("Openbook out...")
-		// wg.Done() // Signal completion of this goroutine
+	go func() {
+		orcaAbandoned = orca.ProcessMessages(workCtx, orcaProcessingCh, poolEnrichCh)
+		enrichWg.Done()
 	}()
 
 	go func() {
-		raydium.ProcessMessages(raydiumProcessingCh, raydiumHookCh)
-		wg.Done()
+		enrichWg.Wait()
+		close(poolEnrichCh)
 	}()
 
 	go func() {
-		openbook.ProcessMessages(openbookProcessingCh, openbookHookCh)
-		wg.Done()
+		openbookAbandoned = openbook.ProcessMessages(workCtx, openbookProcessingCh, marketHookCh)
+		close(marketHookCh)
+	}()
+
+	go openbook.PersistCache(workCtx, time.Minute)
+	go dedup.Persist(workCtx, time.Minute)
+
+	// Enrichment is shared by all hooks
+	enrichTimeout := 20 * time.Second
+	if v := utils.StI64(os.Getenv("ENRICH_TIMEOUT")); v > 0 {
+		enrichTimeout = time.Duration(v) * time.Second
+	}
+	enrichWorkers := 4
+	if v := utils.StI64(os.Getenv("ENRICH_WORKERS")); v > 0 {
+		enrichWorkers = int(v)
+	}
+	pipeline := enrich.NewPipeline(enrichTimeout, enrich.DefaultSteps()...)
+
+	enrichedCh := make(chan *enrich.EnrichedPoolEvent)
+	go func() {
+		pipeline.RunPools(workCtx, poolEnrichCh, enrichedCh, enrichWorkers)
+		close(enrichedCh)
+	}()
+
+	// The reported pools are followed for LP_TRACK_HOURS, for LP burns and locks and for liquidity removals
+	var tracker *track.Tracker
+	trackOpts := track.Options{
+		Window:   24 * time.Hour,
+		Interval: 30 * time.Second,
+		LP:       os.Getenv("ENABLE_LP_TRACKER") == "1",
+	}
+	if os.Getenv("ENABLE_RUG_ALERTS") == "1" {
+		trackOpts.Removal = 50
+		if v, err := strconv.ParseFloat(os.Getenv("RUG_ALERT_THRESHOLD"), 64); err == nil && v > 0 && v <= 100 {
+			trackOpts.Removal = v
+		}
+	}
+	if trackOpts.LP || trackOpts.Removal > 0 {
+		if v := utils.StI64(os.Getenv("LP_TRACK_HOURS")); v > 0 {
+			trackOpts.Window = time.Duration(v) * time.Hour
+		}
+		if v := utils.StI64(os.Getenv("LP_TRACK_INTERVAL")); v > 0 {
+			trackOpts.Interval = time.Duration(v) * time.Second
+		}
+		tracker = track.NewTracker(trackOpts)
+	}
+
+	go func() {
+		for ev := range enrichedCh {
+			if tracker != nil {
+				tracker.Track(ev.Event)
+			}
+			poolHookCh <- ev
+		}
+		close(poolHookCh)
+	}()
+
+	go func() {
+		// Updates stop with the ingestion, the pools in flight are not followed anymore
+		if tracker != nil {
+			tracker.Run(ctx, updateHookCh)
+		}
+		close(updateHookCh)
 	}()
 
-	// Intialise the hooks
+	// Intialise the hooks, storage was registered first so it outlives the hooks it observes
 	if os.Getenv("ENABLE_DISCORD_HOOK") == "1" {
-		discord_hook.Initialise()
+		discord_hook.Initialise(workCtx)
 	}
 	if os.Getenv("ENABLE_TELEGRAM_HOOK") == "1" {
-		telegram_hook.Initialise()
+		telegram_hook.Initialise(workCtx)
 	}
 
+	var wg sync.WaitGroup
+	wg.Add(3) // 3 hook dispatchers, they return once the whole pipeline has drained
+
+	go func() {
+		hooks.RunPoolHooks(poolHookCh)
+		wg.Done()
+	}()
+
 	go func() {
-		hooks.RunRaydiumHooks(raydiumHookCh)
+		hooks.RunMarketHooks(marketHookCh)
 		wg.Done()
 	}()
 
 	go func() {
-		hooks.RunOpenbookHooks(openbookHookCh)
+		hooks.RunUpdateHooks(updateHookCh)
 		wg.Done()
 	}()
 
+	<-ctx.Done()
+	stop() // A second signal kills the process right away
+
+	color.New(color.FgYellow).Printf("Shutting down, draining for up to %v...\n", gracePeriod)
+	graceTimer := time.AfterFunc(gracePeriod, cancelWork)
+
 	wg.Wait()
+	hooks.Close()
+	graceTimer.Stop()
+
+	if err := openbook.FlushCache(); err != nil {
+		color.New(color.FgRed).Printf("Failed to persist openbook cache: %v\n", err)
+	}
+	if err := dedup.Flush(); err != nil {
+		color.New(color.FgRed).Printf("Failed to persist dedup signatures: %v\n", err)
+	}
+
+	var endpointStats []ingest.EndpointStats
+	if race != nil {
+		endpointStats = race.Stats()
+	}
+	printShutdownSummary(raydiumAbandoned, openbookAbandoned, meteoraAbandoned, orcaAbandoned, dedup.Stats(), endpointStats, workCtx.Err() != nil)
+}
+
+// newRaceSource races logsSubscribe on every endpoint, reading RACE_MAX_LAG (milliseconds)
+// and RACE_COOLDOWN (minutes).
+func newRaceSource(wsList []string) *ingest.RaceSource {
+	maxLag := 2 * time.Second
+	if v := utils.StI64(os.Getenv("RACE_MAX_LAG")); v >= 0 {
+		maxLag = time.Duration(v) * time.Millisecond
+	}
+	cooldown := 10 * time.Minute
+	if v := utils.StI64(os.Getenv("RACE_COOLDOWN")); v > 0 {
+		cooldown = time.Duration(v) * time.Minute
+	}
+
+	sources := make([]ingest.Source, len(wsList))
+	for i, wsUrl := range wsList {
+		src := ingest.NewLogsSource(wsUrl)
+		src.Label = "websocket " + wsUrl
+		if u, err := url.Parse(wsUrl); err == nil && u.Host != "" {
+			src.Label = "websocket " + u.Host
+		}
+		sources[i] = src
+	}
+
+	return ingest.NewRaceSource(sources, maxLag, cooldown)
+}
+
+// newDedup reads DEDUP_TTL (minutes), DEDUP_SIZE and DEDUP_PATH.
+func newDedup() (*ingest.Dedup, error) {
+	ttl := 60 * time.Minute
+	if v := utils.StI64(os.Getenv("DEDUP_TTL")); v > 0 {
+		ttl = time.Duration(v) * time.Minute
+	}
+	size := 100_000
+	if v := utils.StI64(os.Getenv("DEDUP_SIZE")); v > 0 {
+		size = int(v)
+	}
+
+	dedup := ingest.NewDedup(ttl, size)
+	if path := os.Getenv("DEDUP_PATH"); path != "" {
+		if err := dedup.Load(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return dedup, nil
+}
+
+func newBackfillSource(source ingest.Source, store *storage.Store, dedup *ingest.Dedup) *ingest.BackfillSource {
+	window := 10 * time.Minute
+	if v := utils.StI64(os.Getenv("BACKFILL_WINDOW")); v > 0 {
+		window = time.Duration(v) * time.Minute
+	}
+	maxSignatures := 2000
+	if v := utils.StI64(os.Getenv("BACKFILL_MAX_SIGNATURES")); v > 0 {
+		maxSignatures = int(v)
+	}
+
+	backfill := ingest.NewBackfillSource(source, nil, window, maxSignatures)
+	backfill.MaxTransactions = 200
+	if v := utils.StI64(os.Getenv("BACKFILL_MAX_TRANSACTIONS")); v > 0 {
+		backfill.MaxTransactions = int(v)
+	}
+	backfill.Dedup = dedup
+	if store != nil {
+		backfill.Checkpoints = store
+		backfill.Handled = func(ctx context.Context, signature solana.Signature) bool {
+			ok, _ := store.HasSignature(ctx, signature.String())
+			return ok
+		}
+	}
+
+	return backfill
+}
+
+// restartLoop keeps calling start until ctx is cancelled.
+func restartLoop(ctx context.Context, name string, start func() error) {
+	for {
+		err := start()
+		if ctx.Err() != nil {
+			fmt.Printf("%s out...\n", name)
+			return
+		}
+		if err != nil {
+			fmt.Printf("%s.Start error, restarting: %v\n", strings.ToLower(name), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Printf("%s out...\n", name)
+			return
+		case <-time.After(3 * time.Second):
+		}
+
+		fmt.Printf("%s is restarting...\n", name)
+	}
+}
+
+func printShutdownSummary(raydiumAbandoned int, openbookAbandoned int, meteoraAbandoned int, orcaAbandoned int, dedupStats []ingest.DedupStats, endpointStats []ingest.EndpointStats, graceExpired bool) {
+	if graceExpired {
+		color.New(color.FgRed).Println("Grace period expired before everything was drained")
+	}
+
+	fmt.Println("Shutdown summary:")
+	fmt.Printf("  raydium: %d signature(s) not processed\n", raydiumAbandoned)
+	fmt.Printf("  openbook: %d signature(s) not processed\n", openbookAbandoned)
+	fmt.Printf("  meteora: %d signature(s) not processed\n", meteoraAbandoned)
+	fmt.Printf("  orca: %d signature(s) not processed\n", orcaAbandoned)
+
+	for _, stats := range dedupStats {
+		fmt.Printf("  %s source: %d forwarded, %d duplicate(s) suppressed\n", stats.Source, stats.Forwarded, stats.Suppressed)
+	}
+	for _, stats := range endpointStats {
+		fmt.Printf("  %s: %d first, %d behind, %d missed, avg lag %v, dropped %d time(s)\n",
+			stats.Name, stats.First, stats.Behind, stats.Missed, stats.AvgLag().Round(time.Millisecond), stats.Dropped)
+	}
+
+	for _, stats := range rpcs.Stats() {
+		fmt.Printf("  rpc %s\n", stats)
+	}
+	if fired, won := rpcs.HedgeStats(); fired > 0 {
+		fmt.Printf("  rpc hedging: %d hedged request(s), %d won\n", fired, won)
+	}
+	for _, histogram := range utils.TransactionFetchStats() {
+		fmt.Printf("  transaction fetches %s\n", histogram)
+	}
+
+	for _, stats := range hooks.Stats() {
+		fmt.Printf("  %s hook: %d sent, %d skipped, %d failed, %d timed out, %d dropped, %d not sent\n",
+			stats.Name, stats.Handled, stats.Skipped, stats.Failed, stats.TimedOut, stats.Dropped, stats.Abandoned)
+	}
 }
@@ -0,0 +1,66 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+)
+
+type funcStep struct {
+	name string
+	fn   func(ctx context.Context, ev *EnrichedPoolEvent) error
+}
+
+func (s funcStep) Name() string { return s.name }
+func (s funcStep) EnrichPool(ctx context.Context, ev *EnrichedPoolEvent) error {
+	return s.fn(ctx, ev)
+}
+
+func Test_EnrichPool(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	// Both steps only finish once the other one has started, so they must run concurrently.
+	wait := func(ctx context.Context) {
+		started <- struct{}{}
+		<-release
+	}
+
+	pipeline := NewPipeline(time.Second,
+		funcStep{"balance", func(ctx context.Context, ev *EnrichedPoolEvent) error {
+			wait(ctx)
+			ev.CreatorBalance = 1.5
+			return nil
+		}},
+		funcStep{"holders", func(ctx context.Context, ev *EnrichedPoolEvent) error {
+			wait(ctx)
+			return errors.New("boom")
+		}},
+		funcStep{"deadline", func(ctx context.Context, ev *EnrichedPoolEvent) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+
+	go func() {
+		<-started
+		<-started
+		close(release)
+	}()
+
+	pool := &events.PoolEvent{}
+	ev := pipeline.EnrichPool(context.Background(), pool)
+
+	if ev.Event != pool {
+		t.Errorf("event does not reference the pool")
+	}
+	if ev.CreatorBalance != 1.5 {
+		t.Errorf("balance step result missing")
+	}
+	if len(ev.Errors) != 2 || ev.Errors["holders"] == nil || !errors.Is(ev.Errors["deadline"], context.DeadlineExceeded) {
+		t.Errorf("unexpected errors: %v", ev.Errors)
+	}
+}
@@ -0,0 +1,110 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+)
+
+// EnrichedPoolEvent is a detected pool together with all the data the hooks need
+// to render it. It is shared between all hooks and must be treated as read-only.
+type EnrichedPoolEvent struct {
+	Event *events.PoolEvent
+
+	TokenData *utils.TokenData // nil when the mint could not be fetched
+	TokenMeta *utils.TokenMeta // nil when the metadata JSON could not be fetched
+	Supply    float64          // Supply of the base token, adjusted for decimals
+
+	MintAuthorityEnabled   bool
+	FreezeAuthorityEnabled bool
+
+	CreatorBalance float64             // SOL balance of the pool creator
+	TopHolders     *[]utils.TopHolder  // Largest holders of the base token
+	Market         *events.MarketEvent // Order book market of Raydium AMM v4 pools
+
+	Errors map[string]error // Errors of the enrichment steps, keyed by step name
+}
+
+// Step enriches a single aspect of an event. Steps of a pipeline run concurrently
+// on the same event, so a step may only write the fields it owns.
+type Step interface {
+	Name() string
+	EnrichPool(ctx context.Context, ev *EnrichedPoolEvent) error
+}
+
+type Pipeline struct {
+	steps   []Step
+	timeout time.Duration
+}
+
+// NewPipeline creates a pipeline that runs the given steps with a shared deadline.
+func NewPipeline(timeout time.Duration, steps ...Step) *Pipeline {
+	return &Pipeline{
+		steps:   steps,
+		timeout: timeout,
+	}
+}
+
+// EnrichPool runs all steps concurrently for the given pool.
+func (p *Pipeline) EnrichPool(ctx context.Context, pool *events.PoolEvent) *EnrichedPoolEvent {
+	startTime := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	ev := &EnrichedPoolEvent{
+		Event:  pool,
+		Errors: make(map[string]error),
+	}
+
+	errs := make([]error, len(p.steps))
+
+	var wg sync.WaitGroup
+	wg.Add(len(p.steps))
+	for i, step := range p.steps {
+		go func() {
+			defer wg.Done()
+			errs[i] = step.EnrichPool(ctx, ev)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			ev.Errors[p.steps[i].Name()] = err
+		}
+	}
+
+	if os.Getenv("DEBUG") == "1" {
+		for name, err := range ev.Errors {
+			color.New(color.FgYellow).Printf("[%s] Enrichment step %s failed: %v\n", pool.TxID, name, err)
+		}
+		fmt.Printf("[%s] Pool enrichment timing (finished: %v)\n", pool.TxID, time.Since(startTime))
+	}
+
+	return ev
+}
+
+// RunPools enriches every pool from rChn on the given number of workers and
+// forwards the result to sendChn. It returns once rChn is closed and drained.
+func (p *Pipeline) RunPools(ctx context.Context, rChn <-chan *events.PoolEvent, sendChn chan<- *EnrichedPoolEvent, workers int) {
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for msg := range rChn {
+				sendChn <- p.EnrichPool(ctx, msg)
+			}
+		}()
+	}
+	wg.Wait()
+
+	fmt.Printf("Pool enrichment out...\n")
+}
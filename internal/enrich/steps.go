@@ -0,0 +1,119 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/openbook"
+	"github.com/OnlyF0uR/solana-monitor/pkg/raydium"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/gagliardetto/solana-go"
+)
+
+// DefaultSteps returns the steps used by the monitor.
+func DefaultSteps() []Step {
+	return []Step{
+		TokenStep{},
+		OpenbookStep{},
+		PumpfunStep{},
+	}
+}
+
+// TokenStep fetches the mint, metaplex metadata, creator account and largest holders
+// of the base token in a single batch, then the metadata JSON. The creator of pools
+// migrated from pump.fun is left to PumpfunStep.
+type TokenStep struct{}
+
+func (TokenStep) Name() string {
+	return "token"
+}
+
+func (TokenStep) EnrichPool(ctx context.Context, ev *EnrichedPoolEvent) error {
+	migrated := ev.Event.Origin == string(raydium.OriginPumpfun)
+
+	var creator solana.PublicKey
+	if !migrated {
+		creator = ev.Event.Creator
+	}
+	accounts, err := utils.GetTokenAccounts_S(ctx, ev.Event.BaseMint, creator)
+	if err != nil {
+		return fmt.Errorf("token data unavailable: %w", err)
+	}
+
+	if !migrated {
+		ev.CreatorBalance = accounts.CreatorBalance
+	}
+	ev.TopHolders = accounts.TopHolders
+
+	tokenMeta := utils.TokenMetaHelper(ctx, accounts.TokenData)
+	if tokenMeta == nil {
+		return errors.New("token metadata unavailable")
+	}
+
+	tokenData := accounts.TokenData
+	ev.TokenData = tokenData
+	ev.TokenMeta = tokenMeta
+	ev.Supply = float64(tokenData.Supply) / math.Pow10(int(tokenData.Decimals))
+	ev.MintAuthorityEnabled = tokenData.MintAuthority != nil
+	ev.FreezeAuthorityEnabled = tokenData.FreezeAuthority != nil
+
+	if accounts.HoldersErr != nil {
+		return fmt.Errorf("top holders unavailable: %w", accounts.HoldersErr)
+	}
+	return nil
+}
+
+// OpenbookStep attaches the openbook market of the pool, resolving it on chain
+// when it was created before the monitor started. Only AMM v4 pools have a market.
+type OpenbookStep struct{}
+
+func (OpenbookStep) Name() string {
+	return "openbook"
+}
+
+func (OpenbookStep) EnrichPool(ctx context.Context, ev *EnrichedPoolEvent) error {
+	pool, ok := ev.Event.Source.(*raydium.RaydiumInfo)
+	if !ok || pool.PoolType != raydium.PoolTypeAmmV4 {
+		return nil
+	}
+
+	// Searching the market by mint scans the whole program, too slow for every pool
+	if pool.SerumMarket.IsZero() {
+		if info := openbook.GetOpenbookInfo(pool.BaseMint.String()); info != nil {
+			ev.Market = info.MarketEvent()
+		}
+		return nil
+	}
+
+	info, err := openbook.LookupOpenbookInfo(ctx, pool.BaseMint, pool.SerumMarket)
+	if err != nil {
+		return err
+	}
+
+	ev.Market = info.MarketEvent()
+	return nil
+}
+
+// PumpfunStep looks up the creator and launch of tokens migrated from pump.fun, which takes
+// a walk through the history of the bonding curve. It owns the creator of those pools and
+// its balance.
+type PumpfunStep struct{}
+
+func (PumpfunStep) Name() string {
+	return "pumpfun"
+}
+
+func (PumpfunStep) EnrichPool(ctx context.Context, ev *EnrichedPoolEvent) error {
+	pool, ok := ev.Event.Source.(*raydium.RaydiumInfo)
+	if !ok || pool.Pumpfun == nil {
+		return nil
+	}
+
+	err := raydium.ResolvePumpfun_S(ctx, pool.Pumpfun, pool.TxTime)
+	ev.Event.Creator = pool.Creator()
+	ev.CreatorBalance = utils.GetBalance_S(ctx, ev.Event.Creator)
+
+	return err
+}
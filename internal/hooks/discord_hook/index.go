@@ -1,44 +1,72 @@
 package discord_hook
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/OnlyF0uR/solana-monitor/internal/hooks"
 	"github.com/bwmarrin/discordgo"
 )
 
-var discord *discordgo.Session
+type DiscordHook struct {
+	botToken string
+	session  *discordgo.Session
 
-var raydiumChannelID string
-var openbookChannelID string
+	raydiumChannelID  string
+	openbookChannelID string
 
-func Initialise() {
+	// Pool messages, the updates of a pool reply to them
+	replies *hooks.Replies[string]
+}
+
+func Initialise(ctx context.Context) {
 	botToken := os.Getenv("DISCORD_BOT_TOKEN")
 	if botToken == "" {
 		panic("DISCORD_BOT_TOKEN not set")
 	}
 
-	dc, err := discordgo.New("Bot " + os.Getenv("DISCORD_BOT_TOKEN"))
-	if err != nil {
-		panic(err)
-	}
-
-	discord = dc
-
-	raydiumChannelID = os.Getenv("DISCORD_RAYDIUM_CHANNEL")
+	raydiumChannelID := os.Getenv("DISCORD_RAYDIUM_CHANNEL")
 	if raydiumChannelID == "" {
 		panic("DISCORD_RAYDIUM_CHANNEL not set")
 	}
 
-	openbookChannelID = os.Getenv("DISCORD_OPENBOOK_CHANNEL")
+	openbookChannelID := os.Getenv("DISCORD_OPENBOOK_CHANNEL")
 	if openbookChannelID == "" {
 		panic("DISCORD_OPENBOOK_CHANNEL not set")
 	}
 
+	hook := &DiscordHook{
+		botToken:          botToken,
+		raydiumChannelID:  raydiumChannelID,
+		openbookChannelID: openbookChannelID,
+		replies:           hooks.NewReplies[string](24*time.Hour, 10_000),
+	}
+
 	// Setup hooks
-	hooks.RegisterOpenbookHook(dc_openbook_hook)
-	hooks.RegisterRaydiumHook(dc_raydium_hook)
+	err := hooks.Register(ctx, hook, hooks.OptionsFromEnv("DISCORD"))
+	if err != nil {
+		panic(err)
+	}
 
 	fmt.Printf("Discord hook initialised\n")
 }
+
+func (h *DiscordHook) Name() string {
+	return "discord"
+}
+
+func (h *DiscordHook) Init(ctx context.Context) error {
+	dc, err := discordgo.New("Bot " + h.botToken)
+	if err != nil {
+		return err
+	}
+
+	h.session = dc
+	return nil
+}
+
+func (h *DiscordHook) Close() error {
+	return h.session.Close()
+}
@@ -7,29 +7,36 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/OnlyF0uR/solana-monitor/pkg/openbook"
+	"github.com/OnlyF0uR/solana-monitor/internal/hooks"
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
 	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
 	"github.com/bwmarrin/discordgo"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/fatih/color"
 )
 
-func dc_openbook_hook(msg *openbook.OpenbookInfo, ctx context.Context) {
+func (h *DiscordHook) HandleMarket(ctx context.Context, msg *events.MarketEvent) error {
 	startTime := time.Now()
 
 	// Required information about the involved tokens
 	baseTokenData, baseTokenMeta := utils.TokenHelper(ctx, msg.BaseMint)
 	if baseTokenData == nil || baseTokenMeta == nil {
-		return
+		return hooks.ErrSkipped
 	}
 
 	tokenBSymbol := utils.TokenToSymbol(msg.QuoteMint)
 
+	// Block times are unknown for transactions streamed by Geyser
+	createdAt := msg.TxTime
+	if createdAt.IsZero() {
+		createdAt = msg.Timestamp
+	}
+
 	if os.Getenv("DEBUG") == "1" {
-		fmt.Printf("[%s] Openbook hook timing (before caller balance: %v)\n", msg.TxID, time.Since(startTime))
+		fmt.Printf("[%s] Market hook timing (before creator balance: %v)\n", msg.TxID, time.Since(startTime))
 	}
 
-	balance := utils.GetBalance_S(ctx, msg.Caller)
+	balance := utils.GetBalance_S(ctx, msg.Creator)
 
 	var embedColour = utils.EMBED_COLOUR_PURPLE
 	var titleEmoji = "🟢"
@@ -38,7 +45,7 @@ func dc_openbook_hook(msg *openbook.OpenbookInfo, ctx context.Context) {
 	}
 
 	if os.Getenv("DEBUG") == "1" {
-		fmt.Printf("[%s] Openbook hook timing (before warnings: %v)\n", msg.TxID, time.Since(startTime))
+		fmt.Printf("[%s] Market hook timing (before warnings: %v)\n", msg.TxID, time.Since(startTime))
 	}
 
 	if msg.Costs < 0.5 {
@@ -47,11 +54,17 @@ func dc_openbook_hook(msg *openbook.OpenbookInfo, ctx context.Context) {
 	}
 
 	if os.Getenv("DEBUG") == "1" {
-		fmt.Printf("[%s] Openbook hook timing (before discord: %v)\n", msg.TxID, time.Since(startTime))
+		fmt.Printf("[%s] Market hook timing (before discord: %v)\n", msg.TxID, time.Since(startTime))
+	}
+
+	// Markets of the other programs are labelled with their venue, OpenBook v1 is the default
+	var titlePrefix string
+	if msg.Kind != "" {
+		titlePrefix = "[" + msg.Label() + "] "
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title: baseTokenData.Data.Symbol + "/" + tokenBSymbol + " - " + strconv.FormatFloat(msg.Costs, 'f', 3, 64) + " SOL " + titleEmoji,
+		Title: titlePrefix + baseTokenData.Data.Symbol + "/" + tokenBSymbol + " - " + strconv.FormatFloat(msg.Costs, 'f', 3, 64) + " SOL " + titleEmoji,
 		Color: embedColour,
 		Fields: []*discordgo.MessageEmbedField{
 			{
@@ -61,12 +74,12 @@ func dc_openbook_hook(msg *openbook.OpenbookInfo, ctx context.Context) {
 			},
 			{
 				Name:   "Token",
-				Value:  "Creator: [" + msg.Caller.Short(3) + "](https://solscan.io/account/" + msg.Caller.String() + ") **(" + strconv.FormatFloat(balance, 'f', 3, 64) + " SOL)**",
+				Value:  "Creator: [" + msg.Creator.Short(3) + "](https://solscan.io/account/" + msg.Creator.String() + ") **(" + strconv.FormatFloat(balance, 'f', 3, 64) + " SOL)**",
 				Inline: true,
 			},
 			{
 				Name:   "History",
-				Value:  "Created: <t:" + utils.I64tS(msg.TxTime.Truncate(time.Second).Unix()) + ":R>",
+				Value:  "Created: <t:" + utils.I64tS(createdAt.Truncate(time.Second).Unix()) + ":R>",
 				Inline: true,
 			},
 			{
@@ -89,13 +102,18 @@ func dc_openbook_hook(msg *openbook.OpenbookInfo, ctx context.Context) {
 		},
 	}
 
-	_, err := discord.ChannelMessageSendEmbed(openbookChannelID, embed)
+	// Venue specific information, right after the creator and history
+	embed.Fields = append(embed.Fields[:3], append(extraFields(msg.Extras), embed.Fields[3:]...)...)
+
+	_, err := h.session.ChannelMessageSendEmbed(h.openbookChannelID, embed, discordgo.WithContext(ctx))
 	if err != nil {
-		fmt.Printf("Error sending message: %v\n", err)
+		return fmt.Errorf("error sending message: %w", err)
 	}
 
 	if os.Getenv("DEBUG") == "1" {
 		spew.Dump(msg)
-		color.New(color.FgBlue).Printf("[%s] Openbook hook timing (finished: %v)\n", msg.TxID, time.Since(startTime))
+		color.New(color.FgBlue).Printf("[%s] Market hook timing (finished: %v)\n", msg.TxID, time.Since(startTime))
 	}
+
+	return nil
 }
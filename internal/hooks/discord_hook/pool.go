@@ -0,0 +1,226 @@
+package discord_hook
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/internal/enrich"
+	"github.com/OnlyF0uR/solana-monitor/internal/hooks"
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/bwmarrin/discordgo"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go"
+)
+
+func (h *DiscordHook) HandlePool(ctx context.Context, ev *enrich.EnrichedPoolEvent) error {
+	startTime := time.Now()
+
+	msg := ev.Event
+	baseTokenData, baseTokenMeta := ev.TokenData, ev.TokenMeta
+	if baseTokenData == nil || baseTokenMeta == nil {
+		return hooks.ErrSkipped
+	}
+
+	tokenBSymbol := utils.TokenToSymbol(msg.QuoteMint)
+
+	// Create the string of the liquidity
+	liquidityStr := strconv.FormatFloat(msg.BaseLiquidity, 'f', 0, 64) + " " + baseTokenData.Data.Symbol + " / " + strconv.FormatFloat(msg.QuoteLiquidity, 'f', 1, 64) + " " + tokenBSymbol
+
+	// Authority strings
+	mintAuthStr := "🔴 **Enabled** 🔴"
+	freezeAuthStr := "🔴 **Enabled** 🔴"
+	if !ev.MintAuthorityEnabled {
+		mintAuthStr = "🟢 **Disabled** 🟢"
+	}
+	if !ev.FreezeAuthorityEnabled {
+		freezeAuthStr = "🟢 **Disabled** 🟢"
+	}
+
+	// Token-2022 extensions that let the issuer take or lock tokens
+	var warningsStr string
+	for _, warning := range baseTokenData.Extensions.Warnings() {
+		warningsStr += "⚠️ " + warning + "\n"
+	}
+
+	// Colour and emoji
+	var embedColour = utils.EMBED_COLOUR_PURPLE
+	var titleEmoji = "🟢"
+	var costs float64 = 0
+
+	// Openbook info if available
+	if ev.Market != nil {
+		costs = ev.Market.Costs
+	}
+
+	if costs < 2 {
+		embedColour = utils.EMBED_COLOUR_BLUE
+	}
+
+	if os.Getenv("DEBUG") == "1" {
+		fmt.Printf("[%s] Pool hook timing (before warnings: %v)\n", msg.TxID, time.Since(startTime))
+	}
+
+	if costs < 0.5 {
+		embedColour = utils.EMBED_COLOUR_RED
+		titleEmoji = "🔴"
+	}
+
+	// Fallback for when no openbook information is available
+	costsStr := "N/A ⚪"
+	if costs > 0 {
+		costsStr = strconv.FormatFloat(costs, 'f', 3, 64) + " SOL " + titleEmoji
+	} else {
+		embedColour = utils.EMBED_COLOUR_WHITE
+	}
+
+	// Get top holder string
+	topHoldersStr := getHolderString(string(msg.Venue), ev.TopHolders, msg.BaseVault, ev.Supply, msg.BaseLiquidity)
+
+	if os.Getenv("DEBUG") == "1" {
+		fmt.Printf("[%s] Pool hook timing (before discord: %v)\n", msg.TxID, time.Since(startTime))
+	}
+
+	// Pools of the other programs are labelled with their venue, Raydium AMM v4 is the default
+	titleStr := baseTokenData.Data.Symbol + "/" + tokenBSymbol + " - " + costsStr
+	if msg.Kind != "" {
+		titleStr = "[" + msg.Label() + "] " + titleStr
+	}
+	if msg.Origin != "" {
+		titleStr = "[" + msg.Origin + "] " + titleStr
+	}
+
+	creator := msg.Creator
+
+	embed := &discordgo.MessageEmbed{
+		Title: titleStr,
+		Color: embedColour,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Token Address",
+				Value:  "``" + msg.BaseMint.String() + "``",
+				Inline: false,
+			},
+			{
+				Name:   "Pool Info",
+				Value:  "Opens: <t:" + utils.I64tS(msg.OpenTime.Unix()) + ":R>\nCreator: [" + creator.Short(3) + "](https://solscan.io/account/" + creator.String() + ") **(" + strconv.FormatFloat(ev.CreatorBalance, 'f', 3, 64) + " SOL)**\nLiquidity: " + liquidityStr,
+				Inline: false,
+			},
+			{
+				Name:   "Token Description",
+				Value:  baseTokenMeta.Description,
+				Inline: false,
+			},
+			{
+				Name:   "Authorities",
+				Value:  "Mint: " + mintAuthStr + "\nFreeze: " + freezeAuthStr,
+				Inline: true,
+			},
+			{
+				Name:   "Token Ownership",
+				Value:  topHoldersStr,
+				Inline: true,
+			},
+			{
+				Name:   "Socials",
+				Value:  utils.SocialstS(baseTokenMeta.Twitter, baseTokenMeta.Telegram, baseTokenMeta.Website),
+				Inline: false,
+			},
+			{
+				Name:  "Extra Links",
+				Value: "[Solscan (Token)](https://solscan.io/account/" + msg.BaseMint.String() + ") | [Solscan (Tx)](https://solscan.io/tx/" + msg.TxID.String() + ") | [Solscan (Pool)](https://solscan.io/account/" + msg.Pool.String() + ") | [BirdEye](https://birdeye.so/token/" + msg.BaseMint.String() + ") | [RugCheck](https://rugcheck.xyz/tokens/" + msg.BaseMint.String() + ") | [Photon](https://photon-sol.tinyastro.io/en/lp/" + msg.Pool.String() + ")",
+			},
+		},
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: baseTokenMeta.Image,
+		},
+	}
+
+	if warningsStr != "" {
+		// Below the authorities and ownership, before the socials
+		warningsField := &discordgo.MessageEmbedField{
+			Name:   "Token-2022 Warnings",
+			Value:  warningsStr[:len(warningsStr)-1],
+			Inline: false,
+		}
+		embed.Fields = append(embed.Fields[:5], append([]*discordgo.MessageEmbedField{warningsField}, embed.Fields[5:]...)...)
+	}
+
+	// Venue specific information, right after the pool info
+	embed.Fields = append(embed.Fields[:2], append(extraFields(msg.Extras), embed.Fields[2:]...)...)
+
+	sent, err := h.session.ChannelMessageSendEmbed(h.raydiumChannelID, embed, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("error sending message: %w", err)
+	}
+	h.replies.Remember(msg.Pool, sent.ID)
+
+	if os.Getenv("DEBUG") == "1" {
+		spew.Dump(msg)
+		color.New(color.FgBlue).Printf("[%s] Pool hook timing (finished: %v)\n", msg.TxID, time.Since(startTime))
+	}
+
+	return nil
+}
+
+// extraFields renders every extra as a field of "Name: Value" lines.
+func extraFields(extras []events.Extra) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
+	for _, extra := range extras {
+		var lines []string
+		for _, field := range extra.Fields() {
+			value := field.Value
+			if field.Link != "" {
+				value = "[" + value + "](" + field.Link + ")"
+			}
+			lines = append(lines, field.Name+": "+value)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   extra.Title(),
+			Value:  strings.Join(lines, "\n"),
+			Inline: false,
+		})
+	}
+	return fields
+}
+
+// getHolderString lists the top holders, headed by the share held by the pool of dex.
+func getHolderString(dex string, topHolders *[]utils.TopHolder, poolCoinTokenAccount solana.PublicKey, supply float64, liquidity float64) string {
+	var topHolderRaydiumAmount string
+	var topHoldersStr string
+	if topHolders == nil {
+		topHoldersStr = "N/A"
+	} else {
+		for i, holder := range *topHolders {
+			supplyPct := (holder.Amount / supply) * 100
+
+			var address = holder.PublicKey.Short(3)
+			if holder.PublicKey == poolCoinTokenAccount {
+				topHolderRaydiumAmount = "*" + dex + ": " + strconv.FormatFloat(supplyPct, 'f', 2, 64) + "%*\n\n"
+				address = address + " (LP)"
+			}
+			if i < 5 {
+				// We add to string here too
+				topHoldersStr += "[" + address + "](https://solscan.io/account/" + holder.PublicKey.String() + ") - " + strconv.FormatFloat(supplyPct, 'f', 2, 64) + "%\n"
+			}
+		}
+	}
+	if len(topHoldersStr) > 0 {
+		topHoldersStr = topHoldersStr[:len(topHoldersStr)-1]
+	}
+
+	if topHolderRaydiumAmount == "" {
+		topHolderRaydiumAmount = "**" + dex + ": " + strconv.FormatFloat((liquidity/supply)*100, 'f', 2, 64) + "%**\n\n"
+	}
+
+	return topHolderRaydiumAmount + topHoldersStr
+}
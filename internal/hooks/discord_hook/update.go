@@ -0,0 +1,68 @@
+package discord_hook
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (h *DiscordHook) HandleUpdate(ctx context.Context, msg *events.PoolUpdate) error {
+	titleEmoji := "🔥"
+	embedColour := utils.EMBED_COLOUR_GREEN
+	accountLink := "[Solscan (LP Mint)](https://solscan.io/account/" + msg.Pool.LPMint.String() + ")"
+	// Block times can be unknown
+	when := msg.TxTime
+	if when.IsZero() {
+		when = msg.Timestamp
+	}
+	updateStr := "Wallet: [" + msg.Wallet.Short(3) + "](https://solscan.io/account/" + msg.Wallet.String() + ")\nWhen: <t:" + utils.I64tS(when.Unix()) + ":R>"
+
+	switch msg.Kind {
+	case events.UpdateLPLocked:
+		titleEmoji = "🔒"
+	case events.UpdateLiquidityRemoved:
+		titleEmoji = "🚨"
+		embedColour = utils.EMBED_COLOUR_RED
+		accountLink = "[Solscan (Vault)](https://solscan.io/account/" + msg.Pool.QuoteVault.String() + ")"
+		updateStr = "Removed: " + strconv.FormatFloat(msg.Amount, 'f', 1, 64) + " " + utils.TokenToSymbol(msg.Pool.QuoteMint) + "\n" + updateStr
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "[" + msg.Pool.Label() + "] " + msg.Summary() + " " + titleEmoji,
+		Color: embedColour,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Addresses",
+				Value:  "**Token**\n``" + msg.Pool.BaseMint.String() + "``\n**Pool**\n``" + msg.Pool.Pool.String() + "``",
+				Inline: false,
+			},
+			{
+				Name:   "Update",
+				Value:  updateStr,
+				Inline: false,
+			},
+			{
+				Name:  "Extra Links",
+				Value: "[Solscan (Tx)](https://solscan.io/tx/" + msg.TxID.String() + ") | " + accountLink + " | [RugCheck](https://rugcheck.xyz/tokens/" + msg.Pool.BaseMint.String() + ")",
+			},
+		},
+	}
+
+	// Reply to the message of the pool when it was posted by this instance
+	send := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}
+	if id, ok := h.replies.Lookup(msg.Pool.Pool); ok {
+		failIfNotExists := false
+		send.Reference = &discordgo.MessageReference{MessageID: id, ChannelID: h.raydiumChannelID, FailIfNotExists: &failIfNotExists}
+	}
+
+	_, err := h.session.ChannelMessageSendComplex(h.raydiumChannelID, send, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("error sending message: %w", err)
+	}
+
+	return nil
+}
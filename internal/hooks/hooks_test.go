@@ -0,0 +1,241 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/internal/enrich"
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/gagliardetto/solana-go"
+)
+
+type testHook struct {
+	mutex   sync.Mutex
+	release chan struct{}
+	err     error
+	seen    []*enrich.EnrichedPoolEvent
+	closed  bool
+}
+
+func (h *testHook) Name() string                   { return "test" }
+func (h *testHook) Init(ctx context.Context) error { return nil }
+func (h *testHook) Close() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.closed = true
+	return nil
+}
+
+func (h *testHook) HandlePool(ctx context.Context, msg *enrich.EnrichedPoolEvent) error {
+	if h.release != nil {
+		select {
+		case <-h.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	h.mutex.Lock()
+	h.seen = append(h.seen, msg)
+	h.mutex.Unlock()
+
+	return h.err
+}
+
+func (h *testHook) HandleMarket(ctx context.Context, msg *events.MarketEvent) error {
+	return h.err
+}
+
+func (h *testHook) HandleUpdate(ctx context.Context, msg *events.PoolUpdate) error {
+	return h.err
+}
+
+func Test_runnerDropOldest(t *testing.T) {
+	hook := &testHook{release: make(chan struct{})}
+	r := newRunner(context.Background(), hook, Options{QueueSize: 2, Workers: 1, Overflow: OverflowDropOldest}.withDefaults())
+	r.start()
+
+	// The first message is picked up by the worker and blocks it,
+	// the remaining four compete for two queue slots.
+	first := &enrich.EnrichedPoolEvent{}
+	r.enqueue(job{pool: first})
+	for len(r.queue) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	msgs := []*enrich.EnrichedPoolEvent{{}, {}, {}, {}}
+	for _, msg := range msgs {
+		r.enqueue(job{pool: msg})
+	}
+
+	close(hook.release)
+	r.stop()
+
+	stats := r.snapshot()
+	if stats.Dropped != 2 || stats.Handled != 3 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if hook.seen[0] != first || hook.seen[1] != msgs[2] || hook.seen[2] != msgs[3] {
+		t.Errorf("oldest messages were not the ones dropped")
+	}
+	if !hook.closed {
+		t.Errorf("hook was not closed")
+	}
+}
+
+func Test_runnerDropNewest(t *testing.T) {
+	hook := &testHook{release: make(chan struct{})}
+	r := newRunner(context.Background(), hook, Options{QueueSize: 1, Workers: 1, Overflow: OverflowDropNewest}.withDefaults())
+	r.start()
+
+	r.enqueue(job{pool: &enrich.EnrichedPoolEvent{}})
+	for len(r.queue) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	kept := &enrich.EnrichedPoolEvent{}
+	r.enqueue(job{pool: kept})
+	r.enqueue(job{pool: &enrich.EnrichedPoolEvent{}})
+
+	close(hook.release)
+	r.stop()
+
+	if stats := r.snapshot(); stats.Dropped != 1 || stats.Handled != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if hook.seen[1] != kept {
+		t.Errorf("newest message was not the one dropped")
+	}
+}
+
+func Test_runnerErrors(t *testing.T) {
+	var reported []error
+	opts := Options{
+		Workers: 1,
+		Timeout: 10 * time.Millisecond,
+		OnError: func(hook string, err error) { reported = append(reported, err) },
+	}
+
+	// Handler that never finishes in time
+	slow := &testHook{release: make(chan struct{})}
+	r := newRunner(context.Background(), slow, opts.withDefaults())
+	r.start()
+	r.enqueue(job{pool: &enrich.EnrichedPoolEvent{}})
+	r.stop()
+
+	if stats := r.snapshot(); stats.TimedOut != 1 {
+		t.Errorf("expected a timeout, got %+v", stats)
+	}
+
+	// Handler that fails and one that skips
+	failing := &testHook{err: errors.New("boom")}
+	r = newRunner(context.Background(), failing, opts.withDefaults())
+	r.start()
+	r.enqueue(job{pool: &enrich.EnrichedPoolEvent{}})
+	r.stop()
+
+	skipping := &testHook{err: ErrSkipped}
+	r2 := newRunner(context.Background(), skipping, opts.withDefaults())
+	r2.start()
+	r2.enqueue(job{pool: &enrich.EnrichedPoolEvent{}})
+	r2.stop()
+
+	if stats := r.snapshot(); stats.Failed != 1 {
+		t.Errorf("expected a failure, got %+v", stats)
+	}
+	if stats := r2.snapshot(); stats.Skipped != 1 || stats.Failed != 0 {
+		t.Errorf("expected a skip, got %+v", stats)
+	}
+	if len(reported) != 2 {
+		t.Errorf("expected 2 reported errors, got %d", len(reported))
+	}
+
+	// Messages after stop are abandoned
+	r.enqueue(job{pool: &enrich.EnrichedPoolEvent{}})
+	if stats := r.snapshot(); stats.Abandoned != 1 {
+		t.Errorf("expected an abandoned message, got %+v", stats)
+	}
+}
+
+func Test_runnerCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	hook := &testHook{release: make(chan struct{})}
+	r := newRunner(ctx, hook, Options{QueueSize: 4, Workers: 1}.withDefaults())
+	r.start()
+
+	// One in-flight call and two queued messages
+	for i := 0; i < 3; i++ {
+		r.enqueue(job{pool: &enrich.EnrichedPoolEvent{}})
+	}
+
+	cancel()
+	r.stop()
+
+	stats := r.snapshot()
+	if stats.Abandoned+stats.TimedOut+stats.Failed != 3 || stats.Handled != 0 {
+		t.Errorf("queued messages were handled after cancellation: %+v", stats)
+	}
+	if len(hook.seen) != 0 {
+		t.Errorf("hook saw %d messages", len(hook.seen))
+	}
+}
+
+func Test_Observe(t *testing.T) {
+	var mutex sync.Mutex
+	var outcomes []Outcome
+	Observe(func(o Outcome) {
+		mutex.Lock()
+		outcomes = append(outcomes, o)
+		mutex.Unlock()
+	})
+	t.Cleanup(func() { observers = nil })
+
+	boom := errors.New("boom")
+	hook := &testHook{err: boom}
+	r := newRunner(context.Background(), hook, Options{Workers: 1}.withDefaults())
+	r.start()
+	r.enqueue(job{market: &events.MarketEvent{}})
+	r.stop()
+	r.enqueue(job{pool: &enrich.EnrichedPoolEvent{}})
+	r.enqueue(job{update: &events.PoolUpdate{}})
+
+	if len(outcomes) != 3 {
+		t.Fatalf("expected 2 outcomes, got %+v", outcomes)
+	}
+	if o := outcomes[0]; o.Hook != "test" || o.Kind != "market" || o.Status != StatusFailed || !errors.Is(o.Err, boom) {
+		t.Errorf("unexpected failure outcome %+v", o)
+	}
+	if o := outcomes[1]; o.Kind != "pool" || o.Status != StatusAbandoned {
+		t.Errorf("unexpected abandoned outcome %+v", o)
+	}
+	if o := outcomes[2]; o.Kind != "update" || o.Status != StatusAbandoned {
+		t.Errorf("unexpected update outcome %+v", o)
+	}
+}
+
+func Test_Replies(t *testing.T) {
+	replies := NewReplies[int](time.Hour, 2)
+
+	pools := []solana.PublicKey{solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()}
+	replies.Remember(pools[0], 1)
+	if id, ok := replies.Lookup(pools[0]); !ok || id != 1 {
+		t.Errorf("lookup: got %d, %v", id, ok)
+	}
+	if _, ok := replies.Lookup(pools[1]); ok {
+		t.Error("unknown pool found")
+	}
+
+	// Never more than max messages
+	replies.Remember(pools[1], 2)
+	replies.Remember(pools[2], 3)
+	if len(replies.messages) > 2 {
+		t.Errorf("kept %d messages", len(replies.messages))
+	}
+	if id, ok := replies.Lookup(pools[2]); !ok || id != 3 {
+		t.Errorf("latest: got %d, %v", id, ok)
+	}
+}
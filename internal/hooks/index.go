@@ -2,38 +2,103 @@ package hooks
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 
-	"github.com/OnlyF0uR/solana-monitor/pkg/openbook"
-	"github.com/OnlyF0uR/solana-monitor/pkg/raydium"
+	"github.com/OnlyF0uR/solana-monitor/internal/enrich"
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
 )
 
-var OpenbookHooks []func(*openbook.OpenbookInfo, context.Context)
-var RaydiumHooks []func(*raydium.RaydiumInfo, context.Context)
+// ErrSkipped can be returned by a hook when it decided not to handle a message
+// (e.g. missing token metadata). It is counted separately and not reported as error.
+var ErrSkipped = errors.New("hook skipped message")
 
-func RegisterOpenbookHook(cb func(*openbook.OpenbookInfo, context.Context)) {
-	OpenbookHooks = append(OpenbookHooks, cb)
+// Hook is a sink that gets notified about every detected market and pool, of
+// every venue, and about the updates of the pools. Every registered hook runs on its own queue and worker pool, so
+// a slow hook never blocks the other ones.
+type Hook interface {
+	// Name is used in logs and statistics.
+	Name() string
+	// Init is called once when the hook is registered.
+	Init(ctx context.Context) error
+	HandlePool(ctx context.Context, msg *enrich.EnrichedPoolEvent) error
+	HandleMarket(ctx context.Context, msg *events.MarketEvent) error
+	// HandleUpdate is called with what happened to a pool after it was handled.
+	HandleUpdate(ctx context.Context, msg *events.PoolUpdate) error
+	// Close is called once the queue of the hook has been drained.
+	Close() error
 }
 
-func RegisterRaydiumHook(cb func(*raydium.RaydiumInfo, context.Context)) {
-	RaydiumHooks = append(RaydiumHooks, cb)
+var runners []*runner
+var runnersMutex = &sync.Mutex{}
+
+// Register initialises the hook and starts its workers. Handler calls derive
+// their context from ctx, once it is cancelled queued messages are abandoned.
+func Register(ctx context.Context, hook Hook, opts Options) error {
+	if err := hook.Init(ctx); err != nil {
+		return fmt.Errorf("failed to initialise hook %s: %w", hook.Name(), err)
+	}
+
+	r := newRunner(ctx, hook, opts.withDefaults())
+	r.start()
+
+	runnersMutex.Lock()
+	runners = append(runners, r)
+	runnersMutex.Unlock()
+
+	return nil
 }
 
-func RunOpenbookHooks(ch <-chan *openbook.OpenbookInfo) {
-	ctx := context.Background()
+func registered() []*runner {
+	runnersMutex.Lock()
+	defer runnersMutex.Unlock()
+
+	return append([]*runner(nil), runners...)
+}
+
+func RunMarketHooks(ch <-chan *events.MarketEvent) {
 	for msg := range ch {
-		// Loop through openbook hooks
-		for _, v := range OpenbookHooks {
-			v(msg, ctx)
+		// Hand the message to every hook queue
+		for _, r := range registered() {
+			r.enqueue(job{market: msg})
 		}
 	}
 }
 
-func RunRaydiumHooks(ch <-chan *raydium.RaydiumInfo) {
-	ctx := context.Background()
+func RunPoolHooks(ch <-chan *enrich.EnrichedPoolEvent) {
 	for msg := range ch {
-		// Loop through raydium hooks
-		for _, v := range RaydiumHooks {
-			v(msg, ctx)
+		// Hand the message to every hook queue
+		for _, r := range registered() {
+			r.enqueue(job{pool: msg})
 		}
 	}
 }
+
+func RunUpdateHooks(ch <-chan *events.PoolUpdate) {
+	for msg := range ch {
+		// Hand the message to every hook queue
+		for _, r := range registered() {
+			r.enqueue(job{update: msg})
+		}
+	}
+}
+
+// Close drains the queues of all hooks, waits for their workers and closes the hooks.
+// Hooks are closed in reverse order of registration, so sinks registered first
+// (e.g. storage observing the other hooks) are closed last.
+func Close() {
+	rs := registered()
+	for i := len(rs) - 1; i >= 0; i-- {
+		rs[i].stop()
+	}
+}
+
+// Stats returns a snapshot of the statistics of every registered hook.
+func Stats() []HookStats {
+	var stats []HookStats
+	for _, r := range registered() {
+		stats = append(stats, r.snapshot())
+	}
+	return stats
+}
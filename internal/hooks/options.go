@@ -0,0 +1,103 @@
+package hooks
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+)
+
+// OverflowPolicy decides what happens when the queue of a hook is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDropNewest drops the message that could not be queued.
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest queued message to make room.
+	OverflowDropOldest
+	// OverflowBlock waits until the queue has room again.
+	OverflowBlock
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowBlock:
+		return "block"
+	default:
+		return "drop-newest"
+	}
+}
+
+// Options configures the queue and worker pool of a single hook.
+type Options struct {
+	QueueSize int            // Buffered messages before the overflow policy kicks in
+	Workers   int            // Concurrent handler calls
+	Timeout   time.Duration  // Deadline for a single handler call
+	Overflow  OverflowPolicy // What to do when the queue is full
+
+	// OnError is called for every failed, timed out or panicking handler call.
+	OnError func(hook string, err error)
+}
+
+func DefaultOptions() Options {
+	return Options{
+		QueueSize: 64,
+		Workers:   2,
+		Timeout:   30 * time.Second,
+		Overflow:  OverflowDropOldest,
+		OnError:   logError,
+	}
+}
+
+// OptionsFromEnv returns the default options, overridden by the
+// <PREFIX>_HOOK_QUEUE_SIZE, <PREFIX>_HOOK_WORKERS, <PREFIX>_HOOK_TIMEOUT (seconds)
+// and <PREFIX>_HOOK_OVERFLOW (drop-newest, drop-oldest, block) variables.
+func OptionsFromEnv(prefix string) Options {
+	opts := DefaultOptions()
+
+	if v := utils.StI64(os.Getenv(prefix + "_HOOK_QUEUE_SIZE")); v > 0 {
+		opts.QueueSize = int(v)
+	}
+	if v := utils.StI64(os.Getenv(prefix + "_HOOK_WORKERS")); v > 0 {
+		opts.Workers = int(v)
+	}
+	if v := utils.StI64(os.Getenv(prefix + "_HOOK_TIMEOUT")); v > 0 {
+		opts.Timeout = time.Duration(v) * time.Second
+	}
+
+	switch strings.ToLower(os.Getenv(prefix + "_HOOK_OVERFLOW")) {
+	case "drop-newest":
+		opts.Overflow = OverflowDropNewest
+	case "drop-oldest":
+		opts.Overflow = OverflowDropOldest
+	case "block":
+		opts.Overflow = OverflowBlock
+	}
+
+	return opts
+}
+
+func (o Options) withDefaults() Options {
+	def := DefaultOptions()
+	if o.QueueSize <= 0 {
+		o.QueueSize = def.QueueSize
+	}
+	if o.Workers <= 0 {
+		o.Workers = def.Workers
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = def.Timeout
+	}
+	if o.OnError == nil {
+		o.OnError = def.OnError
+	}
+	return o
+}
+
+func logError(hook string, err error) {
+	color.New(color.FgRed).Printf("[%s] hook error: %v\n", hook, err)
+}
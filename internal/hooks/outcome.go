@@ -0,0 +1,68 @@
+package hooks
+
+import (
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Status is the final state of a message for a single hook.
+type Status string
+
+const (
+	StatusSent      Status = "sent"
+	StatusSkipped   Status = "skipped"
+	StatusFailed    Status = "failed"
+	StatusTimedOut  Status = "timed_out"
+	StatusDropped   Status = "dropped"
+	StatusAbandoned Status = "abandoned"
+)
+
+// Outcome describes what happened to one message in one hook.
+type Outcome struct {
+	Hook      string
+	Kind      string // pool, market or update
+	Signature solana.Signature
+	Status    Status
+	Err       error // Set for failed and timed out messages
+}
+
+var observers []func(Outcome)
+var observersMutex = &sync.RWMutex{}
+
+// Observe registers fn to be called with the outcome of every message in every hook.
+// It runs on the hook workers, so it should return quickly.
+func Observe(fn func(Outcome)) {
+	observersMutex.Lock()
+	defer observersMutex.Unlock()
+
+	observers = append(observers, fn)
+}
+
+func notify(hook string, j job, status Status, err error) {
+	observersMutex.RLock()
+	defer observersMutex.RUnlock()
+
+	if len(observers) == 0 {
+		return
+	}
+
+	o := Outcome{Hook: hook, Status: status, Err: err}
+	switch {
+	case j.pool != nil:
+		o.Kind = "pool"
+		if j.pool.Event != nil {
+			o.Signature = j.pool.Event.TxID
+		}
+	case j.market != nil:
+		o.Kind = "market"
+		o.Signature = j.market.TxID
+	case j.update != nil:
+		o.Kind = "update"
+		o.Signature = j.update.TxID
+	}
+
+	for _, fn := range observers {
+		fn(o)
+	}
+}
@@ -0,0 +1,60 @@
+package hooks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Replies remembers the message every pool was posted as, so hooks can post the updates
+// of a pool as replies to it. Messages are forgotten after ttl, at most max are kept.
+type Replies[T any] struct {
+	ttl time.Duration
+	max int
+
+	mutex    *sync.Mutex
+	messages map[solana.PublicKey]reply[T]
+}
+
+type reply[T any] struct {
+	id    T
+	added time.Time
+}
+
+func NewReplies[T any](ttl time.Duration, max int) *Replies[T] {
+	return &Replies[T]{
+		ttl:      ttl,
+		max:      max,
+		mutex:    &sync.Mutex{},
+		messages: make(map[solana.PublicKey]reply[T]),
+	}
+}
+
+// Remember stores the id of the message pool was posted as.
+func (r *Replies[T]) Remember(pool solana.PublicKey, id T) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	for key, msg := range r.messages {
+		if now.Sub(msg.added) > r.ttl || len(r.messages) >= r.max {
+			delete(r.messages, key)
+		}
+	}
+
+	r.messages[pool] = reply[T]{id: id, added: now}
+}
+
+// Lookup returns the id of the message pool was posted as, false if it is not known.
+func (r *Replies[T]) Lookup(pool solana.PublicKey) (T, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	msg, ok := r.messages[pool]
+	if !ok || time.Since(msg.added) > r.ttl {
+		var zero T
+		return zero, false
+	}
+	return msg.id, true
+}
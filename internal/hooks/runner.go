@@ -0,0 +1,188 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/OnlyF0uR/solana-monitor/internal/enrich"
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+)
+
+// HookStats is a snapshot of the counters of a single hook.
+type HookStats struct {
+	Name      string
+	Queued    int    // Messages currently waiting in the queue
+	Handled   uint64 // Successful handler calls
+	Skipped   uint64 // Handler calls that returned ErrSkipped
+	Failed    uint64 // Handler calls that returned an error or panicked
+	TimedOut  uint64 // Handler calls that exceeded the timeout
+	Dropped   uint64 // Messages dropped because of the overflow policy
+	Abandoned uint64 // Messages left unhandled because the hook was stopped
+}
+
+type job struct {
+	pool   *enrich.EnrichedPoolEvent
+	market *events.MarketEvent
+	update *events.PoolUpdate
+}
+
+type runner struct {
+	ctx   context.Context
+	hook  Hook
+	opts  Options
+	queue chan job
+
+	// Guards queue against sends after it was closed
+	mutex  sync.RWMutex
+	closed bool
+
+	wg sync.WaitGroup
+
+	handled   atomic.Uint64
+	skipped   atomic.Uint64
+	failed    atomic.Uint64
+	timedOut  atomic.Uint64
+	dropped   atomic.Uint64
+	abandoned atomic.Uint64
+}
+
+func newRunner(ctx context.Context, hook Hook, opts Options) *runner {
+	return &runner{
+		ctx:   ctx,
+		hook:  hook,
+		opts:  opts,
+		queue: make(chan job, opts.QueueSize),
+	}
+}
+
+func (r *runner) start() {
+	r.wg.Add(r.opts.Workers)
+	for i := 0; i < r.opts.Workers; i++ {
+		go func() {
+			defer r.wg.Done()
+			for j := range r.queue {
+				r.handle(j)
+			}
+		}()
+	}
+}
+
+func (r *runner) enqueue(j job) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.closed {
+		r.abandoned.Add(1)
+		notify(r.hook.Name(), j, StatusAbandoned, nil)
+		return
+	}
+
+	switch r.opts.Overflow {
+	case OverflowBlock:
+		r.queue <- j
+	case OverflowDropOldest:
+		for {
+			select {
+			case r.queue <- j:
+				return
+			default:
+			}
+
+			// Make room by discarding the oldest message
+			select {
+			case old := <-r.queue:
+				r.dropped.Add(1)
+				notify(r.hook.Name(), old, StatusDropped, nil)
+			default:
+			}
+		}
+	default:
+		select {
+		case r.queue <- j:
+		default:
+			r.dropped.Add(1)
+			notify(r.hook.Name(), j, StatusDropped, nil)
+		}
+	}
+}
+
+func (r *runner) handle(j job) {
+	if r.ctx.Err() != nil {
+		r.abandoned.Add(1)
+		notify(r.hook.Name(), j, StatusAbandoned, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, r.opts.Timeout)
+	defer cancel()
+
+	err := r.call(ctx, j)
+	switch {
+	case err == nil:
+		r.handled.Add(1)
+		notify(r.hook.Name(), j, StatusSent, nil)
+	case errors.Is(err, ErrSkipped):
+		r.skipped.Add(1)
+		notify(r.hook.Name(), j, StatusSkipped, nil)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		r.timedOut.Add(1)
+		err = fmt.Errorf("timed out after %v: %w", r.opts.Timeout, err)
+		r.opts.OnError(r.hook.Name(), err)
+		notify(r.hook.Name(), j, StatusTimedOut, err)
+	default:
+		r.failed.Add(1)
+		r.opts.OnError(r.hook.Name(), err)
+		notify(r.hook.Name(), j, StatusFailed, err)
+	}
+}
+
+func (r *runner) call(ctx context.Context, j job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+
+	switch {
+	case j.pool != nil:
+		return r.hook.HandlePool(ctx, j.pool)
+	case j.update != nil:
+		return r.hook.HandleUpdate(ctx, j.update)
+	}
+	return r.hook.HandleMarket(ctx, j.market)
+}
+
+// stop closes the queue, lets the workers drain it and closes the hook.
+// Cancel the context of the runner first to abandon the queued messages instead.
+func (r *runner) stop() {
+	r.mutex.Lock()
+	if r.closed {
+		r.mutex.Unlock()
+		return
+	}
+	r.closed = true
+	close(r.queue)
+	r.mutex.Unlock()
+
+	r.wg.Wait()
+
+	if err := r.hook.Close(); err != nil {
+		r.opts.OnError(r.hook.Name(), fmt.Errorf("failed to close: %w", err))
+	}
+}
+
+func (r *runner) snapshot() HookStats {
+	return HookStats{
+		Name:      r.hook.Name(),
+		Queued:    len(r.queue),
+		Handled:   r.handled.Load(),
+		Skipped:   r.skipped.Load(),
+		Failed:    r.failed.Load(),
+		TimedOut:  r.timedOut.Load(),
+		Dropped:   r.dropped.Load(),
+		Abandoned: r.abandoned.Load(),
+	}
+}
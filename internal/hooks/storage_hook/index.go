@@ -0,0 +1,153 @@
+package storage_hook
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/internal/enrich"
+	"github.com/OnlyF0uR/solana-monitor/internal/hooks"
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/openbook"
+	"github.com/OnlyF0uR/solana-monitor/pkg/storage"
+	"github.com/fatih/color"
+)
+
+// StorageHook writes every market and pool, with its enrichment snapshot, into the
+// event store. It also records the outcome of every other hook.
+type StorageHook struct {
+	path  string
+	store *storage.Store
+}
+
+// Initialise opens the store at STORAGE_PATH and registers the hook. Register it
+// before the other hooks, so it is closed after them and sees all their outcomes.
+func Initialise(ctx context.Context) *storage.Store {
+	path := os.Getenv("STORAGE_PATH")
+	if path == "" {
+		path = "solana-monitor.db"
+	}
+
+	hook := &StorageHook{path: path}
+
+	// Writes are cheap, but losing them defeats the purpose of the store
+	opts := hooks.OptionsFromEnv("STORAGE")
+	if os.Getenv("STORAGE_HOOK_OVERFLOW") == "" {
+		opts.Overflow = hooks.OverflowBlock
+	}
+
+	err := hooks.Register(ctx, hook, opts)
+	if err != nil {
+		panic(err)
+	}
+
+	hooks.Observe(hook.recordOutcome)
+
+	fmt.Printf("Storage hook initialised (%s)\n", path)
+
+	return hook.store
+}
+
+func (h *StorageHook) Name() string {
+	return "storage"
+}
+
+func (h *StorageHook) Init(ctx context.Context) error {
+	store, err := storage.Open(ctx, h.path)
+	if err != nil {
+		return err
+	}
+
+	h.store = store
+	return nil
+}
+
+func (h *StorageHook) Close() error {
+	return h.store.Close()
+}
+
+func (h *StorageHook) HandlePool(ctx context.Context, msg *enrich.EnrichedPoolEvent) error {
+	// The store keeps the decoded pools, with the accounts of every venue
+	pool := storedPool(msg.Event.Source)
+	if pool == nil {
+		return hooks.ErrSkipped
+	}
+
+	inserted, err := h.store.SavePool(ctx, pool)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		return hooks.ErrSkipped // Already stored, e.g. seen again after a restart
+	}
+
+	snapshot := storage.Enrichment{
+		Signature:              msg.Event.TxID.String(),
+		Mint:                   msg.Event.BaseMint.String(),
+		CapturedAt:             time.Now(),
+		TokenData:              msg.TokenData,
+		TokenMeta:              msg.TokenMeta,
+		MintAuthorityEnabled:   msg.MintAuthorityEnabled,
+		FreezeAuthorityEnabled: msg.FreezeAuthorityEnabled,
+		CreatorBalance:         msg.CreatorBalance,
+	}
+	if msg.TopHolders != nil {
+		snapshot.TopHolders = *msg.TopHolders
+	}
+	if len(msg.Errors) > 0 {
+		snapshot.Errors = make(map[string]string, len(msg.Errors))
+		for step, err := range msg.Errors {
+			snapshot.Errors[step] = err.Error()
+		}
+	}
+
+	return h.store.SaveEnrichment(ctx, snapshot)
+}
+
+func (h *StorageHook) HandleMarket(ctx context.Context, msg *events.MarketEvent) error {
+	info, ok := msg.Source.(*openbook.OpenbookInfo)
+	if !ok {
+		return hooks.ErrSkipped
+	}
+
+	inserted, err := h.store.SaveMarket(ctx, info)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		return hooks.ErrSkipped
+	}
+	return nil
+}
+
+// HandleUpdate stores nothing, the updates are only recorded through the outcomes of the other hooks.
+func (h *StorageHook) HandleUpdate(ctx context.Context, msg *events.PoolUpdate) error {
+	return hooks.ErrSkipped
+}
+
+// recordOutcome stores the outcome of the other hooks, it runs on their workers.
+func (h *StorageHook) recordOutcome(o hooks.Outcome) {
+	if o.Hook == h.Name() {
+		return
+	}
+
+	n := storage.Notification{
+		Signature: o.Signature.String(),
+		Kind:      o.Kind,
+		Hook:      o.Hook,
+		Status:    string(o.Status),
+		CreatedAt: time.Now(),
+	}
+	if o.Err != nil {
+		n.Error = o.Err.Error()
+	}
+
+	// Not bound to the hook context, abandoned messages are recorded during shutdown as well
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.SaveNotification(ctx, n); err != nil && os.Getenv("DEBUG") == "1" {
+		color.New(color.FgYellow).Printf("[storage] failed to record %s outcome: %v\n", o.Hook, err)
+	}
+}
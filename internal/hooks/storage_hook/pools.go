@@ -0,0 +1,85 @@
+package storage_hook
+
+import (
+	"github.com/OnlyF0uR/solana-monitor/pkg/meteora"
+	"github.com/OnlyF0uR/solana-monitor/pkg/orca"
+	"github.com/OnlyF0uR/solana-monitor/pkg/raydium"
+	"github.com/OnlyF0uR/solana-monitor/pkg/storage"
+)
+
+// storedPool converts what a parser decoded into a row of the store, nil for unknown sources.
+func storedPool(source any) *storage.Pool {
+	switch info := source.(type) {
+	case *raydium.RaydiumInfo:
+		return &storage.Pool{
+			TxID:            info.TxID,
+			ProgramID:       info.ProgramID,
+			Pool:            info.AmmID,
+			OpenOrders:      info.AmmOpenOrders,
+			LPMint:          info.LPTokenAddress,
+			BaseMint:        info.BaseMint,
+			QuoteMint:       info.QuoteMint,
+			BaseVault:       info.PoolCoinTokenAccount,
+			QuoteVault:      info.PoolPcTokenAccount,
+			TargetOrders:    info.AmmTargetOrders,
+			LPAccount:       info.AmmLiquidityCreator,
+			SerumMarket:     info.SerumMarket,
+			BaseLiquidity:   info.BaseMintLiquidity,
+			QuoteLiquidity:  info.QuoteMintLiquidity,
+			InitBaseAmount:  info.Metadata.InitCoinAmount,
+			InitQuoteAmount: info.Metadata.InitPcAmount,
+			Nonce:           info.Metadata.Nonce,
+			OpenTime:        info.Metadata.OpenTime,
+			Caller:          info.Caller,
+			Slot:            info.Slot,
+			TxTime:          info.TxTime,
+			Timestamp:       info.Timestamp,
+			Swapped:         info.Swapped,
+		}
+	case *meteora.MeteoraInfo:
+		return &storage.Pool{
+			TxID:            info.TxID,
+			ProgramID:       info.ProgramID,
+			Pool:            info.Pool,
+			LPMint:          info.LPMint,
+			BaseMint:        info.BaseMint,
+			QuoteMint:       info.QuoteMint,
+			BaseVault:       info.BaseVault,
+			QuoteVault:      info.QuoteVault,
+			LPAccount:       info.LPAccount,
+			BaseLiquidity:   info.BaseLiquidity,
+			QuoteLiquidity:  info.QuoteLiquidity,
+			InitBaseAmount:  info.InitBaseAmount,
+			InitQuoteAmount: info.InitQuoteAmount,
+			OpenTime:        uint64(info.Timestamp.Unix()), // Trading starts right away
+			Caller:          info.Caller,
+			Slot:            info.Slot,
+			TxTime:          info.TxTime,
+			Timestamp:       info.Timestamp,
+			Swapped:         info.Swapped,
+		}
+	case *orca.WhirlpoolInfo:
+		return &storage.Pool{
+			TxID:            info.TxID,
+			ProgramID:       info.ProgramID,
+			Pool:            info.Pool,
+			LPMint:          info.LPMint,
+			BaseMint:        info.BaseMint,
+			QuoteMint:       info.QuoteMint,
+			BaseVault:       info.BaseVault,
+			QuoteVault:      info.QuoteVault,
+			LPAccount:       info.LPAccount,
+			BaseLiquidity:   info.BaseLiquidity,
+			QuoteLiquidity:  info.QuoteLiquidity,
+			InitBaseAmount:  info.InitBaseAmount,
+			InitQuoteAmount: info.InitQuoteAmount,
+			OpenTime:        uint64(info.OpenTime().Unix()),
+			Caller:          info.Caller,
+			Slot:            info.Slot,
+			TxTime:          info.TxTime,
+			Timestamp:       info.Timestamp,
+			Swapped:         info.Swapped,
+		}
+	}
+	return nil
+}
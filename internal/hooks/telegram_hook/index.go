@@ -6,43 +6,80 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/OnlyF0uR/solana-monitor/internal/hooks"
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
 	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
 	"github.com/gagliardetto/solana-go"
 	"github.com/go-telegram/bot"
 )
 
-var telegram *bot.Bot
-var chatId string
+type TelegramHook struct {
+	botToken string
+	chatId   string
+	telegram *bot.Bot
 
-func Initialise() {
+	// Pool messages, the updates of a pool reply to them
+	replies *hooks.Replies[int]
+}
+
+func Initialise(ctx context.Context) {
 	botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
 	if botToken == "" {
 		panic("TELEGRAM_BOT_TOKEN not set")
 	}
 
-	chatId = os.Getenv("TELEGRAM_CHAT_ID")
+	chatId := os.Getenv("TELEGRAM_CHAT_ID")
 	if chatId == "" {
 		panic("TELEGRAM_CHAT_ID not set")
 	}
 
-	b, err := bot.New(botToken)
+	hook := &TelegramHook{
+		botToken: botToken,
+		chatId:   chatId,
+		replies:  hooks.NewReplies[int](24*time.Hour, 10_000),
+	}
+
+	err := hooks.Register(ctx, hook, hooks.OptionsFromEnv("TELEGRAM"))
 	if err != nil {
 		panic(err)
 	}
 
-	telegram = b
+	fmt.Printf("Telegram hook initialised\n")
+}
 
-	hooks.RegisterOpenbookHook(tg_openbook_hook)
-	hooks.RegisterRaydiumHook(tg_raydium_hook)
+func (h *TelegramHook) Name() string {
+	return "telegram"
+}
 
-	fmt.Printf("Telegram hook initialised\n")
+func (h *TelegramHook) Init(ctx context.Context) error {
+	b, err := bot.New(h.botToken)
+	if err != nil {
+		return err
+	}
+
+	h.telegram = b
+	return nil
+}
+
+func (h *TelegramHook) Close() error {
+	return nil
 }
 
-func getHolderString(ctx context.Context, mint solana.PublicKey, poolCoinTokenAccount solana.PublicKey, supply float64, liquidity float64) string {
-	topHolders := utils.GetTopHolders_S(ctx, mint)
+// extraLines renders every extra as escaped "Name: Value" lines, links are left out.
+func extraLines(extras []events.Extra) string {
+	var lines string
+	for _, extra := range extras {
+		for _, field := range extra.Fields() {
+			lines += "\n" + bot.EscapeMarkdown(field.Name+": "+field.Value)
+		}
+	}
+	return lines
+}
 
+// getHolderString lists the top holders, headed by the share held by the pool of dex.
+func getHolderString(dex string, topHolders *[]utils.TopHolder, poolCoinTokenAccount solana.PublicKey, supply float64, liquidity float64) string {
 	var topHolderRaydiumAmount string
 	var topHoldersStr string
 	if topHolders == nil {
@@ -53,7 +90,7 @@ func getHolderString(ctx context.Context, mint solana.PublicKey, poolCoinTokenAc
 
 			var address = "[" + bot.EscapeMarkdown(holder.PublicKey.Short(6)) + "](https://solscan.io/account/" + holder.PublicKey.String() + ")"
 			if holder.PublicKey == poolCoinTokenAccount {
-				topHolderRaydiumAmount = "*Raydium: " + strings.Replace(strconv.FormatFloat(supplyPct, 'f', 2, 64), ".", "\\.", 1) + "%*\n\n"
+				topHolderRaydiumAmount = "*" + dex + ": " + strings.Replace(strconv.FormatFloat(supplyPct, 'f', 2, 64), ".", "\\.", 1) + "%*\n\n"
 				address = address + " \\(LP\\)"
 			}
 			if i < 5 {
@@ -67,7 +104,7 @@ func getHolderString(ctx context.Context, mint solana.PublicKey, poolCoinTokenAc
 	}
 
 	if topHolderRaydiumAmount == "" {
-		topHolderRaydiumAmount = "*Raydium: " + strings.Replace(strconv.FormatFloat((liquidity/supply)*100, 'f', 2, 64), ".", "\\.", 1) + " SOL" + "%*\n\n"
+		topHolderRaydiumAmount = "*" + dex + ": " + strings.Replace(strconv.FormatFloat((liquidity/supply)*100, 'f', 2, 64), ".", "\\.", 1) + " SOL" + "%*\n\n"
 	}
 
 	return topHolderRaydiumAmount + topHoldersStr
@@ -6,21 +6,22 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/OnlyF0uR/solana-monitor/pkg/openbook"
+	"github.com/OnlyF0uR/solana-monitor/internal/hooks"
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
 	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 )
 
-func tg_openbook_hook(msg *openbook.OpenbookInfo, ctx context.Context) {
+func (h *TelegramHook) HandleMarket(ctx context.Context, msg *events.MarketEvent) error {
 	baseTokenData, baseTokenMeta := utils.TokenHelper(ctx, msg.BaseMint)
 	if baseTokenData == nil || baseTokenMeta == nil {
-		return
+		return hooks.ErrSkipped
 	}
 
 	tokenBSymbol := utils.TokenToSymbol(msg.QuoteMint)
 
-	balance := utils.GetBalance_S(ctx, msg.Caller)
+	balance := utils.GetBalance_S(ctx, msg.Creator)
 
 	var titleEmoji = "🟢"
 	if msg.Costs < 2 {
@@ -55,10 +56,15 @@ func tg_openbook_hook(msg *openbook.OpenbookInfo, ctx context.Context) {
 		socialsStr = "\n\n*Socials*" + socialsStr
 	}
 
+	header := bot.EscapeMarkdown(strings.ToUpper(msg.Label())) + " MARKET"
+
+	// Venue specific information
+	titleStr += extraLines(msg.Extras)
+
 	linkPreviewDisabled := false
-	_, err := telegram.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: chatId,
-		Text:   fmt.Sprintf("*\\[OPENBOOK MARKET\\]*\n%s\n\n*Token Address*\n`%s`\n*Market Id*\n`%s`\n*Creator Address* \\(%s\\)\n`%s`\n\n*Token Description*\n%s%s", titleStr, msg.BaseMint.String(), msg.Market.String(), creatorBalanceStr, msg.Caller.String(), baseTokenMeta.Description, socialsStr),
+	_, err := h.telegram.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: h.chatId,
+		Text:   fmt.Sprintf("*\\[%s\\]*\n%s\n\n*Token Address*\n`%s`\n*Market Id*\n`%s`\n*Creator Address* \\(%s\\)\n`%s`\n\n*Token Description*\n%s%s", header, titleStr, msg.BaseMint.String(), msg.Market.String(), creatorBalanceStr, msg.Creator.String(), baseTokenMeta.Description, socialsStr),
 		LinkPreviewOptions: &models.LinkPreviewOptions{
 			IsDisabled: &linkPreviewDisabled,
 		},
@@ -78,6 +84,8 @@ func tg_openbook_hook(msg *openbook.OpenbookInfo, ctx context.Context) {
 		},
 	})
 	if err != nil {
-		fmt.Printf("Error sending telegram message: %v\n", err)
+		return fmt.Errorf("error sending telegram message: %w", err)
 	}
+
+	return nil
 }
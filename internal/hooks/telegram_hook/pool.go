@@ -0,0 +1,119 @@
+package telegram_hook
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/OnlyF0uR/solana-monitor/internal/enrich"
+	"github.com/OnlyF0uR/solana-monitor/internal/hooks"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+func (h *TelegramHook) HandlePool(ctx context.Context, ev *enrich.EnrichedPoolEvent) error {
+	msg := ev.Event
+	if ev.TokenData == nil || ev.TokenMeta == nil {
+		return hooks.ErrSkipped
+	}
+
+	// String corrections (the event is shared, so escape into local copies)
+	baseTokenSymbol := bot.EscapeMarkdown(ev.TokenData.Data.Symbol)
+	baseTokenDescription := bot.EscapeMarkdown(ev.TokenMeta.Description)
+	tokenBSymbol := bot.EscapeMarkdown(utils.TokenToSymbol(msg.QuoteMint))
+	baseTokenMeta := ev.TokenMeta
+
+	// Manual escaping
+	creatorBalanceStr := strings.Replace(strconv.FormatFloat(ev.CreatorBalance, 'f', 3, 64), ".", "\\.", 1) + " SOL"
+
+	// Create the string of the liquidity
+	liquidityStr := strconv.FormatFloat(msg.BaseLiquidity, 'f', 0, 64) + " " + baseTokenSymbol + " / " + strconv.FormatFloat(msg.QuoteLiquidity, 'f', 1, 64) + " " + tokenBSymbol
+
+	// Authority strings
+	mintAuthStr := "🔴 *Enabled* 🔴"
+	freezeAuthStr := "🔴 *Enabled* 🔴"
+	if !ev.MintAuthorityEnabled {
+		mintAuthStr = "🟢 *Disabled* 🟢"
+	}
+	if !ev.FreezeAuthorityEnabled {
+		freezeAuthStr = "🟢 *Disabled* 🟢"
+	}
+
+	// Colour and emoji
+	var costs float64 = 0
+	var costsStr = "N/A ⚪"
+
+	// Openbook info if available
+	if ev.Market != nil {
+		costs = ev.Market.Costs
+		if costs < 0.5 {
+			costsStr = strings.Replace(strconv.FormatFloat(costs, 'f', 3, 64), ".", "\\.", 1) + " 🔴"
+		} else if costs < 2 {
+			costsStr = strings.Replace(strconv.FormatFloat(costs, 'f', 3, 64), ".", "\\.", 1) + " 🟠"
+		} else {
+			costsStr = strings.Replace(strconv.FormatFloat(costs, 'f', 3, 64), ".", "\\.", 1) + " 🟢"
+		}
+	}
+
+	titleStr := bot.EscapeMarkdown("Pair: "+baseTokenSymbol+" / "+tokenBSymbol+"\nCosts: "+costsStr+"\nLiquidity: "+liquidityStr) + "\nToken Mint Auth: " + mintAuthStr + "\nToken Freeze Auth: " + freezeAuthStr
+
+	// Token-2022 extensions that let the issuer take or lock tokens
+	for _, warning := range ev.TokenData.Extensions.Warnings() {
+		titleStr += "\n⚠️ " + bot.EscapeMarkdown(warning)
+	}
+
+	// Venue specific information
+	titleStr += extraLines(msg.Extras)
+
+	// Get top holder string
+	topHoldersStr := getHolderString(string(msg.Venue), ev.TopHolders, msg.BaseVault, ev.Supply, msg.BaseLiquidity)
+
+	var socialsStr string = ""
+	if baseTokenMeta.Telegram != "" {
+		socialsStr += "\nTelegram: " + utils.SocialtS(baseTokenMeta.Telegram)
+	} else if baseTokenMeta.Twitter != "" {
+		socialsStr += "\nTwitter: " + utils.SocialtS(baseTokenMeta.Twitter)
+	} else if baseTokenMeta.Website != "" {
+		socialsStr += "\nWebsite: " + utils.SocialtS(baseTokenMeta.Website)
+	}
+	if socialsStr != "" {
+		socialsStr = bot.EscapeMarkdown(socialsStr)
+		socialsStr = "\n\n*Socials*" + socialsStr
+	}
+
+	poolStr := bot.EscapeMarkdown(strings.ToUpper(msg.Label())) + " POOL"
+	if msg.Origin != "" {
+		poolStr = strings.ToUpper(bot.EscapeMarkdown(msg.Origin)) + " → " + poolStr
+	}
+
+	linkPreviewDisabled := false
+	sent, err := h.telegram.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: h.chatId,
+		Text:   fmt.Sprintf("*\\[%s\\]*\n%s\n\n*Pair Address*\n`%s`\n*Token Address*\n`%s`\n*Creator Address* \\(%s\\)\n`%s`\n\n*Token Description*\n%s%s\n\n*Holders*\n%s", poolStr, titleStr, msg.Pool.String(), msg.BaseMint.String(), creatorBalanceStr, msg.Creator.String(), baseTokenDescription, socialsStr, topHoldersStr),
+		LinkPreviewOptions: &models.LinkPreviewOptions{
+			IsDisabled: &linkPreviewDisabled,
+		},
+		ParseMode: models.ParseModeMarkdown,
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{
+					{Text: "Token - Solscan", URL: "https://solscan.io/account/" + msg.BaseMint.String()},
+					{Text: "Market - Solscan", URL: "https://solscan.io/account/" + msg.Pool.String()},
+					{Text: "Tx - Solscan", URL: "https://solscan.io/tx/" + msg.TxID.String()},
+				},
+				{
+					{Text: "RugCheck", URL: "https://rugcheck.xyz/tokens/" + msg.BaseMint.String()},
+					{Text: "BirdEye", URL: "https://birdeye.so/token/" + msg.BaseMint.String()},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error sending telegram message: %w", err)
+	}
+	h.replies.Remember(msg.Pool, sent.ID)
+
+	return nil
+}
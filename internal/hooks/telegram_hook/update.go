@@ -0,0 +1,47 @@
+package telegram_hook
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+func (h *TelegramHook) HandleUpdate(ctx context.Context, msg *events.PoolUpdate) error {
+	header := bot.EscapeMarkdown(strings.ToUpper(msg.Pool.Label() + " " + string(msg.Kind)))
+	summary := bot.EscapeMarkdown(msg.Summary())
+	if msg.Kind == events.UpdateLiquidityRemoved {
+		summary += "\n" + bot.EscapeMarkdown(strconv.FormatFloat(msg.Amount, 'f', 1, 64)+" "+utils.TokenToSymbol(msg.Pool.QuoteMint)+" taken out")
+	}
+
+	params := &bot.SendMessageParams{
+		ChatID:    h.chatId,
+		Text:      fmt.Sprintf("*\\[%s\\]*\n%s\n\n*Pair Address*\n`%s`\n*Token Address*\n`%s`\n*Wallet*\n`%s`", header, summary, msg.Pool.Pool.String(), msg.Pool.BaseMint.String(), msg.Wallet.String()),
+		ParseMode: models.ParseModeMarkdown,
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{
+					{Text: "Tx - Solscan", URL: "https://solscan.io/tx/" + msg.TxID.String()},
+					{Text: "RugCheck", URL: "https://rugcheck.xyz/tokens/" + msg.Pool.BaseMint.String()},
+				},
+			},
+		},
+	}
+
+	// Reply to the message of the pool when it was posted by this instance
+	if id, ok := h.replies.Lookup(msg.Pool.Pool); ok {
+		params.ReplyParameters = &models.ReplyParameters{MessageID: id, AllowSendingWithoutReply: true}
+	}
+
+	_, err := h.telegram.SendMessage(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error sending telegram message: %w", err)
+	}
+
+	return nil
+}
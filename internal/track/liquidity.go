@@ -0,0 +1,158 @@
+package track
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// pollVault compares the quote vault to the highest balance since the creation, so
+// liquidity taken out over several polls adds up. Once it dropped by at least
+// Options.Removal percent, the new transactions are looked up, and the pool is reported
+// when the withdrawals among them took out that much.
+func (t *Tracker) pollVault(ctx context.Context, p *trackedPool, vault *rpc.Account) *events.PoolUpdate {
+	quote := tokenAmount(vault)
+	p.peak = max(p.peak, quote)
+	if p.peak == 0 || quote >= p.quote || float64(p.peak-quote)/float64(p.peak)*100 < t.opts.Removal {
+		p.quote = quote
+		return nil // Buys, sells and small withdrawals
+	}
+
+	signatures, err := newSignatures(ctx, p, []solana.PublicKey{p.event.QuoteVault}, p.vaultSeen)
+	var update *events.PoolUpdate
+	var decimals uint8
+	removed := p.removed
+	for _, sig := range signatures {
+		var rpcTx *rpc.GetTransactionResult
+		var tx *solana.Transaction
+		rpcTx, tx, err = utils.GetConfirmedTransaction_S(ctx, sig)
+		if err != nil {
+			break
+		}
+
+		if amount, d, u := removal(p, rpcTx, tx); u != nil {
+			update, decimals, removed = u, d, removed+amount
+		}
+	}
+	if err != nil {
+		color.New(color.FgYellow).Printf("[TRACK] pollVault -> Failed to look into the quote vault of %s: %v\n", p.event.Pool, err)
+		return nil // Retried on the next poll
+	}
+
+	for _, sig := range signatures {
+		p.vaultSeen[sig] = true
+	}
+	p.quote, p.removed = quote, removed
+
+	share := float64(removed) / float64(p.peak) * 100
+	if update == nil || share < t.opts.Removal {
+		return nil // Mostly sells, or taken out by transactions that are not listed anymore
+	}
+	// Reported with the last withdrawal, for everything taken out so far
+	update.Share = min(share, 100)
+	update.Amount = float64(removed) / math.Pow10(int(decimals))
+	p.vault = false // Reported once, what is left is not worth an alert
+
+	return update
+}
+
+// removal returns how much quote a withdrawal took out of the vault, with its decimals and
+// the wallet that received it. The update is nil for anything but a withdrawal.
+func removal(p *trackedPool, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) (uint64, uint8, *events.PoolUpdate) {
+	if rpcTx.Meta == nil || len(tx.Signatures) == 0 {
+		return 0, 0, nil
+	}
+	keys := utils.AccountKeys(rpcTx, tx)
+
+	pre := tokenBalances(keys, rpcTx.Meta.PreTokenBalances, p.event.QuoteMint)
+	post := tokenBalances(keys, rpcTx.Meta.PostTokenBalances, p.event.QuoteMint)
+	if pre[p.event.QuoteVault] <= post[p.event.QuoteVault] {
+		return 0, 0, nil
+	}
+	removed := pre[p.event.QuoteVault] - post[p.event.QuoteVault]
+
+	// Only the pool program moves the vault's tokens
+	if _, ok := invokedPrograms(keys, rpcTx, tx)[p.event.ProgramID]; !ok {
+		return 0, 0, nil
+	}
+
+	// Sells take quote out as well, but put base in. Withdrawals do not, and burn the LP
+	// tokens of pools that have fungible ones.
+	base := p.event.BaseVault
+	if tokenBalances(keys, rpcTx.Meta.PostTokenBalances, p.event.BaseMint)[base] > tokenBalances(keys, rpcTx.Meta.PreTokenBalances, p.event.BaseMint)[base] {
+		return 0, 0, nil
+	}
+	lpPre := tokenBalances(keys, rpcTx.Meta.PreTokenBalances, p.event.LPMint)
+	lpPost := tokenBalances(keys, rpcTx.Meta.PostTokenBalances, p.event.LPMint)
+	if fungibleLP(rpcTx, p.event.LPMint) && total(lpPre) <= total(lpPost) {
+		return 0, 0, nil
+	}
+
+	// The funds end up in the token account that gained the most
+	var receiver solana.PublicKey
+	var decimals uint8
+	var gained uint64
+	for _, balance := range rpcTx.Meta.PostTokenBalances {
+		if balance.Mint != p.event.QuoteMint || int(balance.AccountIndex) >= len(keys) || balance.UiTokenAmount == nil {
+			continue
+		}
+		decimals = balance.UiTokenAmount.Decimals
+
+		account := keys[balance.AccountIndex]
+		amount, err := strconv.ParseUint(balance.UiTokenAmount.Amount, 10, 64)
+		if err != nil || account == p.event.QuoteVault || amount <= pre[account] || amount-pre[account] <= gained {
+			continue
+		}
+		gained = amount - pre[account]
+		if balance.Owner != nil {
+			receiver = *balance.Owner
+		}
+	}
+
+	// Or as SOL, when the wrapped SOL account was closed in the same transaction
+	if receiver.IsZero() {
+		var lamports uint64
+		for i, key := range keys {
+			if i >= len(rpcTx.Meta.PreBalances) || i >= len(rpcTx.Meta.PostBalances) {
+				break
+			}
+			if rpcTx.Meta.PostBalances[i] > rpcTx.Meta.PreBalances[i] && rpcTx.Meta.PostBalances[i]-rpcTx.Meta.PreBalances[i] > lamports {
+				lamports = rpcTx.Meta.PostBalances[i] - rpcTx.Meta.PreBalances[i]
+				receiver = key
+			}
+		}
+	}
+
+	return removed, decimals, &events.PoolUpdate{
+		Kind:      events.UpdateLiquidityRemoved,
+		Pool:      p.event,
+		Amount:    float64(removed) / math.Pow10(int(decimals)),
+		Wallet:    receiver,
+		TxID:      tx.Signatures[0],
+		Slot:      rpcTx.Slot,
+		TxTime:    utils.BlockTime(rpcTx),
+		Timestamp: time.Now(),
+	}
+}
+
+// fungibleLP reports whether tx moved LP tokens of mint, position NFTs have no decimals.
+func fungibleLP(rpcTx *rpc.GetTransactionResult, mint solana.PublicKey) bool {
+	if mint.IsZero() {
+		return false
+	}
+	for _, balances := range [][]rpc.TokenBalance{rpcTx.Meta.PreTokenBalances, rpcTx.Meta.PostTokenBalances} {
+		for _, balance := range balances {
+			if balance.Mint == mint && balance.UiTokenAmount != nil && balance.UiTokenAmount.Decimals > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package track
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type locker struct {
+	name string
+	// unlockTime decodes when the tokens unlock from the instruction that locked them,
+	// zero if it is unknown.
+	unlockTime func(data []byte) time.Time
+}
+
+// Programs whose locks are recognised, only Streamflow for now. LP tokens sent to any other
+// locker look like a transfer and are not reported.
+var lockers = map[solana.PublicKey]locker{
+	solana.MustPublicKeyFromBase58(utils.STREAMFLOW_PROGRAM_ID): {"Streamflow", streamflowUnlockTime},
+}
+
+// Streamflow create instruction: tag 0, then start_time, net_amount_deposited, period,
+// amount_per_period, cliff and cliff_amount, all u64.
+const streamflowCreateTag = 0
+
+// streamflowUnlockTime returns when the last tokens of a stream unlock. LP locks release
+// everything at the cliff, vesting streams release the rest every period after it.
+func streamflowUnlockTime(data []byte) time.Time {
+	if len(data) < 1+6*8 || data[0] != streamflowCreateTag {
+		return time.Time{}
+	}
+
+	arg := func(i int) uint64 {
+		return binary.LittleEndian.Uint64(data[1+i*8:])
+	}
+	start, net, period, perPeriod, cliff, cliffAmount := arg(0), arg(1), arg(2), arg(3), arg(4), arg(5)
+
+	end := max(start, cliff)
+	if net > cliffAmount && perPeriod > 0 {
+		end += (net - cliffAmount + perPeriod - 1) / perPeriod * period
+	}
+	return time.Unix(int64(end), 0)
+}
+
+// pollLP compares the LP supply and the LP tokens left with the creator to the last poll,
+// and looks up the transactions when either went down.
+func (t *Tracker) pollLP(ctx context.Context, p *trackedPool, mint, account *rpc.Account) []*events.PoolUpdate {
+	if mint == nil {
+		p.lp = false
+		return nil
+	}
+
+	mintData := mint.Data.GetBinary()
+	if len(mintData) <= mintDecimalsOffset || mintData[mintDecimalsOffset] == 0 {
+		p.lp = false // Position NFT of a concentrated liquidity pool, closing the position burns it
+		return nil
+	}
+	supply := binary.LittleEndian.Uint64(mintData[mintSupplyOffset:])
+	balance := tokenAmount(account)
+
+	var addresses []solana.PublicKey
+	if balance < p.balance {
+		addresses = append(addresses, p.event.LPAccount)
+	}
+	// Not known before the first poll, the creator's LP tokens cover the burns up to then
+	if p.supply > 0 && supply < p.supply {
+		addresses = append(addresses, p.event.LPMint)
+	}
+
+	var updates []*events.PoolUpdate
+	if len(addresses) > 0 {
+		signatures, err := newSignatures(ctx, p, addresses, p.lpSeen)
+		for _, sig := range signatures {
+			var rpcTx *rpc.GetTransactionResult
+			var tx *solana.Transaction
+			rpcTx, tx, err = utils.GetConfirmedTransaction_S(ctx, sig)
+			if err != nil {
+				break
+			}
+			p.lpSeen[sig] = true
+
+			if update := classify(p, rpcTx, tx); update != nil {
+				updates = append(updates, update)
+			}
+		}
+		if err != nil {
+			color.New(color.FgYellow).Printf("[TRACK] pollLP -> Failed to look into the LP tokens of %s: %v\n", p.event.Pool, err)
+			return updates // Retried on the next poll, the transactions already looked at are skipped
+		}
+	}
+
+	p.supply, p.balance = supply, balance
+	if balance == 0 {
+		p.lp = false // Nothing left for the creator to burn or lock
+	}
+
+	return updates
+}
+
+// invokedPrograms returns the programs tx invoked, directly or through another program,
+// with the data of their first instruction.
+func invokedPrograms(keys solana.PublicKeySlice, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) map[solana.PublicKey][]byte {
+	invoked := make(map[solana.PublicKey][]byte)
+	invoke := func(instr solana.CompiledInstruction) {
+		if int(instr.ProgramIDIndex) >= len(keys) {
+			return
+		}
+		if _, ok := invoked[keys[instr.ProgramIDIndex]]; !ok {
+			invoked[keys[instr.ProgramIDIndex]] = instr.Data
+		}
+	}
+	for _, instr := range tx.Message.Instructions {
+		invoke(instr)
+	}
+	for _, inner := range rpcTx.Meta.InnerInstructions {
+		for _, instr := range inner.Instructions {
+			invoke(instr)
+		}
+	}
+	return invoked
+}
+
+// classify tells burns and locks of the LP tokens apart, nil for anything else.
+func classify(p *trackedPool, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) *events.PoolUpdate {
+	if rpcTx.Meta == nil || len(tx.Signatures) == 0 {
+		return nil
+	}
+	keys := utils.AccountKeys(rpcTx, tx)
+	invoked := invokedPrograms(keys, rpcTx, tx)
+
+	// Withdrawing liquidity burns the LP tokens as well
+	if _, ok := invoked[p.event.ProgramID]; ok {
+		return nil
+	}
+
+	pre := tokenBalances(keys, rpcTx.Meta.PreTokenBalances, p.event.LPMint)
+	post := tokenBalances(keys, rpcTx.Meta.PostTokenBalances, p.event.LPMint)
+
+	update := &events.PoolUpdate{
+		Pool:      p.event,
+		Wallet:    keys[0],
+		TxID:      tx.Signatures[0],
+		Slot:      rpcTx.Slot,
+		TxTime:    utils.BlockTime(rpcTx),
+		Timestamp: time.Now(),
+	}
+
+	for program, l := range lockers {
+		data, ok := invoked[program]
+		if !ok {
+			continue
+		}
+
+		if pre[p.event.LPAccount] <= post[p.event.LPAccount] {
+			return nil // Locked someone else's LP tokens
+		}
+		update.Kind = events.UpdateLPLocked
+		update.Share = lpShare(pre[p.event.LPAccount]-post[p.event.LPAccount], p.minted)
+		update.Locker = l.name
+		update.Until = l.unlockTime(data)
+		return update
+	}
+
+	preTotal, postTotal := total(pre), total(post)
+	if preTotal <= postTotal {
+		return nil // Transferred, the LP tokens are still around
+	}
+
+	update.Kind = events.UpdateLPBurned
+	update.Share = lpShare(preTotal-postTotal, p.minted)
+	return update
+}
+
+func lpShare(amount, minted uint64) float64 {
+	return min(float64(amount)/float64(minted)*100, 100)
+}
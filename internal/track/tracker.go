@@ -0,0 +1,326 @@
+// Package track follows reported pools for a while and reports what happens to them
+// afterwards, e.g. the creator burning the LP tokens or pulling the liquidity.
+package track
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Offsets in the token program accounts, Token-2022 accounts start the same way
+const (
+	mintSupplyOffset   = 36 // After the mint authority option
+	mintDecimalsOffset = 44
+	tokenAmountOffset  = 64 // After the mint and owner
+)
+
+// Pools are tracked for Options.Window, at most trackMax at a time.
+const trackMax = 5_000
+
+// Signatures fetched per request when something changed, older ones are paged in until the
+// transactions already looked at.
+const signaturesLimit = 25
+
+type Options struct {
+	Window   time.Duration
+	Interval time.Duration
+	LP       bool    // Report LP burns and locks
+	Removal  float64 // Report removals of this many percent of the peak quote liquidity, 0 to not follow the vaults
+}
+
+type trackedPool struct {
+	event  *events.PoolEvent
+	added  time.Time
+	polled bool // The balances after the creation are known
+
+	// LP tokens, lp is false once they are not followed anymore
+	lp      bool
+	minted  uint64 // LP tokens the creator received
+	supply  uint64 // LP supply at the last poll
+	balance uint64 // LP tokens left with the creator at the last poll
+	lpSeen  map[solana.Signature]bool
+
+	// Quote vault, vault is false once it is not followed anymore
+	vault     bool
+	quote     uint64 // Quote vault balance at the last poll
+	peak      uint64 // Highest quote vault balance since the creation
+	removed   uint64 // Quote taken out by the removals found so far
+	vaultSeen map[solana.Signature]bool
+}
+
+// Tracker polls the LP mint, the LP account and the quote vault of every tracked pool in one
+// batch. Burns and locks of the LP tokens and liquidity removals are sent as pool updates.
+type Tracker struct {
+	opts Options
+
+	mutex *sync.Mutex
+	pools map[solana.PublicKey]*trackedPool
+}
+
+func NewTracker(opts Options) *Tracker {
+	return &Tracker{
+		opts:  opts,
+		mutex: &sync.Mutex{},
+		pools: make(map[solana.PublicKey]*trackedPool),
+	}
+}
+
+// Track starts following the pool, pools with nothing to follow are ignored.
+func (t *Tracker) Track(ev *events.PoolEvent) {
+	p := &trackedPool{
+		event:     ev,
+		added:     time.Now(),
+		lp:        t.opts.LP && !ev.LPMint.IsZero() && !ev.LPAccount.IsZero(),
+		vault:     t.opts.Removal > 0 && !ev.QuoteVault.IsZero() && !ev.SharedVaults,
+		lpSeen:    make(map[solana.Signature]bool),
+		vaultSeen: make(map[solana.Signature]bool),
+	}
+	if !p.lp && !p.vault {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if _, ok := t.pools[ev.Pool]; ok || len(t.pools) >= trackMax {
+		return
+	}
+	t.pools[ev.Pool] = p
+}
+
+// Tracked returns the number of pools being followed.
+func (t *Tracker) Tracked() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return len(t.pools)
+}
+
+// Run polls the tracked pools every interval until ctx is cancelled.
+func (t *Tracker) Run(ctx context.Context, ch chan<- *events.PoolUpdate) {
+	ticker := time.NewTicker(t.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, update := range t.poll(ctx) {
+			select {
+			case ch <- update:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// snapshot returns the pools still within the window, oldest first.
+func (t *Tracker) snapshot() []*trackedPool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	pools := make([]*trackedPool, 0, len(t.pools))
+	for key, p := range t.pools {
+		if time.Since(p.added) > t.opts.Window {
+			delete(t.pools, key)
+			continue
+		}
+		pools = append(pools, p)
+	}
+
+	sort.Slice(pools, func(i, j int) bool {
+		return pools[i].added.Before(pools[j].added)
+	})
+	return pools
+}
+
+func (t *Tracker) untrack(p *trackedPool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.pools, p.event.Pool)
+}
+
+// poll checks every tracked pool once and returns what happened since the last poll.
+func (t *Tracker) poll(ctx context.Context) []*events.PoolUpdate {
+	pools := t.snapshot()
+	if len(pools) == 0 {
+		return nil
+	}
+
+	// The accounts of every pool, in the order they are checked below
+	var keys []solana.PublicKey
+	for _, p := range pools {
+		if p.lp {
+			keys = append(keys, p.event.LPMint, p.event.LPAccount)
+		}
+		if p.vault {
+			keys = append(keys, p.event.QuoteVault)
+		}
+	}
+
+	accounts, err := utils.GetMultipleAccounts_S(ctx, keys...)
+	if err != nil {
+		color.New(color.FgYellow).Printf("[TRACK] poll -> Failed to get the accounts of %d pools: %v\n", len(pools), err)
+		return nil
+	}
+
+	var updates []*events.PoolUpdate
+	for _, p := range pools {
+		var mint, lpAccount, vault *rpc.Account
+		if p.lp {
+			mint, lpAccount, accounts = accounts[0], accounts[1], accounts[2:]
+		}
+		if p.vault {
+			vault, accounts = accounts[0], accounts[1:]
+		}
+
+		if !p.polled {
+			if err := t.baseline(ctx, p); err != nil {
+				color.New(color.FgYellow).Printf("[TRACK] poll -> Failed to get the creation of %s: %v\n", p.event.Pool, err)
+				continue // Retried on the next poll
+			}
+			p.polled = true
+		}
+
+		if p.lp {
+			updates = append(updates, t.pollLP(ctx, p, mint, lpAccount)...)
+		}
+		if p.vault {
+			if update := t.pollVault(ctx, p, vault); update != nil {
+				updates = append(updates, update)
+			}
+		}
+
+		if !p.lp && !p.vault {
+			t.untrack(p)
+		}
+	}
+
+	return updates
+}
+
+// baseline sets the balances right after the pool was created, so what happens before the
+// first poll is not missed.
+func (t *Tracker) baseline(ctx context.Context, p *trackedPool) error {
+	rpcTx, tx, err := utils.GetConfirmedTransaction_S(ctx, p.event.TxID)
+	if err != nil {
+		return err
+	}
+	keys := utils.AccountKeys(rpcTx, tx)
+
+	if p.lp {
+		p.minted = tokenBalances(keys, rpcTx.Meta.PostTokenBalances, p.event.LPMint)[p.event.LPAccount]
+		p.balance = p.minted
+		if p.minted == 0 {
+			if os.Getenv("DEBUG") == "1" {
+				color.New(color.FgYellow).Printf("[TRACK] baseline -> No LP tokens minted to %s, not following them for %s\n", p.event.LPAccount, p.event.Pool)
+			}
+			p.lp = false
+		}
+	}
+
+	// Concentrated liquidity pools are funded after their creation, they start at 0
+	if p.vault {
+		p.quote = tokenBalances(keys, rpcTx.Meta.PostTokenBalances, p.event.QuoteMint)[p.event.QuoteVault]
+		p.peak = p.quote
+	}
+
+	return nil
+}
+
+// tokenAmount returns the raw amount held by a token account, 0 for closed accounts.
+func tokenAmount(account *rpc.Account) uint64 {
+	if account == nil {
+		return 0
+	}
+
+	data := account.Data.GetBinary()
+	if len(data) < tokenAmountOffset+8 {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(data[tokenAmountOffset:])
+}
+
+// total sums the amounts returned by tokenBalances.
+func total(amounts map[solana.PublicKey]uint64) uint64 {
+	var sum uint64
+	for _, amount := range amounts {
+		sum += amount
+	}
+	return sum
+}
+
+// tokenBalances returns the raw amounts of the token accounts of mint in balances.
+func tokenBalances(keys solana.PublicKeySlice, balances []rpc.TokenBalance, mint solana.PublicKey) map[solana.PublicKey]uint64 {
+	amounts := make(map[solana.PublicKey]uint64)
+	for _, balance := range balances {
+		if balance.Mint != mint || int(balance.AccountIndex) >= len(keys) || balance.UiTokenAmount == nil {
+			continue
+		}
+
+		amount, err := strconv.ParseUint(balance.UiTokenAmount.Amount, 10, 64)
+		if err != nil {
+			continue
+		}
+		amounts[keys[balance.AccountIndex]] = amount
+	}
+	return amounts
+}
+
+// newSignatures returns the successful transactions of addresses since the pool was created
+// that are not in seen, oldest first. Busy addresses are paged back until the creation or a
+// transaction in seen.
+func newSignatures(ctx context.Context, p *trackedPool, addresses []solana.PublicKey, seen map[solana.Signature]bool) ([]solana.Signature, error) {
+	var found []*rpc.TransactionSignature
+	listed := make(map[solana.Signature]bool)
+	for _, address := range addresses {
+		before := solana.Signature{}
+		for {
+			signatures, err := utils.GetSignaturesForAddress_S(ctx, address, before, signaturesLimit)
+			if err != nil {
+				return nil, err
+			}
+
+			done := len(signatures) < signaturesLimit
+			for _, sig := range signatures {
+				if sig.Slot < p.event.Slot || sig.Signature == p.event.TxID || seen[sig.Signature] {
+					done = true // The creation, or looked at before along with everything older
+					break
+				}
+				if sig.Err != nil || listed[sig.Signature] {
+					continue
+				}
+				listed[sig.Signature] = true
+				found = append(found, sig)
+			}
+			if done {
+				break
+			}
+			before = signatures[len(signatures)-1].Signature
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].Slot < found[j].Slot
+	})
+
+	signatures := make([]solana.Signature, len(found))
+	for i, sig := range found {
+		signatures[i] = sig.Signature
+	}
+	return signatures, nil
+}
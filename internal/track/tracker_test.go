@@ -0,0 +1,190 @@
+package track
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs/rpctest"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/gagliardetto/solana-go"
+)
+
+func trackedEvent(pool, mint, account, tx string) *events.PoolEvent {
+	return &events.PoolEvent{
+		Venue:     events.VenueRaydium,
+		ProgramID: solana.MustPublicKeyFromBase58(utils.RAYDIUM_PROGRAM_ID),
+		Pool:      solana.MustPublicKeyFromBase58(pool),
+		LPMint:    solana.MustPublicKeyFromBase58(mint),
+		LPAccount: solana.MustPublicKeyFromBase58(account),
+		TxID:      solana.MustSignatureFromBase58(tx),
+		Slot:      268_000_100,
+	}
+}
+
+func Test_pollBurn(t *testing.T) {
+	ctx := context.Background()
+
+	replay := rpctest.Use(t, "testdata/lp_burn.json")
+
+	tracker := NewTracker(Options{Window: time.Hour, Interval: time.Minute, LP: true})
+	tracker.Track(trackedEvent(
+		"ASdyCssjs5iMqwzM8poYb9XumeQiotK1DshPKEdSXCaV",
+		"8MepaSgfY9oKeEoHazgEhRKKmRd5XD1YPHph5kTTJX8K",
+		"CMxVWCR586vVTNZ3E1bAXTmfKbib7RNVdcqtqwmphHFk",
+		"4yGCXKypRbGJrm6uobScnzMkUzFfmtfEMqzFkWYXDVSoaN9cHxeYBVRPkiA3KqKnT25DSjsp3ko6sMjQHufLPxZ",
+	))
+	// Pools without LP tokens, e.g. DLMM pairs, are not tracked
+	tracker.Track(&events.PoolEvent{Pool: solana.NewWallet().PublicKey()})
+	if tracker.Tracked() != 1 {
+		t.Fatalf("tracked: got %d, want 1", tracker.Tracked())
+	}
+
+	// The withdrawal burns LP tokens as well, but is not reported as a burn
+	updates := tracker.poll(ctx)
+	if len(updates) != 1 {
+		t.Fatalf("updates: got %d, want 1, misses: %v", len(updates), replay.Misses())
+	}
+
+	u := updates[0]
+	if u.Kind != events.UpdateLPBurned || u.Share != 90 {
+		t.Errorf("got %s %v%%, want LP burned 90%%", u.Kind, u.Share)
+	}
+	if u.TxID.String() != "iPNXxYeUyFM4Han4pNChSkkfxeRQuvLA6koGncHW8zQodjzoXDmNLbRxTGsSUDA3Ue7npu28NSj5xSQHFt2xKBK" || u.Slot != 268_000_103 {
+		t.Errorf("unexpected burn %s in %d", u.TxID, u.Slot)
+	}
+	if u.Wallet.String() != "By92JgosbGesNjBUuysmkJe6DNVvo7rUpB7q5DVAHiak" || u.Pool.Pool.String() != "ASdyCssjs5iMqwzM8poYb9XumeQiotK1DshPKEdSXCaV" {
+		t.Errorf("unexpected wallet %s for %s", u.Wallet, u.Pool.Pool)
+	}
+
+	// The creator holds no LP tokens anymore
+	if tracker.Tracked() != 0 {
+		t.Error("pool still tracked")
+	}
+}
+
+func Test_pollLock(t *testing.T) {
+	ctx := context.Background()
+
+	replay := rpctest.Use(t, "testdata/lp_lock.json")
+
+	tracker := NewTracker(Options{Window: time.Hour, Interval: time.Minute, LP: true})
+	tracker.Track(trackedEvent(
+		"8PE4WsfSADc5JSbMdF3zn8SvR2NiGfvRqo9zqotyT2cc",
+		"YHicvoxCf4UJuRWBmbuWKJD1HKugkgdg4i4NLHLcxix",
+		"B4YuAD3vKKedC2Mo6kjQLErzen5XtYXere5zbzg8ngeN",
+		"vUxwTbQtbHNydjBUjpb1pRR9vb6FiSgx7C4QkKq7BeX8hDf6xf8hqdBUh5qTM1W4hNPu6n449UL36iubBbcZrin",
+	))
+
+	updates := tracker.poll(ctx)
+	if len(updates) != 1 {
+		t.Fatalf("updates: got %d, want 1, misses: %v", len(updates), replay.Misses())
+	}
+
+	u := updates[0]
+	if u.Kind != events.UpdateLPLocked || u.Share != 50 || u.Locker != "Streamflow" {
+		t.Errorf("got %s %v%% (%s), want LP locked 50%% (Streamflow)", u.Kind, u.Share, u.Locker)
+	}
+	// Everything unlocks at the cliff
+	if !u.Until.Equal(time.Unix(1748536200, 0)) {
+		t.Errorf("until: got %v", u.Until)
+	}
+
+	// Nothing changed since, the lock is not reported twice
+	if updates := tracker.poll(ctx); len(updates) != 0 || tracker.Tracked() != 1 {
+		t.Errorf("second poll: got %d updates, %d tracked", len(updates), tracker.Tracked())
+	}
+}
+
+// vaultEvent is the pool of the liquidity_removed fixtures.
+func vaultEvent() *events.PoolEvent {
+	return &events.PoolEvent{
+		Venue:      events.VenueRaydium,
+		ProgramID:  solana.MustPublicKeyFromBase58(utils.RAYDIUM_PROGRAM_ID),
+		Pool:       solana.MustPublicKeyFromBase58("DXEPLYEB6j8GdKm8HHyDSJ5UcyX49d7oA8wEjo2RZZpY"),
+		BaseMint:   solana.MustPublicKeyFromBase58("Fcbdhzqfeobb5Aq1EPVpQxvRV6ZyfenrZg9apLFJ9X6q"),
+		QuoteMint:  solana.WrappedSol,
+		BaseVault:  solana.MustPublicKeyFromBase58("2GkaR7b5jbFDWTsHwg4qvQ21xMErQvuXsf99XnjofHWx"),
+		QuoteVault: solana.MustPublicKeyFromBase58("B8886Y8Tz5vPudW1KJPTvPt1ZQ81zmkBATYcYHmZphjN"),
+		LPMint:     solana.MustPublicKeyFromBase58("2oiQ4WAibjduUMNq16w2b7TqZM3u2k2mfKVRBiAicoYR"),
+		TxID:       solana.MustSignatureFromBase58("5LfDoEXYC58qHnetMpsnqpnDJLsV4wBnujaEr7FHp1tajVy3UYhDQxUdLzkCnyMDkZvFFGw9V9GJq6YiCgyDZXTw"),
+		Slot:       268_000_100,
+	}
+}
+
+func Test_pollVault(t *testing.T) {
+	ctx := context.Background()
+
+	replay := rpctest.Use(t, "testdata/liquidity_removed.json")
+
+	tracker := NewTracker(Options{Window: time.Hour, Interval: time.Minute, Removal: 50})
+	tracker.Track(vaultEvent())
+	// Dynamic AMM vaults hold the tokens of every pool of the mint
+	tracker.Track(&events.PoolEvent{Pool: solana.NewWallet().PublicKey(), QuoteVault: solana.NewWallet().PublicKey(), SharedVaults: true})
+	if tracker.Tracked() != 1 {
+		t.Fatalf("tracked: got %d, want 1", tracker.Tracked())
+	}
+
+	// A sell took out 60% of the SOL
+	if updates := tracker.poll(ctx); len(updates) != 0 {
+		t.Fatalf("sell: got %d updates, want 0", len(updates))
+	}
+	if misses := replay.Misses(); len(misses) > 0 || tracker.Tracked() != 1 {
+		t.Fatalf("sell: %d tracked, misses: %v", tracker.Tracked(), misses)
+	}
+
+	// Bought back, then 30% withdrawn
+	replay = rpctest.Use(t, "testdata/liquidity_removed.json", "testdata/liquidity_removed_withdraw.json")
+	if updates := tracker.poll(ctx); len(updates) != 0 {
+		t.Fatalf("withdrawal: got %d updates, want 0", len(updates))
+	}
+
+	// Another 30% withdrawn, less than half of what was left at the last poll
+	replay = rpctest.Use(t, "testdata/liquidity_removed.json", "testdata/liquidity_removed_withdraw.json", "testdata/liquidity_removed_split.json")
+	updates := tracker.poll(ctx)
+	if len(updates) != 1 {
+		t.Fatalf("updates: got %d, want 1, misses: %v", len(updates), replay.Misses())
+	}
+
+	// Both withdrawals, not the sell
+	u := updates[0]
+	if u.Kind != events.UpdateLiquidityRemoved || u.Share != 60 || u.Amount != 6 {
+		t.Errorf("got %s %v%% (%v SOL), want liquidity removed 60%% (6 SOL)", u.Kind, u.Share, u.Amount)
+	}
+	if u.TxID.String() != "2CfAuJa9RwTYVhhEHgi4fdnYothdK4M34sDTeYk7v8t7HSgW96KBNGEMeofYTrTruj16e1BJrrJk4mbAbaBi1heo" || u.Slot != 268_000_105 {
+		t.Errorf("unexpected removal %s in %d", u.TxID, u.Slot)
+	}
+	// Sent to another wallet than the one that signed
+	if u.Wallet.String() != "EGE9n8vtXS2mW1hgixQkbJW6C66x1wHFWegUKtxSs4Hx" {
+		t.Errorf("wallet: got %s", u.Wallet)
+	}
+
+	// Reported once
+	if tracker.Tracked() != 0 {
+		t.Error("pool still tracked")
+	}
+}
+
+func Test_pollVaultPages(t *testing.T) {
+	ctx := context.Background()
+
+	// The withdrawals are followed by more buys than fit a page of signatures
+	replay := rpctest.Use(t, "testdata/liquidity_removed.json", "testdata/liquidity_removed_withdraw.json", "testdata/liquidity_removed_split.json", "testdata/liquidity_removed_paged.json")
+
+	tracker := NewTracker(Options{Window: time.Hour, Interval: time.Minute, Removal: 50})
+	tracker.Track(vaultEvent())
+
+	updates := tracker.poll(ctx)
+	if len(updates) != 1 {
+		t.Fatalf("updates: got %d, want 1, misses: %v", len(updates), replay.Misses())
+	}
+
+	u := updates[0]
+	if u.Kind != events.UpdateLiquidityRemoved || u.Share != 60 || u.Amount != 6 {
+		t.Errorf("got %s %v%% (%v SOL), want liquidity removed 60%% (6 SOL)", u.Kind, u.Share, u.Amount)
+	}
+	if u.TxID.String() != "2CfAuJa9RwTYVhhEHgi4fdnYothdK4M34sDTeYk7v8t7HSgW96KBNGEMeofYTrTruj16e1BJrrJk4mbAbaBi1heo" {
+		t.Errorf("unexpected removal %s", u.TxID)
+	}
+}
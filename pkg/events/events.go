@@ -0,0 +1,145 @@
+// Package events is the venue independent model of detected pools and markets. Every
+// parser emits these events, the enrichment and the hooks only work against them.
+package events
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Venue is the DEX or order book a pool or market was created on.
+type Venue string
+
+const (
+	VenueRaydium  Venue = "Raydium"
+	VenueMeteora  Venue = "Meteora"
+	VenueOrca     Venue = "Orca"
+	VenueOpenbook Venue = "OpenBook"
+)
+
+// Field is a single line of venue specific information, e.g. a fee tier.
+type Field struct {
+	Name  string
+	Value string
+	Link  string // Optional URL of the value
+}
+
+// Extra is venue specific information attached to an event. Hooks render it as a
+// titled section of fields, without knowing the venue.
+type Extra interface {
+	Title() string
+	Fields() []Field
+}
+
+// PoolEvent is a pool that was created, or first funded, on any venue.
+type PoolEvent struct {
+	Venue     Venue
+	Kind      string // Pool program within the venue, e.g. CPMM, empty for Raydium AMM v4
+	Origin    string // Launchpad the pool was migrated from, e.g. pump.fun
+	ProgramID solana.PublicKey
+
+	Pool       solana.PublicKey
+	BaseMint   solana.PublicKey // Never SOL or USDC when the other side is
+	QuoteMint  solana.PublicKey
+	BaseVault  solana.PublicKey
+	QuoteVault solana.PublicKey
+	LPMint     solana.PublicKey // LP mint, or position NFT of concentrated liquidity pools
+	LPAccount  solana.PublicKey // Token account the creator received the LP tokens in
+
+	SharedVaults bool // The vaults hold the tokens of other pools as well
+
+	BaseLiquidity  float64 // Initial liquidity, adjusted for decimals
+	QuoteLiquidity float64
+	OpenTime       time.Time // When trading starts
+
+	Creator   solana.PublicKey // Creator of the token for migrated pools
+	TxID      solana.Signature
+	Slot      uint64
+	TxTime    time.Time // Block time
+	Timestamp time.Time // Detection time
+
+	Extras []Extra
+	Source any // What the parser decoded, e.g. *raydium.RaydiumInfo
+}
+
+// Label names the venue and pool program in notifications, e.g. "Raydium CPMM".
+func (ev *PoolEvent) Label() string {
+	if ev.Kind == "" {
+		return string(ev.Venue)
+	}
+	return string(ev.Venue) + " " + ev.Kind
+}
+
+// MarketEvent is an order book market that was created on any venue.
+type MarketEvent struct {
+	Venue     Venue
+	Kind      string // Program version, e.g. v2, empty for the default one
+	ProgramID solana.PublicKey
+
+	Market     solana.PublicKey
+	BaseMint   solana.PublicKey // Never SOL or USDC when the other side is
+	QuoteMint  solana.PublicKey
+	BaseVault  solana.PublicKey
+	QuoteVault solana.PublicKey
+
+	Costs float64 // SOL spent on the market accounts
+
+	Creator   solana.PublicKey
+	TxID      solana.Signature
+	Slot      uint64
+	TxTime    time.Time // Block time
+	Timestamp time.Time // Detection time
+
+	Extras []Extra
+	Source any // What the parser decoded, e.g. *openbook.OpenbookInfo
+}
+
+// Label names the venue and program version in notifications, e.g. "OpenBook v2".
+func (ev *MarketEvent) Label() string {
+	if ev.Kind == "" {
+		return string(ev.Venue)
+	}
+	return string(ev.Venue) + " " + ev.Kind
+}
+
+// UpdateKind is what happened to a pool after it was reported.
+type UpdateKind string
+
+const (
+	UpdateLPBurned         UpdateKind = "LP burned"
+	UpdateLPLocked         UpdateKind = "LP locked"
+	UpdateLiquidityRemoved UpdateKind = "Liquidity removed"
+)
+
+// PoolUpdate is something that happened to a reported pool, e.g. its creator burning
+// the LP tokens or pulling the liquidity.
+type PoolUpdate struct {
+	Kind UpdateKind
+	Pool *PoolEvent // The pool as it was reported
+
+	Share  float64   // In percent, of the LP tokens the creator received or of the quote liquidity removed
+	Amount float64   // Quote tokens removed, adjusted for decimals
+	Locker string    // Program the LP tokens were locked with
+	Until  time.Time // When the locked LP tokens unlock, zero if unknown
+
+	Wallet    solana.PublicKey // Signer of the transaction, receiver of the funds for removals
+	TxID      solana.Signature
+	Slot      uint64
+	TxTime    time.Time // Block time
+	Timestamp time.Time // Detection time
+}
+
+// Summary describes the update in a few words, e.g. "LP burned 90%".
+func (u *PoolUpdate) Summary() string {
+	summary := string(u.Kind) + " " + strconv.FormatFloat(math.Round(u.Share*10)/10, 'f', -1, 64) + "%"
+	if u.Kind == UpdateLPLocked {
+		if !u.Until.IsZero() {
+			summary += " until " + u.Until.UTC().Format("2006-01-02")
+		}
+		summary += " (" + u.Locker + ")"
+	}
+	return summary
+}
@@ -0,0 +1,245 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// CheckpointStore persists the slot every stream was processed up to.
+type CheckpointStore interface {
+	Checkpoint(ctx context.Context, stream string) (uint64, error)
+	SaveCheckpoint(ctx context.Context, stream string, slot uint64) error
+}
+
+const backfillPageSize = 1000
+
+// BackfillSource wraps a Source. Every time a subscription starts (on startup and after
+// every reconnect) it replays the transactions of the program that were missed since
+// the last checkpoint, while the live subscription is already running. Wrap it in a
+// DedupSource sharing its Dedup, the live subscription and the backfill overlap.
+type BackfillSource struct {
+	Source      Source
+	Checkpoints CheckpointStore // Optional, without it only Window limits the backfill
+
+	Window        time.Duration // How far back to go at most
+	MaxSignatures int           // Upper bound of signatures listed per backfill
+
+	// Upper bound of transactions fetched per backfill, 0 for no limit. Most signatures
+	// of a program are swaps that only the logs of the full transaction tell apart.
+	MaxTransactions int
+
+	// Signatures already delivered are not fetched again. Optional.
+	Dedup *Dedup
+
+	// Handled reports whether a signature was already handled by a previous run,
+	// those are not fetched again either. Optional.
+	Handled func(ctx context.Context, signature solana.Signature) bool
+}
+
+func NewBackfillSource(src Source, checkpoints CheckpointStore, window time.Duration, maxSignatures int) *BackfillSource {
+	return &BackfillSource{
+		Source:        src,
+		Checkpoints:   checkpoints,
+		Window:        window,
+		MaxSignatures: maxSignatures,
+	}
+}
+
+func (s *BackfillSource) Name() string {
+	return s.Source.Name()
+}
+
+func (s *BackfillSource) Subscribe(ctx context.Context, program solana.PublicKey, filter LogFilter, ch chan<- Event) error {
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	live := make(chan Event)
+	subErr := make(chan error, 1)
+	go func() {
+		subErr <- s.Source.Subscribe(subCtx, program, filter, live)
+	}()
+
+	// The checkpoint only advances once the gap is filled, so a crash during the
+	// backfill does not skip the part that was not replayed yet. A backfill stopped by
+	// MaxTransactions advances it up to where the replay got.
+	stream := program.String()
+	var mutex sync.Mutex
+	var covered uint64  // Highest slot processed
+	var replayed uint64 // Slot the backfill got up to when it did not fill the gap
+	filled := false
+
+	backfillDone := make(chan struct{})
+	go func() {
+		defer close(backfillDone)
+
+		slot, complete, forwarded, err := s.backfill(subCtx, program, filter, ch)
+		if err != nil {
+			if subCtx.Err() == nil {
+				color.New(color.FgYellow).Printf("Backfill of %s failed: %v\n", stream, err)
+			}
+			return
+		}
+		if forwarded > 0 || os.Getenv("DEBUG") == "1" {
+			fmt.Printf("Backfill of %s: %d missed transaction(s) replayed\n", stream, forwarded)
+		}
+
+		mutex.Lock()
+		if complete {
+			covered = max(covered, slot)
+			filled = true
+		} else {
+			replayed = slot
+		}
+		mutex.Unlock()
+	}()
+
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	saved := uint64(0)
+	save := func() {
+		mutex.Lock()
+		slot := replayed
+		if filled {
+			slot = covered
+		}
+		mutex.Unlock()
+
+		if slot <= saved || s.Checkpoints == nil {
+			return
+		}
+		saveCtx, saveCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer saveCancel()
+		if err := s.Checkpoints.SaveCheckpoint(saveCtx, stream, slot); err != nil {
+			color.New(color.FgYellow).Printf("Failed to save checkpoint of %s: %v\n", stream, err)
+			return
+		}
+		saved = slot
+	}
+
+	for {
+		select {
+		case ev := <-live:
+			if !forward(ctx, ch, ev) {
+				continue // Cancelled, the subscription returns next
+			}
+
+			mutex.Lock()
+			covered = max(covered, ev.Slot)
+			mutex.Unlock()
+		case <-ticker.C:
+			save()
+		case err := <-subErr:
+			cancel()
+			<-backfillDone
+			save()
+			return err
+		}
+	}
+}
+
+// backfill forwards the missed transactions oldest first. When the whole gap was replayed
+// it returns the newest slot of the program at the time it started and complete. Otherwise
+// it returns the slot everything was replayed up to, 0 when the oldest signatures of the
+// gap were not even listed.
+func (s *BackfillSource) backfill(ctx context.Context, program solana.PublicKey, filter LogFilter, ch chan<- Event) (uint64, bool, int, error) {
+	var since uint64
+	if s.Checkpoints != nil {
+		slot, err := s.Checkpoints.Checkpoint(ctx, program.String())
+		if err != nil {
+			return 0, false, 0, err
+		}
+		since = slot
+	}
+	cutoff := time.Now().Add(-s.Window)
+
+	var newest uint64
+	var pending []*rpc.TransactionSignature
+	var before solana.Signature
+	listed := true // Every signature back to the checkpoint or the window
+
+paging:
+	for {
+		page, err := utils.GetSignaturesForAddress_S(ctx, program, before, backfillPageSize)
+		if err != nil {
+			return 0, false, 0, err
+		}
+
+		for _, sig := range page {
+			if newest == 0 {
+				newest = sig.Slot
+			}
+			if sig.Slot <= since || (sig.BlockTime != nil && sig.BlockTime.Time().Before(cutoff)) {
+				break paging
+			}
+			if len(pending) >= s.MaxSignatures {
+				color.New(color.FgYellow).Printf("Backfill of %s limited to %d signatures, the checkpoint is kept\n", program, s.MaxSignatures)
+				listed = false
+				break paging
+			}
+			if sig.Err != nil {
+				continue // Failed transactions never created anything
+			}
+			pending = append(pending, sig)
+		}
+
+		if len(page) < backfillPageSize {
+			break
+		}
+		before = page[len(page)-1].Signature
+	}
+
+	forwarded, fetched := 0, 0
+	for i := len(pending) - 1; i >= 0; i-- {
+		sig := pending[i]
+		if (s.Dedup != nil && s.Dedup.Seen(program, sig.Signature)) || (s.Handled != nil && s.Handled(ctx, sig.Signature)) {
+			continue
+		}
+
+		if s.MaxTransactions > 0 && fetched >= s.MaxTransactions {
+			color.New(color.FgYellow).Printf("Backfill of %s stopped after %d transactions\n", program, fetched)
+			// The rest of this slot is replayed again next time, the dedup skips what was sent
+			if !listed || sig.Slot <= since+1 {
+				return 0, false, forwarded, nil
+			}
+			return sig.Slot - 1, false, forwarded, nil
+		}
+		fetched++
+
+		rpcTx, tx, err := utils.GetConfirmedTransaction_S(ctx, sig.Signature)
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, false, forwarded, ctx.Err()
+			}
+			continue
+		}
+		if rpcTx.Meta == nil || !filter(rpcTx.Meta.LogMessages) {
+			continue
+		}
+		ev := Event{
+			Signature:   sig.Signature,
+			Slot:        rpcTx.Slot,
+			Logs:        rpcTx.Meta.LogMessages,
+			Source:      "backfill",
+			Transaction: rpcTx,
+			Tx:          tx,
+		}
+		if !forward(ctx, ch, ev) {
+			return 0, false, forwarded, ctx.Err()
+		}
+		forwarded++
+	}
+
+	if !listed {
+		return 0, false, forwarded, nil
+	}
+	return max(newest, since), true, forwarded, nil
+}
@@ -0,0 +1,270 @@
+package ingest_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs"
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs/rpctest"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type memCheckpoints struct {
+	mutex sync.Mutex
+	slots map[string]uint64
+}
+
+func (m *memCheckpoints) Checkpoint(ctx context.Context, stream string) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.slots[stream], nil
+}
+
+func (m *memCheckpoints) SaveCheckpoint(ctx context.Context, stream string, slot uint64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.slots[stream] = slot
+	return nil
+}
+
+// liveSource delivers its events once released, then reports a disconnect.
+type liveSource struct {
+	events  []ingest.Event
+	release chan struct{}
+}
+
+func (s *liveSource) Name() string { return "live" }
+
+func (s *liveSource) Subscribe(ctx context.Context, program solana.PublicKey, filter ingest.LogFilter, ch chan<- ingest.Event) error {
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return nil
+	}
+	for _, ev := range s.events {
+		ch <- ev
+	}
+	return errors.New("disconnected")
+}
+
+func signatureCall(t *testing.T, program solana.PublicKey, signatures []*rpc.TransactionSignature) rpctest.Call {
+	result, err := json.Marshal(signatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rpctest.Call{
+		Method: "getSignaturesForAddress",
+		Params: json.RawMessage(`["` + program.String() + `",{"commitment":"confirmed","limit":1000}]`),
+		Result: result,
+	}
+}
+
+func transactionCall(t *testing.T, slot uint64, tx *solana.Transaction, meta *rpc.TransactionMeta) rpctest.Call {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, _ := json.Marshal(map[string]any{
+		"slot":        slot,
+		"blockTime":   time.Now().Unix(),
+		"meta":        json.RawMessage(metaJSON),
+		"transaction": []string{base64.StdEncoding.EncodeToString(raw), "base64"},
+		"version":     "legacy",
+	})
+	return rpctest.Call{
+		Method: "getTransaction",
+		Params: json.RawMessage(`["` + tx.Signatures[0].String() + `",{"commitment":"confirmed","encoding":"base64","maxSupportedTransactionVersion":0}]`),
+		Result: result,
+	}
+}
+
+func Test_BackfillSource(t *testing.T) {
+	program := solana.NewWallet().PublicKey()
+	filter := func(logs []string) bool {
+		for _, log := range logs {
+			if strings.Contains(log, "initialize2") {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Newest first: a pool also delivered live, a missed pool, a failed transaction,
+	// a swap, a pool handled by a previous run and one before the checkpoint.
+	live, liveMeta := testTransaction(15, program, false)
+	missed, missedMeta := testTransaction(14, program, false)
+	failed, _ := testTransaction(13, program, false)
+	swap, swapMeta := testTransaction(12, program, false)
+	swapMeta.LogMessages = []string{"Program log: swap"}
+	handled, _ := testTransaction(11, program, false)
+	old, _ := testTransaction(10, program, false)
+
+	now := solana.UnixTimeSeconds(time.Now().Unix())
+	signatures := []*rpc.TransactionSignature{
+		{Signature: live.Signatures[0], Slot: 105, BlockTime: &now},
+		{Signature: missed.Signatures[0], Slot: 104, BlockTime: &now},
+		{Signature: failed.Signatures[0], Slot: 103, BlockTime: &now, Err: map[string]any{"InstructionError": []any{0, "Custom"}}},
+		{Signature: swap.Signatures[0], Slot: 102, BlockTime: &now},
+		{Signature: handled.Signatures[0], Slot: 101, BlockTime: &now},
+		{Signature: old.Signatures[0], Slot: 90, BlockTime: &now},
+	}
+
+	replay := rpctest.NewReplay(&rpctest.Fixture{Calls: []rpctest.Call{
+		signatureCall(t, program, signatures),
+		transactionCall(t, 105, live, liveMeta),
+		transactionCall(t, 104, missed, missedMeta),
+		transactionCall(t, 102, swap, swapMeta),
+	}})
+	rpcs.SetClients(replay.Client())
+	t.Cleanup(func() { rpcs.SetClients() })
+
+	source := &liveSource{
+		events:  []ingest.Event{{Signature: live.Signatures[0], Slot: 105, Source: "live"}},
+		release: make(chan struct{}),
+	}
+	checkpoints := &memCheckpoints{slots: map[string]uint64{program.String(): 90}}
+	dedup := ingest.NewDedup(time.Hour, 100)
+
+	backfill := ingest.NewBackfillSource(source, checkpoints, time.Hour, 100)
+	backfill.Dedup = dedup
+	backfill.Handled = func(ctx context.Context, signature solana.Signature) bool {
+		return signature == handled.Signatures[0]
+	}
+	src := ingest.NewDedupSource(backfill, dedup)
+
+	ch := make(chan ingest.Event, 8)
+	done := make(chan error, 1)
+	go func() {
+		done <- src.Subscribe(context.Background(), program, filter, ch)
+	}()
+
+	// The backfill replays oldest first, the live duplicate arrives afterwards
+	var events []ingest.Event
+	for len(events) < 2 {
+		select {
+		case ev := <-ch:
+			events = append(events, ev)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of 2 events, misses: %v", len(events), replay.Misses())
+		}
+	}
+	close(source.release)
+
+	if err := <-done; err == nil || err.Error() != "disconnected" {
+		t.Errorf("unexpected subscribe error: %v", err)
+	}
+	if len(ch) != 0 {
+		t.Errorf("live duplicate was forwarded")
+	}
+
+	if events[0].Signature != missed.Signatures[0] || events[1].Signature != live.Signatures[0] {
+		t.Fatalf("unexpected events %s, %s", events[0].Signature, events[1].Signature)
+	}
+	for _, ev := range events {
+		if ev.Source != "backfill" || ev.Transaction == nil || ev.Tx == nil {
+			t.Errorf("backfilled event %s without transaction", ev.Signature)
+		}
+	}
+	if events[0].Slot != 104 {
+		t.Errorf("slot %d, want 104", events[0].Slot)
+	}
+
+	stats := dedup.Stats()
+	if len(stats) != 2 || stats[0] != (ingest.DedupStats{Source: "backfill", Forwarded: 2}) || stats[1] != (ingest.DedupStats{Source: "live", Suppressed: 1}) {
+		t.Errorf("unexpected dedup stats %+v", stats)
+	}
+
+	if slot, _ := checkpoints.Checkpoint(context.Background(), program.String()); slot != 105 {
+		t.Errorf("checkpoint %d, want 105", slot)
+	}
+
+	// A reconnect resumes from the checkpoint, nothing is replayed twice
+	source.events = nil
+	source.release = make(chan struct{})
+	close(source.release)
+	if err := src.Subscribe(context.Background(), program, filter, ch); err == nil {
+		t.Error("expected the disconnect")
+	}
+	if len(ch) != 0 {
+		t.Errorf("%d event(s) replayed again", len(ch))
+	}
+}
+
+func Test_BackfillSourceLimits(t *testing.T) {
+	program := solana.NewWallet().PublicKey()
+	filter := func(logs []string) bool { return true }
+
+	first, firstMeta := testTransaction(21, program, false)
+	second, secondMeta := testTransaction(22, program, false)
+	third, thirdMeta := testTransaction(23, program, false)
+
+	now := solana.UnixTimeSeconds(time.Now().Unix())
+	signatures := []*rpc.TransactionSignature{
+		{Signature: third.Signatures[0], Slot: 104, BlockTime: &now},
+		{Signature: second.Signatures[0], Slot: 103, BlockTime: &now},
+		{Signature: first.Signatures[0], Slot: 102, BlockTime: &now},
+	}
+
+	replay := rpctest.NewReplay(&rpctest.Fixture{Calls: []rpctest.Call{
+		signatureCall(t, program, signatures),
+		transactionCall(t, 102, first, firstMeta),
+		transactionCall(t, 103, second, secondMeta),
+		transactionCall(t, 104, third, thirdMeta),
+	}})
+	rpcs.SetClients(replay.Client())
+	t.Cleanup(func() { rpcs.SetClients() })
+
+	run := func(backfill *ingest.BackfillSource, want int) []ingest.Event {
+		source := backfill.Source.(*liveSource)
+		ch := make(chan ingest.Event, 8)
+		done := make(chan error, 1)
+		go func() {
+			done <- backfill.Subscribe(context.Background(), program, filter, ch)
+		}()
+
+		var events []ingest.Event
+		for len(events) < want {
+			select {
+			case ev := <-ch:
+				events = append(events, ev)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("received %d of %d events, misses: %v", len(events), want, replay.Misses())
+			}
+		}
+		close(source.release)
+		<-done
+		return events
+	}
+
+	// Only the newest signatures were listed, the older ones of the gap were never replayed
+	checkpoints := &memCheckpoints{slots: map[string]uint64{program.String(): 90}}
+	backfill := ingest.NewBackfillSource(&liveSource{release: make(chan struct{})}, checkpoints, time.Hour, 2)
+	if events := run(backfill, 2); events[0].Signature != second.Signatures[0] || events[1].Signature != third.Signatures[0] {
+		t.Errorf("unexpected events %s, %s", events[0].Signature, events[1].Signature)
+	}
+	if slot, _ := checkpoints.Checkpoint(context.Background(), program.String()); slot != 90 {
+		t.Errorf("checkpoint %d after a truncated listing, want 90", slot)
+	}
+
+	// The transaction budget runs out, the checkpoint advances to the last slot replayed
+	backfill = ingest.NewBackfillSource(&liveSource{release: make(chan struct{})}, checkpoints, time.Hour, 100)
+	backfill.MaxTransactions = 1
+	if events := run(backfill, 1); events[0].Signature != first.Signatures[0] {
+		t.Errorf("unexpected event %s", events[0].Signature)
+	}
+	if slot, _ := checkpoints.Checkpoint(context.Background(), program.String()); slot != 102 {
+		t.Errorf("checkpoint %d after the budget ran out, want 102", slot)
+	}
+}
@@ -0,0 +1,276 @@
+package ingest
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Dedup remembers the signatures forwarded per program for a limited time, so
+// reconnects, overlapping sources and the backfill never deliver a transaction twice.
+// Signatures are dropped in the order they were claimed (FIFO), suppressing a duplicate
+// does not keep a signature around for longer. It is shared by all sources and safe for
+// concurrent use.
+type Dedup struct {
+	ttl     time.Duration
+	size    int
+	path    string // Optional file the signatures are persisted to
+	mutex   sync.Mutex
+	entries map[seenKey]*list.Element
+	order   *list.List // Oldest claim first
+	stats   map[string]*DedupStats
+	dirty   bool
+}
+
+// seenKey is per program, a transaction creating a market and a pool at once
+// is forwarded to both streams.
+type seenKey struct {
+	Program   solana.PublicKey `json:"program"`
+	Signature solana.Signature `json:"signature"`
+}
+
+type seenEntry struct {
+	seenKey
+	SeenAt time.Time `json:"seenAt"`
+}
+
+// DedupStats counts the events of a single source.
+type DedupStats struct {
+	Source     string
+	Forwarded  uint64
+	Suppressed uint64
+}
+
+// NewDedup keeps signatures for ttl and at most size of them.
+func NewDedup(ttl time.Duration, size int) *Dedup {
+	return &Dedup{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[seenKey]*list.Element),
+		order:   list.New(),
+		stats:   make(map[string]*DedupStats),
+	}
+}
+
+// Claim records the signature for the program. It returns false if it was already
+// claimed, in which case the event is a duplicate and counted as suppressed for source.
+func (d *Dedup) Claim(program solana.PublicKey, signature solana.Signature, source string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.expire(time.Now())
+
+	stats, ok := d.stats[source]
+	if !ok {
+		stats = &DedupStats{Source: source}
+		d.stats[source] = stats
+	}
+
+	key := seenKey{program, signature}
+	if _, ok := d.entries[key]; ok {
+		stats.Suppressed++
+		return false
+	}
+
+	d.entries[key] = d.order.PushBack(&seenEntry{seenKey: key, SeenAt: time.Now()})
+	d.dirty = true
+	stats.Forwarded++
+
+	for d.size > 0 && d.order.Len() > d.size {
+		d.remove(d.order.Front())
+	}
+
+	return true
+}
+
+// Release forgets a signature claimed by source that could not be delivered after all,
+// so a later delivery or the backfill of the next run is not suppressed.
+func (d *Dedup) Release(program solana.PublicKey, signature solana.Signature, source string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	e, ok := d.entries[seenKey{program, signature}]
+	if !ok {
+		return
+	}
+	d.remove(e)
+
+	if stats, ok := d.stats[source]; ok && stats.Forwarded > 0 {
+		stats.Forwarded--
+	}
+}
+
+// Seen reports whether the signature was claimed for the program, without claiming it.
+func (d *Dedup) Seen(program solana.PublicKey, signature solana.Signature) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.expire(time.Now())
+
+	_, ok := d.entries[seenKey{program, signature}]
+	return ok
+}
+
+// Stats returns the counters of every source, sorted by source name.
+func (d *Dedup) Stats() []DedupStats {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	list := make([]DedupStats, 0, len(d.stats))
+	for _, stats := range d.stats {
+		list = append(list, *stats)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Source < list[j].Source })
+
+	return list
+}
+
+// Load reads the signatures persisted to path by a previous run and keeps persisting
+// to it on Flush. A missing file is not an error.
+func (d *Dedup) Load(path string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.path = path
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var entries []*seenEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return fmt.Errorf("dedup %s: %w", path, err)
+	}
+
+	for _, entry := range entries {
+		if _, ok := d.entries[entry.seenKey]; !ok {
+			d.entries[entry.seenKey] = d.order.PushBack(entry)
+		}
+	}
+	d.expire(time.Now())
+
+	return nil
+}
+
+// Flush writes the signatures to the loaded path if they changed since the last flush.
+func (d *Dedup) Flush() error {
+	d.mutex.Lock()
+	if d.path == "" || !d.dirty {
+		d.mutex.Unlock()
+		return nil
+	}
+
+	d.expire(time.Now())
+
+	entries := make([]*seenEntry, 0, d.order.Len())
+	for e := d.order.Front(); e != nil; e = e.Next() {
+		entries = append(entries, e.Value.(*seenEntry))
+	}
+	path := d.path
+	d.dirty = false
+
+	raw, err := json.Marshal(entries)
+	d.mutex.Unlock()
+
+	if err == nil {
+		err = utils.WriteFileAtomic(path, raw)
+	}
+	if err != nil {
+		d.mutex.Lock()
+		d.dirty = true
+		d.mutex.Unlock()
+	}
+
+	return err
+}
+
+// Persist flushes every interval until ctx is cancelled.
+func (d *Dedup) Persist(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Flush(); err != nil {
+				color.New(color.FgYellow).Printf("Dedup -> failed to persist: %v\n", err)
+			}
+		}
+	}
+}
+
+// expire drops the signatures older than the ttl, the list is ordered by age.
+func (d *Dedup) expire(now time.Time) {
+	for e := d.order.Front(); e != nil && d.ttl > 0; e = d.order.Front() {
+		if now.Sub(e.Value.(*seenEntry).SeenAt) <= d.ttl {
+			return
+		}
+		d.remove(e)
+	}
+}
+
+func (d *Dedup) remove(e *list.Element) {
+	d.order.Remove(e)
+	delete(d.entries, e.Value.(*seenEntry).seenKey)
+	d.dirty = true
+}
+
+// DedupSource wraps a Source and drops the events already delivered by any source
+// sharing the same Dedup.
+type DedupSource struct {
+	Source Source
+	Dedup  *Dedup
+}
+
+func NewDedupSource(src Source, dedup *Dedup) *DedupSource {
+	return &DedupSource{Source: src, Dedup: dedup}
+}
+
+func (s *DedupSource) Name() string {
+	return s.Source.Name()
+}
+
+func (s *DedupSource) Subscribe(ctx context.Context, program solana.PublicKey, filter LogFilter, ch chan<- Event) error {
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan Event)
+	subErr := make(chan error, 1)
+	go func() {
+		subErr <- s.Source.Subscribe(subCtx, program, filter, events)
+	}()
+
+	for {
+		select {
+		case ev := <-events:
+			if !s.Dedup.Claim(program, ev.Signature, ev.Source) {
+				if os.Getenv("DEBUG") == "1" {
+					fmt.Printf("Duplicate %s from %s suppressed\n", ev.Signature, ev.Source)
+				}
+				continue
+			}
+			// Claimed first so a concurrent delivery of the same signature is suppressed
+			if !forward(ctx, ch, ev) {
+				s.Dedup.Release(program, ev.Signature, ev.Source)
+			}
+		case err := <-subErr:
+			return err
+		}
+	}
+}
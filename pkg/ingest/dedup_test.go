@@ -0,0 +1,120 @@
+package ingest_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func Test_Dedup(t *testing.T) {
+	raydium := solana.NewWallet().PublicKey()
+	openbook := solana.NewWallet().PublicKey()
+	sig := func(seed byte) solana.Signature { return solana.Signature{seed} }
+
+	dedup := ingest.NewDedup(time.Hour, 2)
+
+	if !dedup.Claim(raydium, sig(1), "websocket") || dedup.Claim(raydium, sig(1), "geyser") {
+		t.Error("duplicate was not suppressed")
+	}
+	// The same transaction may create a market and a pool
+	if !dedup.Claim(openbook, sig(1), "websocket") {
+		t.Error("signature of another program was suppressed")
+	}
+
+	// Size bound, the oldest signature goes first
+	dedup.Claim(raydium, sig(2), "backfill")
+	if dedup.Seen(raydium, sig(1)) || !dedup.Seen(openbook, sig(1)) || !dedup.Seen(raydium, sig(2)) {
+		t.Error("oldest signature was not evicted")
+	}
+
+	stats := dedup.Stats()
+	want := []ingest.DedupStats{
+		{Source: "backfill", Forwarded: 1},
+		{Source: "geyser", Suppressed: 1},
+		{Source: "websocket", Forwarded: 2},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Errorf("stats %d: got %+v, want %+v", i, stats[i], want[i])
+		}
+	}
+}
+
+func Test_DedupTTL(t *testing.T) {
+	program := solana.NewWallet().PublicKey()
+	dedup := ingest.NewDedup(20*time.Millisecond, 0)
+
+	dedup.Claim(program, solana.Signature{1}, "websocket")
+	time.Sleep(40 * time.Millisecond)
+
+	if dedup.Seen(program, solana.Signature{1}) || !dedup.Claim(program, solana.Signature{1}, "websocket") {
+		t.Error("expired signature is still suppressed")
+	}
+}
+
+func Test_DedupPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.json")
+	program := solana.NewWallet().PublicKey()
+
+	dedup := ingest.NewDedup(time.Hour, 100)
+	if err := dedup.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	dedup.Claim(program, solana.Signature{1}, "websocket")
+	if err := dedup.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart
+	restored := ingest.NewDedup(time.Hour, 100)
+	if err := restored.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Claim(program, solana.Signature{1}, "websocket") {
+		t.Error("signature of the previous run was delivered again")
+	}
+}
+
+// eventSource delivers its events regardless of ctx, then reports a disconnect.
+type eventSource struct {
+	events []ingest.Event
+}
+
+func (s *eventSource) Name() string { return "events" }
+
+func (s *eventSource) Subscribe(ctx context.Context, program solana.PublicKey, filter ingest.LogFilter, ch chan<- ingest.Event) error {
+	for _, ev := range s.events {
+		ch <- ev
+	}
+	return errors.New("disconnected")
+}
+
+func Test_DedupSourceCancelled(t *testing.T) {
+	program := solana.NewWallet().PublicKey()
+	dedup := ingest.NewDedup(time.Hour, 100)
+
+	// Shutting down while nobody reads the events
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	src := ingest.NewDedupSource(&eventSource{events: []ingest.Event{{Signature: solana.Signature{1}, Source: "websocket"}}}, dedup)
+	if err := src.Subscribe(ctx, program, nil, make(chan ingest.Event)); err == nil {
+		t.Error("expected the disconnect")
+	}
+
+	// The backfill of the next run still delivers it
+	if dedup.Seen(program, solana.Signature{1}) {
+		t.Error("undelivered signature is still claimed")
+	}
+	if stats := dedup.Stats(); len(stats) != 1 || stats[0].Forwarded != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,368 @@
+package ingest
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest/geyserpb"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// GeyserSource subscribes to transactions on a Yellowstone gRPC (Geyser) endpoint.
+// It delivers the full transaction and meta, so no RPC round trip is required.
+type GeyserSource struct {
+	Target   string // host:port
+	Token    string // Sent as x-token header when set
+	Insecure bool   // Plaintext connection, for local servers
+}
+
+// NewGeyserSource parses endpoints like https://host:443, http://127.0.0.1:10000 or host:port.
+func NewGeyserSource(endpoint string, token string) (*GeyserSource, error) {
+	src := &GeyserSource{Target: endpoint, Token: token}
+
+	u, err := url.Parse(endpoint)
+	if err == nil && u.Host != "" {
+		src.Target = u.Host
+		src.Insecure = u.Scheme == "http"
+		if u.Port() == "" {
+			if src.Insecure {
+				src.Target += ":80"
+			} else {
+				src.Target += ":443"
+			}
+		}
+	}
+
+	if src.Target == "" {
+		return nil, errors.New("empty geyser endpoint")
+	}
+
+	return src, nil
+}
+
+func (s *GeyserSource) Name() string {
+	return "geyser"
+}
+
+func (s *GeyserSource) Subscribe(ctx context.Context, program solana.PublicKey, filter LogFilter, ch chan<- Event) error {
+	creds := credentials.NewTLS(&tls.Config{})
+	if s.Insecure {
+		creds = insecure.NewCredentials()
+	}
+
+	conn, err := grpc.NewClient(s.Target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(geyserpb.Codec{})),
+	)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if s.Token != "" {
+		streamCtx = metadata.AppendToOutgoingContext(streamCtx, "x-token", s.Token)
+	}
+
+	stream, err := conn.NewStream(streamCtx, &grpc.StreamDesc{
+		StreamName:    "Subscribe",
+		ServerStreams: true,
+		ClientStreams: true,
+	}, geyserpb.SubscribeMethod)
+	if err != nil {
+		return err
+	}
+
+	// Failed and vote transactions are filtered out by the server
+	notVote, notFailed := false, false
+	commitment := geyserpb.CommitmentConfirmed
+	req := (&geyserpb.SubscribeRequest{
+		Transactions: map[string]*geyserpb.TransactionFilter{
+			program.String(): {
+				Vote:           &notVote,
+				Failed:         &notFailed,
+				AccountInclude: []string{program.String()},
+			},
+		},
+		Commitment: &commitment,
+	}).Marshal()
+	if err := stream.SendMsg(&req); err != nil {
+		return err
+	}
+
+	for {
+		var frame []byte
+		if err := stream.RecvMsg(&frame); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		var update geyserpb.SubscribeUpdate
+		if err := update.Unmarshal(frame); err != nil {
+			return fmt.Errorf("failed to decode geyser update: %w", err)
+		}
+
+		// Some providers close idle streams unless pings are answered
+		if update.Ping {
+			ping := (&geyserpb.SubscribeRequest{Ping: &geyserpb.Ping{ID: 1}}).Marshal()
+			if err := stream.SendMsg(&ping); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if update.Transaction == nil || update.Transaction.Transaction == nil {
+			continue
+		}
+
+		ev, err := EventFromGeyser(update.Transaction)
+		if err != nil {
+			return fmt.Errorf("failed to convert geyser transaction: %w", err)
+		}
+		ev.Source = s.Name()
+
+		if !filter(ev.Logs) {
+			continue
+		}
+
+		if !forward(ctx, ch, ev) {
+			return nil
+		}
+	}
+}
+
+// EventFromGeyser converts a geyser transaction update into an Event carrying the
+// same data a getTransaction call would return. Geyser does not provide the block
+// time, it is left nil.
+func EventFromGeyser(update *geyserpb.SubscribeUpdateTransaction) (Event, error) {
+	info := update.Transaction
+	if info.Transaction == nil || info.Transaction.Message == nil || info.Meta == nil {
+		return Event{}, errors.New("incomplete transaction")
+	}
+
+	tx, err := transactionFromGeyser(info.Transaction)
+	if err != nil {
+		return Event{}, err
+	}
+
+	envelope, err := newTransactionEnvelope(tx)
+	if err != nil {
+		return Event{}, err
+	}
+
+	meta, err := metaFromGeyser(info.Meta)
+	if err != nil {
+		return Event{}, err
+	}
+
+	version := rpc.LegacyTransactionVersion
+	if info.Transaction.Message.Versioned {
+		version = 0
+	}
+
+	return Event{
+		Signature: tx.Signatures[0],
+		Slot:      update.Slot,
+		Logs:      meta.LogMessages,
+		Transaction: &rpc.GetTransactionResult{
+			Slot:        update.Slot,
+			Transaction: envelope,
+			Meta:        meta,
+			Version:     version,
+		},
+		Tx: tx,
+	}, nil
+}
+
+func transactionFromGeyser(gtx *geyserpb.Transaction) (*solana.Transaction, error) {
+	if len(gtx.Signatures) == 0 {
+		return nil, errors.New("transaction without signatures")
+	}
+
+	tx := &solana.Transaction{}
+	for _, sig := range gtx.Signatures {
+		if len(sig) != solana.SignatureLength {
+			return nil, fmt.Errorf("invalid signature length %d", len(sig))
+		}
+		tx.Signatures = append(tx.Signatures, solana.SignatureFromBytes(sig))
+	}
+
+	msg := gtx.Message
+	keys, err := publicKeys(msg.AccountKeys)
+	if err != nil {
+		return nil, err
+	}
+	tx.Message.AccountKeys = keys
+
+	if msg.Header != nil {
+		tx.Message.Header = solana.MessageHeader{
+			NumRequiredSignatures:       uint8(msg.Header.NumRequiredSignatures),
+			NumReadonlySignedAccounts:   uint8(msg.Header.NumReadonlySignedAccounts),
+			NumReadonlyUnsignedAccounts: uint8(msg.Header.NumReadonlyUnsignedAccounts),
+		}
+	}
+
+	if len(msg.RecentBlockhash) == 32 {
+		tx.Message.RecentBlockhash = solana.HashFromBytes(msg.RecentBlockhash)
+	}
+
+	for _, ix := range msg.Instructions {
+		tx.Message.Instructions = append(tx.Message.Instructions, solana.CompiledInstruction{
+			ProgramIDIndex: uint16(ix.ProgramIDIndex),
+			Accounts:       accountIndexes(ix.Accounts),
+			Data:           ix.Data,
+		})
+	}
+
+	if msg.Versioned {
+		tx.Message.SetVersion(solana.MessageVersionV0)
+
+		var lookups []solana.MessageAddressTableLookup
+		for _, l := range msg.AddressTableLookups {
+			if len(l.AccountKey) != solana.PublicKeyLength {
+				return nil, errors.New("invalid address table key")
+			}
+			lookups = append(lookups, solana.MessageAddressTableLookup{
+				AccountKey:      solana.PublicKeyFromBytes(l.AccountKey),
+				WritableIndexes: l.WritableIndexes,
+				ReadonlyIndexes: l.ReadonlyIndexes,
+			})
+		}
+		if len(lookups) > 0 {
+			tx.Message.SetAddressTableLookups(lookups)
+		}
+	}
+
+	return tx, nil
+}
+
+func metaFromGeyser(gmeta *geyserpb.TransactionStatusMeta) (*rpc.TransactionMeta, error) {
+	meta := &rpc.TransactionMeta{
+		Fee:                  gmeta.Fee,
+		PreBalances:          gmeta.PreBalances,
+		PostBalances:         gmeta.PostBalances,
+		LogMessages:          gmeta.LogMessages,
+		ComputeUnitsConsumed: gmeta.ComputeUnitsConsumed,
+	}
+
+	// The error is bincode encoded, callers only check it against nil
+	if gmeta.Err != nil {
+		meta.Err = map[string]interface{}{"geyser": base64.StdEncoding.EncodeToString(gmeta.Err)}
+	}
+
+	for _, inner := range gmeta.InnerInstructions {
+		ii := rpc.InnerInstruction{Index: uint16(inner.Index)}
+		for _, ix := range inner.Instructions {
+			ii.Instructions = append(ii.Instructions, solana.CompiledInstruction{
+				ProgramIDIndex: uint16(ix.ProgramIDIndex),
+				Accounts:       accountIndexes(ix.Accounts),
+				Data:           ix.Data,
+			})
+		}
+		meta.InnerInstructions = append(meta.InnerInstructions, ii)
+	}
+
+	var err error
+	if meta.PreTokenBalances, err = tokenBalancesFromGeyser(gmeta.PreTokenBalances); err != nil {
+		return nil, err
+	}
+	if meta.PostTokenBalances, err = tokenBalancesFromGeyser(gmeta.PostTokenBalances); err != nil {
+		return nil, err
+	}
+	if meta.LoadedAddresses.Writable, err = publicKeys(gmeta.LoadedWritableAddresses); err != nil {
+		return nil, err
+	}
+	if meta.LoadedAddresses.ReadOnly, err = publicKeys(gmeta.LoadedReadonlyAddresses); err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+func tokenBalancesFromGeyser(balances []*geyserpb.TokenBalance) ([]rpc.TokenBalance, error) {
+	var out []rpc.TokenBalance
+	for _, b := range balances {
+		mint, err := solana.PublicKeyFromBase58(b.Mint)
+		if err != nil {
+			return nil, err
+		}
+
+		tb := rpc.TokenBalance{
+			AccountIndex: uint16(b.AccountIndex),
+			Mint:         mint,
+		}
+
+		if b.Owner != "" {
+			owner, err := solana.PublicKeyFromBase58(b.Owner)
+			if err != nil {
+				return nil, err
+			}
+			tb.Owner = &owner
+		}
+
+		if b.UiTokenAmount != nil {
+			uiAmount := b.UiTokenAmount.UiAmount
+			tb.UiTokenAmount = &rpc.UiTokenAmount{
+				Amount:         b.UiTokenAmount.Amount,
+				Decimals:       uint8(b.UiTokenAmount.Decimals),
+				UiAmount:       &uiAmount,
+				UiAmountString: b.UiTokenAmount.UiAmountString,
+			}
+		}
+
+		out = append(out, tb)
+	}
+	return out, nil
+}
+
+// newTransactionEnvelope wraps tx the way getTransaction returns it with base64 encoding.
+func newTransactionEnvelope(tx *solana.Transaction) (*rpc.TransactionResultEnvelope, error) {
+	bin, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal([]string{base64.StdEncoding.EncodeToString(bin), "base64"})
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope rpc.TransactionResultEnvelope
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, err
+	}
+	return &envelope, nil
+}
+
+func publicKeys(raw [][]byte) (solana.PublicKeySlice, error) {
+	var keys solana.PublicKeySlice
+	for _, k := range raw {
+		if len(k) != solana.PublicKeyLength {
+			return nil, fmt.Errorf("invalid public key length %d", len(k))
+		}
+		keys = append(keys, solana.PublicKeyFromBytes(k))
+	}
+	return keys, nil
+}
+
+func accountIndexes(raw []byte) []uint16 {
+	out := make([]uint16, len(raw))
+	for i, idx := range raw {
+		out[i] = uint16(idx)
+	}
+	return out
+}
@@ -0,0 +1,221 @@
+package ingest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest/geyserpb"
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest/geysertest"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func testTransaction(seed byte, program solana.PublicKey, versioned bool) (*solana.Transaction, *rpc.TransactionMeta) {
+	var sig solana.Signature
+	sig[0] = seed
+
+	payer := solana.PublicKeyFromBytes(append([]byte{seed}, make([]byte, 31)...))
+	mint := solana.NewWallet().PublicKey()
+	owner := solana.NewWallet().PublicKey()
+
+	tx := &solana.Transaction{
+		Signatures: []solana.Signature{sig},
+		Message: solana.Message{
+			AccountKeys: solana.PublicKeySlice{payer, mint, program},
+			Header: solana.MessageHeader{
+				NumRequiredSignatures:       1,
+				NumReadonlyUnsignedAccounts: 1,
+			},
+			RecentBlockhash: solana.Hash{seed},
+			Instructions: []solana.CompiledInstruction{
+				{ProgramIDIndex: 2, Accounts: []uint16{0, 1, 3}, Data: []byte{1, 2, 3}},
+			},
+		},
+	}
+
+	meta := &rpc.TransactionMeta{
+		Fee:          5000,
+		PreBalances:  []uint64{10_000_000_000, 0, 1},
+		PostBalances: []uint64{9_000_000_000, 0, 1},
+		LogMessages: []string{
+			"Program " + program.String() + " invoke [1]",
+			"Program log: initialize2: InitializeInstruction2 { nonce: 254 }",
+		},
+	}
+
+	uiAmount := 12.5
+	meta.PostTokenBalances = []rpc.TokenBalance{{
+		AccountIndex: 1,
+		Mint:         mint,
+		Owner:        &owner,
+		UiTokenAmount: &rpc.UiTokenAmount{
+			Amount:         "12500000",
+			Decimals:       6,
+			UiAmount:       &uiAmount,
+			UiAmountString: "12.5",
+		},
+	}}
+
+	if versioned {
+		table := solana.NewWallet().PublicKey()
+		loaded := solana.NewWallet().PublicKey()
+		tx.Message.SetAddressTableLookups([]solana.MessageAddressTableLookup{
+			{AccountKey: table, WritableIndexes: []uint8{4}},
+		})
+		meta.LoadedAddresses.Writable = solana.PublicKeySlice{loaded}
+	}
+
+	return tx, meta
+}
+
+func Test_GeyserSource(t *testing.T) {
+	server, err := geysertest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	src, err := ingest.NewGeyserSource("http://"+server.Addr, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	program := solana.NewWallet().PublicKey()
+	filter := func(logs []string) bool {
+		for _, log := range logs {
+			if strings.Contains(log, "initialize2") {
+				return true
+			}
+		}
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan ingest.Event, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- src.Subscribe(ctx, program, filter, ch)
+	}()
+
+	select {
+	case <-server.Subscribed():
+	case <-time.After(5 * time.Second):
+		t.Fatal("client never subscribed")
+	}
+
+	// A ping, a transaction rejected by the filter and two accepted ones
+	server.Send(&geyserpb.SubscribeUpdate{Ping: true})
+
+	rejected, rejectedMeta := testTransaction(1, program, false)
+	rejectedMeta.LogMessages = []string{"Program log: swap"}
+	server.SendTransaction(100, rejected, rejectedMeta)
+
+	legacy, legacyMeta := testTransaction(2, program, false)
+	server.SendTransaction(101, legacy, legacyMeta)
+
+	versioned, versionedMeta := testTransaction(3, program, true)
+	server.SendTransaction(102, versioned, versionedMeta)
+
+	var events []ingest.Event
+	for len(events) < 2 {
+		select {
+		case ev := <-ch:
+			events = append(events, ev)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of 2 events", len(events))
+		}
+	}
+
+	// The ping reply is recorded asynchronously by the server
+	deadline := time.Now().Add(5 * time.Second)
+	for len(server.Requests()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Subscribe returned error after cancel: %v", err)
+	}
+
+	for i, want := range []struct {
+		tx   *solana.Transaction
+		meta *rpc.TransactionMeta
+		slot uint64
+	}{
+		{legacy, legacyMeta, 101},
+		{versioned, versionedMeta, 102},
+	} {
+		ev := events[i]
+
+		if ev.Signature != want.tx.Signatures[0] || ev.Slot != want.slot || ev.Source != "geyser" {
+			t.Errorf("event %d: unexpected header %s/%d/%s", i, ev.Signature, ev.Slot, ev.Source)
+		}
+		if ev.Transaction == nil || ev.Tx == nil {
+			t.Fatalf("event %d: transaction missing", i)
+		}
+		if ev.Tx.Message.AccountKeys[2] != program || ev.Tx.Message.Instructions[0].Accounts[2] != 3 {
+			t.Errorf("event %d: message not decoded", i)
+		}
+		if ev.Tx.Message.IsVersioned() != want.tx.Message.IsVersioned() {
+			t.Errorf("event %d: version mismatch", i)
+		}
+
+		// The envelope must decode the same way a getTransaction response does
+		decoded, err := ev.Transaction.Transaction.GetTransaction()
+		if err != nil || decoded.Signatures[0] != want.tx.Signatures[0] {
+			t.Errorf("event %d: envelope not usable: %v", i, err)
+		}
+
+		meta := ev.Transaction.Meta
+		if meta.Err != nil || meta.PreBalances[0] != want.meta.PreBalances[0] || meta.PostBalances[0] != want.meta.PostBalances[0] {
+			t.Errorf("event %d: balances not decoded", i)
+		}
+		balance := meta.PostTokenBalances[0]
+		if balance.Mint != want.meta.PostTokenBalances[0].Mint || *balance.Owner != *want.meta.PostTokenBalances[0].Owner || *balance.UiTokenAmount.UiAmount != 12.5 {
+			t.Errorf("event %d: token balances not decoded: %+v", i, balance)
+		}
+		if len(meta.LoadedAddresses.Writable) != len(want.meta.LoadedAddresses.Writable) {
+			t.Errorf("event %d: loaded addresses not decoded", i)
+		}
+		if ev.Transaction.BlockTime != nil {
+			t.Errorf("event %d: block time not provided by geyser, got %v", i, ev.Transaction.BlockTime)
+		}
+	}
+
+	// Subscribe request, followed by the ping reply
+	requests := server.Requests()
+	if len(requests) < 2 || requests[1].Ping == nil {
+		t.Fatalf("expected subscribe request and ping reply, got %d requests", len(requests))
+	}
+	txFilter := requests[0].Transactions[program.String()]
+	if txFilter == nil || txFilter.AccountInclude[0] != program.String() || *txFilter.Failed || *txFilter.Vote {
+		t.Errorf("unexpected transaction filter: %+v", txFilter)
+	}
+	if *requests[0].Commitment != geyserpb.CommitmentConfirmed {
+		t.Errorf("unexpected commitment %d", *requests[0].Commitment)
+	}
+	if tokens := server.Tokens(); len(tokens) != 1 || tokens[0] != "secret" {
+		t.Errorf("unexpected tokens %v", tokens)
+	}
+}
+
+func Test_NewGeyserSource(t *testing.T) {
+	for endpoint, want := range map[string]ingest.GeyserSource{
+		"https://grpc.example.com":    {Target: "grpc.example.com:443"},
+		"https://grpc.example.com:10": {Target: "grpc.example.com:10"},
+		"http://127.0.0.1:10000":      {Target: "127.0.0.1:10000", Insecure: true},
+		"grpc.example.com:443":        {Target: "grpc.example.com:443"},
+	} {
+		src, err := ingest.NewGeyserSource(endpoint, "")
+		if err != nil {
+			t.Errorf("%s: %v", endpoint, err)
+			continue
+		}
+		if *src != want {
+			t.Errorf("%s: got %+v, want %+v", endpoint, *src, want)
+		}
+	}
+}
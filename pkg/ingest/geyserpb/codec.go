@@ -0,0 +1,29 @@
+package geyserpb
+
+import "fmt"
+
+// Codec passes already encoded messages through gRPC, so the messages in this
+// package can be used without generated code. Values must be of type *[]byte.
+// Use it with grpc.ForceCodec on clients and grpc.ForceServerCodec on servers.
+type Codec struct{}
+
+func (Codec) Marshal(v any) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("geyserpb: cannot marshal %T", v)
+	}
+	return *b, nil
+}
+
+func (Codec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("geyserpb: cannot unmarshal into %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (Codec) Name() string {
+	return "proto"
+}
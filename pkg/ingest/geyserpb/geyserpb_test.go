@@ -0,0 +1,349 @@
+package geyserpb
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// The messages of geyser.proto and solana-storage.proto this package covers, with their
+// upstream names. Encoding through the reference implementation checks the field numbers,
+// wire types and proto3 optional fields of the hand written codec.
+const schema = `
+name: "geyser.proto" package: "geyser" syntax: "proto3"
+enum_type { name: "CommitmentLevel" value { name: "PROCESSED" number: 0 } value { name: "CONFIRMED" number: 1 } value { name: "FINALIZED" number: 2 } }
+message_type {
+  name: "SubscribeRequest"
+  field { name: "transactions" number: 3 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".geyser.SubscribeRequest.TransactionsEntry" }
+  field { name: "commitment" number: 6 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".geyser.CommitmentLevel" oneof_index: 0 proto3_optional: true }
+  field { name: "ping" number: 9 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".geyser.SubscribeRequestPing" oneof_index: 1 proto3_optional: true }
+  nested_type {
+    name: "TransactionsEntry" options { map_entry: true }
+    field { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+    field { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".geyser.SubscribeRequestFilterTransactions" }
+  }
+  oneof_decl { name: "_commitment" } oneof_decl { name: "_ping" }
+}
+message_type {
+  name: "SubscribeRequestFilterTransactions"
+  field { name: "vote" number: 1 label: LABEL_OPTIONAL type: TYPE_BOOL oneof_index: 0 proto3_optional: true }
+  field { name: "failed" number: 2 label: LABEL_OPTIONAL type: TYPE_BOOL oneof_index: 1 proto3_optional: true }
+  field { name: "account_include" number: 3 label: LABEL_REPEATED type: TYPE_STRING }
+  field { name: "account_exclude" number: 4 label: LABEL_REPEATED type: TYPE_STRING }
+  field { name: "account_required" number: 6 label: LABEL_REPEATED type: TYPE_STRING }
+  oneof_decl { name: "_vote" } oneof_decl { name: "_failed" }
+}
+message_type { name: "SubscribeRequestPing" field { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 } }
+message_type {
+  name: "SubscribeUpdate"
+  field { name: "filters" number: 1 label: LABEL_REPEATED type: TYPE_STRING }
+  field { name: "transaction" number: 4 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".geyser.SubscribeUpdateTransaction" oneof_index: 0 }
+  field { name: "ping" number: 6 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".geyser.SubscribeUpdatePing" oneof_index: 0 }
+  oneof_decl { name: "update_oneof" }
+}
+message_type { name: "SubscribeUpdatePing" }
+message_type {
+  name: "SubscribeUpdateTransaction"
+  field { name: "transaction" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".geyser.SubscribeUpdateTransactionInfo" }
+  field { name: "slot" number: 2 label: LABEL_OPTIONAL type: TYPE_UINT64 }
+}
+message_type {
+  name: "SubscribeUpdateTransactionInfo"
+  field { name: "signature" number: 1 label: LABEL_OPTIONAL type: TYPE_BYTES }
+  field { name: "is_vote" number: 2 label: LABEL_OPTIONAL type: TYPE_BOOL }
+  field { name: "transaction" number: 3 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".geyser.Transaction" }
+  field { name: "meta" number: 4 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".geyser.TransactionStatusMeta" }
+  field { name: "index" number: 5 label: LABEL_OPTIONAL type: TYPE_UINT64 }
+}
+message_type {
+  name: "Transaction"
+  field { name: "signatures" number: 1 label: LABEL_REPEATED type: TYPE_BYTES }
+  field { name: "message" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".geyser.Message" }
+}
+message_type {
+  name: "Message"
+  field { name: "header" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".geyser.MessageHeader" }
+  field { name: "account_keys" number: 2 label: LABEL_REPEATED type: TYPE_BYTES }
+  field { name: "recent_blockhash" number: 3 label: LABEL_OPTIONAL type: TYPE_BYTES }
+  field { name: "instructions" number: 4 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".geyser.CompiledInstruction" }
+  field { name: "versioned" number: 5 label: LABEL_OPTIONAL type: TYPE_BOOL }
+  field { name: "address_table_lookups" number: 6 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".geyser.MessageAddressTableLookup" }
+}
+message_type {
+  name: "MessageHeader"
+  field { name: "num_required_signatures" number: 1 label: LABEL_OPTIONAL type: TYPE_UINT32 }
+  field { name: "num_readonly_signed_accounts" number: 2 label: LABEL_OPTIONAL type: TYPE_UINT32 }
+  field { name: "num_readonly_unsigned_accounts" number: 3 label: LABEL_OPTIONAL type: TYPE_UINT32 }
+}
+message_type {
+  name: "CompiledInstruction"
+  field { name: "program_id_index" number: 1 label: LABEL_OPTIONAL type: TYPE_UINT32 }
+  field { name: "accounts" number: 2 label: LABEL_OPTIONAL type: TYPE_BYTES }
+  field { name: "data" number: 3 label: LABEL_OPTIONAL type: TYPE_BYTES }
+}
+message_type {
+  name: "MessageAddressTableLookup"
+  field { name: "account_key" number: 1 label: LABEL_OPTIONAL type: TYPE_BYTES }
+  field { name: "writable_indexes" number: 2 label: LABEL_OPTIONAL type: TYPE_BYTES }
+  field { name: "readonly_indexes" number: 3 label: LABEL_OPTIONAL type: TYPE_BYTES }
+}
+message_type {
+  name: "TransactionStatusMeta"
+  field { name: "err" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".geyser.TransactionError" }
+  field { name: "fee" number: 2 label: LABEL_OPTIONAL type: TYPE_UINT64 }
+  field { name: "pre_balances" number: 3 label: LABEL_REPEATED type: TYPE_UINT64 }
+  field { name: "post_balances" number: 4 label: LABEL_REPEATED type: TYPE_UINT64 }
+  field { name: "inner_instructions" number: 5 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".geyser.InnerInstructions" }
+  field { name: "log_messages" number: 6 label: LABEL_REPEATED type: TYPE_STRING }
+  field { name: "pre_token_balances" number: 7 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".geyser.TokenBalance" }
+  field { name: "post_token_balances" number: 8 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".geyser.TokenBalance" }
+  field { name: "loaded_writable_addresses" number: 12 label: LABEL_REPEATED type: TYPE_BYTES }
+  field { name: "loaded_readonly_addresses" number: 13 label: LABEL_REPEATED type: TYPE_BYTES }
+  field { name: "compute_units_consumed" number: 16 label: LABEL_OPTIONAL type: TYPE_UINT64 oneof_index: 0 proto3_optional: true }
+  oneof_decl { name: "_compute_units_consumed" }
+}
+message_type { name: "TransactionError" field { name: "err" number: 1 label: LABEL_OPTIONAL type: TYPE_BYTES } }
+message_type {
+  name: "InnerInstructions"
+  field { name: "index" number: 1 label: LABEL_OPTIONAL type: TYPE_UINT32 }
+  field { name: "instructions" number: 2 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".geyser.InnerInstruction" }
+}
+message_type {
+  name: "InnerInstruction"
+  field { name: "program_id_index" number: 1 label: LABEL_OPTIONAL type: TYPE_UINT32 }
+  field { name: "accounts" number: 2 label: LABEL_OPTIONAL type: TYPE_BYTES }
+  field { name: "data" number: 3 label: LABEL_OPTIONAL type: TYPE_BYTES }
+  field { name: "stack_height" number: 4 label: LABEL_OPTIONAL type: TYPE_UINT32 oneof_index: 0 proto3_optional: true }
+  oneof_decl { name: "_stack_height" }
+}
+message_type {
+  name: "TokenBalance"
+  field { name: "account_index" number: 1 label: LABEL_OPTIONAL type: TYPE_UINT32 }
+  field { name: "mint" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING }
+  field { name: "ui_token_amount" number: 3 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".geyser.UiTokenAmount" }
+  field { name: "owner" number: 4 label: LABEL_OPTIONAL type: TYPE_STRING }
+  field { name: "program_id" number: 5 label: LABEL_OPTIONAL type: TYPE_STRING }
+}
+message_type {
+  name: "UiTokenAmount"
+  field { name: "ui_amount" number: 1 label: LABEL_OPTIONAL type: TYPE_DOUBLE }
+  field { name: "decimals" number: 2 label: LABEL_OPTIONAL type: TYPE_UINT32 }
+  field { name: "amount" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING }
+  field { name: "ui_amount_string" number: 4 label: LABEL_OPTIONAL type: TYPE_STRING }
+}
+`
+
+func referenceMessage(t *testing.T, name protoreflect.FullName) *dynamicpb.Message {
+	t.Helper()
+
+	fd := &descriptorpb.FileDescriptorProto{}
+	if err := prototext.Unmarshal([]byte(schema), fd); err != nil {
+		t.Fatal(err)
+	}
+	file, err := protodesc.NewFile(fd, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc := file.Messages().ByName(name.Name())
+	if desc == nil {
+		t.Fatalf("message %s not in the schema", name)
+	}
+	return dynamicpb.NewMessage(desc)
+}
+
+// assertKnown fails for fields the reference implementation could not match to the
+// schema, e.g. because of a wrong wire type.
+func assertKnown(t *testing.T, path string, m protoreflect.Message) {
+	t.Helper()
+
+	if unknown := m.GetUnknown(); len(unknown) > 0 {
+		t.Errorf("%s: unknown fields %x", path, unknown)
+	}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				if fd.MapValue().Message() != nil {
+					assertKnown(t, path+"."+string(fd.Name())+"["+k.String()+"]", mv.Message())
+				}
+				return true
+			})
+		case fd.IsList() && fd.Message() != nil:
+			for i := 0; i < v.List().Len(); i++ {
+				assertKnown(t, path+"."+string(fd.Name()), v.List().Get(i).Message())
+			}
+		case fd.Message() != nil:
+			assertKnown(t, path+"."+string(fd.Name()), v.Message())
+		}
+		return true
+	})
+}
+
+// roundTrip encodes msg, decodes it with the reference implementation and returns its
+// encoding of the same message.
+func roundTrip(t *testing.T, name protoreflect.FullName, encoded []byte) []byte {
+	t.Helper()
+
+	ref := referenceMessage(t, name)
+	if err := proto.Unmarshal(encoded, ref); err != nil {
+		t.Fatalf("reference decode: %v", err)
+	}
+	assertKnown(t, string(name), ref)
+
+	out, err := proto.MarshalOptions{Deterministic: true}.Marshal(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func Test_SubscribeRequest(t *testing.T) {
+	no, commitment := false, CommitmentProcessed
+	want := &SubscribeRequest{
+		Transactions: map[string]*TransactionFilter{
+			"raydium": {Vote: &no, Failed: &no, AccountInclude: []string{"675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8"}},
+			"orca":    {AccountInclude: []string{"whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc"}, AccountExclude: []string{"a"}, AccountRequired: []string{"b", "c"}},
+		},
+		// Processed and false are the zero values, but still sent
+		Commitment: &commitment,
+	}
+
+	got := &SubscribeRequest{}
+	if err := got.Unmarshal(roundTrip(t, "geyser.SubscribeRequest", want.Marshal())); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got.Transactions["raydium"].Vote == nil || got.Transactions["orca"].Vote != nil {
+		t.Error("optional vote not preserved")
+	}
+
+	// The reply to a ping, field 9 holding id 7
+	ping := (&SubscribeRequest{Ping: &Ping{ID: 7}}).Marshal()
+	if want := []byte{0x4a, 0x02, 0x08, 0x07}; !reflect.DeepEqual(ping, want) {
+		t.Errorf("ping: got %x, want %x", ping, want)
+	}
+}
+
+func Test_SubscribeUpdate(t *testing.T) {
+	stackHeight, units := uint32(2), uint64(61_234)
+	key := func(b byte) []byte {
+		k := make([]byte, 32)
+		k[0] = b
+		return k
+	}
+	balance := func(index uint32, amount string, ui float64, uiString string) *TokenBalance {
+		return &TokenBalance{
+			AccountIndex:  index,
+			Mint:          "So11111111111111111111111111111111111111112",
+			UiTokenAmount: &UiTokenAmount{UiAmount: ui, Decimals: 9, Amount: amount, UiAmountString: uiString},
+			Owner:         "9TvWjT8bHxHTNCqAnhg6keHp24p8Gp4854hxFpcD2JMt",
+			ProgramID:     "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA",
+		}
+	}
+
+	want := &SubscribeUpdate{
+		Filters: []string{"raydium"},
+		Transaction: &SubscribeUpdateTransaction{
+			Slot: 268_000_100,
+			Transaction: &TransactionInfo{
+				Signature: make([]byte, 64),
+				Index:     17,
+				Transaction: &Transaction{
+					Signatures: [][]byte{make([]byte, 64)},
+					Message: &Message{
+						Header:          &MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 2},
+						AccountKeys:     [][]byte{key(1), key(2), key(3)},
+						RecentBlockhash: key(9),
+						Instructions: []*CompiledInstruction{
+							{ProgramIDIndex: 2, Accounts: []byte{0, 1, 3}, Data: []byte{0x01, 0xfe}},
+						},
+						Versioned: true,
+						AddressTableLookups: []*AddressTableLookup{
+							{AccountKey: key(4), WritableIndexes: []byte{5}, ReadonlyIndexes: []byte{6, 7}},
+						},
+					},
+				},
+				Meta: &TransactionStatusMeta{
+					Fee: 5000,
+					// Balances above 2^63 do not exist, but the varints must not be sign extended
+					PreBalances:  []uint64{100_000_000_000, 0, 1 << 63},
+					PostBalances: []uint64{99_999_995_000, 0, 1 << 63},
+					InnerInstructions: []*InnerInstructions{{
+						Index:        0,
+						Instructions: []*InnerInstruction{{ProgramIDIndex: 1, Accounts: []byte{3}, Data: []byte{0x03}, StackHeight: &stackHeight}},
+					}},
+					LogMessages:             []string{"Program log: Instruction: Initialize2"},
+					PreTokenBalances:        []*TokenBalance{balance(3, "0", 0, "0")},
+					PostTokenBalances:       []*TokenBalance{balance(3, "12500000000", 12.5, "12.5")},
+					LoadedWritableAddresses: [][]byte{key(5)},
+					LoadedReadonlyAddresses: [][]byte{key(6), key(7)},
+					ComputeUnitsConsumed:    &units,
+				},
+			},
+		},
+	}
+
+	got := &SubscribeUpdate{}
+	if err := got.Unmarshal(roundTrip(t, "geyser.SubscribeUpdate", want.Marshal())); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got.Transaction.Transaction, want.Transaction.Transaction)
+	}
+
+	// Failed transactions carry the bincode encoded error
+	failed := &SubscribeUpdate{Transaction: &SubscribeUpdateTransaction{Slot: 1, Transaction: &TransactionInfo{Meta: &TransactionStatusMeta{Err: []byte{8, 0, 0, 0, 0x2a}}}}}
+	got = &SubscribeUpdate{}
+	if err := got.Unmarshal(roundTrip(t, "geyser.SubscribeUpdate", failed.Marshal())); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Transaction.Transaction.Meta.Err, failed.Transaction.Transaction.Meta.Err) {
+		t.Errorf("err: got %x", got.Transaction.Transaction.Meta.Err)
+	}
+
+	// Pings are empty messages
+	got = &SubscribeUpdate{}
+	if err := got.Unmarshal(roundTrip(t, "geyser.SubscribeUpdate", (&SubscribeUpdate{Ping: true}).Marshal())); err != nil || !got.Ping || got.Transaction != nil {
+		t.Errorf("ping: got %+v (%v)", got, err)
+	}
+}
+
+func Test_UnmarshalWire(t *testing.T) {
+	// Older encoders write repeated scalars unpacked, unknown fields are skipped
+	var b []byte
+	b = protowire.AppendTag(b, 3, protowire.VarintType)
+	b = protowire.AppendVarint(b, 10)
+	b = protowire.AppendTag(b, 3, protowire.VarintType)
+	b = protowire.AppendVarint(b, 20)
+	b = protowire.AppendTag(b, 99, protowire.Fixed32Type)
+	b = protowire.AppendFixed32(b, 1)
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, 5000)
+
+	meta := &TransactionStatusMeta{}
+	if err := meta.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(meta.PreBalances, []uint64{10, 20}) || meta.Fee != 5000 {
+		t.Errorf("got %+v", meta)
+	}
+
+	// Truncated messages are errors, not partial messages
+	encoded := (&SubscribeUpdate{Filters: []string{"raydium"}, Transaction: &SubscribeUpdateTransaction{Slot: 42}}).Marshal()
+	for _, n := range []int{1, len(encoded) / 2, len(encoded) - 1} {
+		if err := (&SubscribeUpdate{}).Unmarshal(encoded[:n]); err == nil {
+			t.Errorf("no error for %d of %d bytes", n, len(encoded))
+		}
+	}
+}
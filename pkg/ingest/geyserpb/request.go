@@ -0,0 +1,137 @@
+package geyserpb
+
+import (
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type CommitmentLevel int32
+
+const (
+	CommitmentProcessed CommitmentLevel = 0
+	CommitmentConfirmed CommitmentLevel = 1
+	CommitmentFinalized CommitmentLevel = 2
+)
+
+// SubscribeRequest only covers transaction filters, commitment and pings.
+type SubscribeRequest struct {
+	Transactions map[string]*TransactionFilter // field 3
+	Commitment   *CommitmentLevel              // field 6
+	Ping         *Ping                         // field 9, 8 is the entry filter
+}
+
+type TransactionFilter struct {
+	Vote            *bool    // field 1
+	Failed          *bool    // field 2
+	AccountInclude  []string // field 3
+	AccountExclude  []string // field 4
+	AccountRequired []string // field 6
+}
+
+type Ping struct {
+	ID int32 // field 1
+}
+
+func (m *SubscribeRequest) Marshal() []byte {
+	var b []byte
+
+	// Map entries are encoded in a stable order
+	keys := make([]string, 0, len(m.Transactions))
+	for k := range m.Transactions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var entry []byte
+		entry = appendString(entry, 1, k)
+		entry = appendMessage(entry, 2, m.Transactions[k].Marshal())
+		b = appendMessage(b, 3, entry)
+	}
+
+	if m.Commitment != nil {
+		b = protowire.AppendTag(b, 6, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*m.Commitment))
+	}
+
+	if m.Ping != nil {
+		b = appendMessage(b, 9, appendVarint(nil, 1, uint64(m.Ping.ID)))
+	}
+
+	return b
+}
+
+func (m *SubscribeRequest) Unmarshal(b []byte) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 3:
+			var key string
+			filter := &TransactionFilter{}
+			err := fields(f.b, func(ef field) error {
+				switch ef.num {
+				case 1:
+					key = string(ef.b)
+				case 2:
+					return filter.Unmarshal(ef.b)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if m.Transactions == nil {
+				m.Transactions = make(map[string]*TransactionFilter)
+			}
+			m.Transactions[key] = filter
+		case 6:
+			c := CommitmentLevel(f.v)
+			m.Commitment = &c
+		case 9:
+			m.Ping = &Ping{}
+			return fields(f.b, func(pf field) error {
+				if pf.num == 1 {
+					m.Ping.ID = int32(pf.v)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+}
+
+func (m *TransactionFilter) Marshal() []byte {
+	var b []byte
+	b = appendOptionalBool(b, 1, m.Vote)
+	b = appendOptionalBool(b, 2, m.Failed)
+	for _, s := range m.AccountInclude {
+		b = appendString(b, 3, s)
+	}
+	for _, s := range m.AccountExclude {
+		b = appendString(b, 4, s)
+	}
+	for _, s := range m.AccountRequired {
+		b = appendString(b, 6, s)
+	}
+	return b
+}
+
+func (m *TransactionFilter) Unmarshal(b []byte) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			v := f.v != 0
+			m.Vote = &v
+		case 2:
+			v := f.v != 0
+			m.Failed = &v
+		case 3:
+			m.AccountInclude = append(m.AccountInclude, string(f.b))
+		case 4:
+			m.AccountExclude = append(m.AccountExclude, string(f.b))
+		case 6:
+			m.AccountRequired = append(m.AccountRequired, string(f.b))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,453 @@
+package geyserpb
+
+// SubscribeUpdate only decodes transaction and ping updates, other updates are skipped.
+type SubscribeUpdate struct {
+	Filters     []string                    // field 1
+	Transaction *SubscribeUpdateTransaction // field 4
+	Ping        bool                        // field 6
+}
+
+type SubscribeUpdateTransaction struct {
+	Transaction *TransactionInfo // field 1
+	Slot        uint64           // field 2
+}
+
+type TransactionInfo struct {
+	Signature   []byte                 // field 1
+	IsVote      bool                   // field 2
+	Transaction *Transaction           // field 3
+	Meta        *TransactionStatusMeta // field 4
+	Index       uint64                 // field 5
+}
+
+type Transaction struct {
+	Signatures [][]byte // field 1
+	Message    *Message // field 2
+}
+
+type Message struct {
+	Header              *MessageHeader         // field 1
+	AccountKeys         [][]byte               // field 2
+	RecentBlockhash     []byte                 // field 3
+	Instructions        []*CompiledInstruction // field 4
+	Versioned           bool                   // field 5
+	AddressTableLookups []*AddressTableLookup  // field 6
+}
+
+type MessageHeader struct {
+	NumRequiredSignatures       uint32 // field 1
+	NumReadonlySignedAccounts   uint32 // field 2
+	NumReadonlyUnsignedAccounts uint32 // field 3
+}
+
+type CompiledInstruction struct {
+	ProgramIDIndex uint32 // field 1
+	Accounts       []byte // field 2
+	Data           []byte // field 3
+}
+
+type AddressTableLookup struct {
+	AccountKey      []byte // field 1
+	WritableIndexes []byte // field 2
+	ReadonlyIndexes []byte // field 3
+}
+
+type TransactionStatusMeta struct {
+	Err                     []byte               // field 1 (TransactionError.err), nil on success
+	Fee                     uint64               // field 2
+	PreBalances             []uint64             // field 3
+	PostBalances            []uint64             // field 4
+	InnerInstructions       []*InnerInstructions // field 5
+	LogMessages             []string             // field 6
+	PreTokenBalances        []*TokenBalance      // field 7
+	PostTokenBalances       []*TokenBalance      // field 8
+	LoadedWritableAddresses [][]byte             // field 12
+	LoadedReadonlyAddresses [][]byte             // field 13
+	ComputeUnitsConsumed    *uint64              // field 16
+}
+
+type InnerInstructions struct {
+	Index        uint32              // field 1
+	Instructions []*InnerInstruction // field 2
+}
+
+type InnerInstruction struct {
+	ProgramIDIndex uint32  // field 1
+	Accounts       []byte  // field 2
+	Data           []byte  // field 3
+	StackHeight    *uint32 // field 4
+}
+
+type TokenBalance struct {
+	AccountIndex  uint32         // field 1
+	Mint          string         // field 2
+	UiTokenAmount *UiTokenAmount // field 3
+	Owner         string         // field 4
+	ProgramID     string         // field 5
+}
+
+type UiTokenAmount struct {
+	UiAmount       float64 // field 1
+	Decimals       uint32  // field 2
+	Amount         string  // field 3
+	UiAmountString string  // field 4
+}
+
+func (m *SubscribeUpdate) Marshal() []byte {
+	var b []byte
+	for _, s := range m.Filters {
+		b = appendString(b, 1, s)
+	}
+	if m.Transaction != nil {
+		b = appendMessage(b, 4, m.Transaction.Marshal())
+	}
+	if m.Ping {
+		b = appendMessage(b, 6, nil)
+	}
+	return b
+}
+
+func (m *SubscribeUpdate) Unmarshal(b []byte) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.Filters = append(m.Filters, string(f.b))
+		case 4:
+			m.Transaction = &SubscribeUpdateTransaction{}
+			return m.Transaction.Unmarshal(f.b)
+		case 6:
+			m.Ping = true
+		}
+		return nil
+	})
+}
+
+func (m *SubscribeUpdateTransaction) Marshal() []byte {
+	var b []byte
+	if m.Transaction != nil {
+		b = appendMessage(b, 1, m.Transaction.Marshal())
+	}
+	return appendVarint(b, 2, m.Slot)
+}
+
+func (m *SubscribeUpdateTransaction) Unmarshal(b []byte) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.Transaction = &TransactionInfo{}
+			return m.Transaction.Unmarshal(f.b)
+		case 2:
+			m.Slot = f.v
+		}
+		return nil
+	})
+}
+
+func (m *TransactionInfo) Marshal() []byte {
+	var b []byte
+	b = appendBytes(b, 1, m.Signature)
+	b = appendBool(b, 2, m.IsVote)
+	if m.Transaction != nil {
+		b = appendMessage(b, 3, m.Transaction.Marshal())
+	}
+	if m.Meta != nil {
+		b = appendMessage(b, 4, m.Meta.Marshal())
+	}
+	return appendVarint(b, 5, m.Index)
+}
+
+func (m *TransactionInfo) Unmarshal(b []byte) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.Signature = f.b
+		case 2:
+			m.IsVote = f.v != 0
+		case 3:
+			m.Transaction = &Transaction{}
+			return m.Transaction.Unmarshal(f.b)
+		case 4:
+			m.Meta = &TransactionStatusMeta{}
+			return m.Meta.Unmarshal(f.b)
+		case 5:
+			m.Index = f.v
+		}
+		return nil
+	})
+}
+
+func (m *Transaction) Marshal() []byte {
+	var b []byte
+	for _, s := range m.Signatures {
+		b = appendBytes(b, 1, s)
+	}
+	if m.Message != nil {
+		b = appendMessage(b, 2, m.Message.Marshal())
+	}
+	return b
+}
+
+func (m *Transaction) Unmarshal(b []byte) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.Signatures = append(m.Signatures, f.b)
+		case 2:
+			m.Message = &Message{}
+			return m.Message.Unmarshal(f.b)
+		}
+		return nil
+	})
+}
+
+func (m *Message) Marshal() []byte {
+	var b []byte
+	if m.Header != nil {
+		var h []byte
+		h = appendVarint(h, 1, uint64(m.Header.NumRequiredSignatures))
+		h = appendVarint(h, 2, uint64(m.Header.NumReadonlySignedAccounts))
+		h = appendVarint(h, 3, uint64(m.Header.NumReadonlyUnsignedAccounts))
+		b = appendMessage(b, 1, h)
+	}
+	for _, k := range m.AccountKeys {
+		b = appendBytes(b, 2, k)
+	}
+	b = appendBytes(b, 3, m.RecentBlockhash)
+	for _, ix := range m.Instructions {
+		var ib []byte
+		ib = appendVarint(ib, 1, uint64(ix.ProgramIDIndex))
+		ib = appendBytes(ib, 2, ix.Accounts)
+		ib = appendBytes(ib, 3, ix.Data)
+		b = appendMessage(b, 4, ib)
+	}
+	b = appendBool(b, 5, m.Versioned)
+	for _, l := range m.AddressTableLookups {
+		var lb []byte
+		lb = appendBytes(lb, 1, l.AccountKey)
+		lb = appendBytes(lb, 2, l.WritableIndexes)
+		lb = appendBytes(lb, 3, l.ReadonlyIndexes)
+		b = appendMessage(b, 6, lb)
+	}
+	return b
+}
+
+func (m *Message) Unmarshal(b []byte) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.Header = &MessageHeader{}
+			return fields(f.b, func(hf field) error {
+				switch hf.num {
+				case 1:
+					m.Header.NumRequiredSignatures = uint32(hf.v)
+				case 2:
+					m.Header.NumReadonlySignedAccounts = uint32(hf.v)
+				case 3:
+					m.Header.NumReadonlyUnsignedAccounts = uint32(hf.v)
+				}
+				return nil
+			})
+		case 2:
+			m.AccountKeys = append(m.AccountKeys, f.b)
+		case 3:
+			m.RecentBlockhash = f.b
+		case 4:
+			ix := &CompiledInstruction{}
+			m.Instructions = append(m.Instructions, ix)
+			return fields(f.b, func(inf field) error {
+				switch inf.num {
+				case 1:
+					ix.ProgramIDIndex = uint32(inf.v)
+				case 2:
+					ix.Accounts = inf.b
+				case 3:
+					ix.Data = inf.b
+				}
+				return nil
+			})
+		case 5:
+			m.Versioned = f.v != 0
+		case 6:
+			l := &AddressTableLookup{}
+			m.AddressTableLookups = append(m.AddressTableLookups, l)
+			return fields(f.b, func(lf field) error {
+				switch lf.num {
+				case 1:
+					l.AccountKey = lf.b
+				case 2:
+					l.WritableIndexes = lf.b
+				case 3:
+					l.ReadonlyIndexes = lf.b
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+}
+
+func (m *TransactionStatusMeta) Marshal() []byte {
+	var b []byte
+	if m.Err != nil {
+		b = appendMessage(b, 1, appendBytes(nil, 1, m.Err))
+	}
+	b = appendVarint(b, 2, m.Fee)
+	b = appendPacked(b, 3, m.PreBalances)
+	b = appendPacked(b, 4, m.PostBalances)
+	for _, inner := range m.InnerInstructions {
+		var ib []byte
+		ib = appendVarint(ib, 1, uint64(inner.Index))
+		for _, ix := range inner.Instructions {
+			var xb []byte
+			xb = appendVarint(xb, 1, uint64(ix.ProgramIDIndex))
+			xb = appendBytes(xb, 2, ix.Accounts)
+			xb = appendBytes(xb, 3, ix.Data)
+			if ix.StackHeight != nil {
+				xb = appendVarint(xb, 4, uint64(*ix.StackHeight))
+			}
+			ib = appendMessage(ib, 2, xb)
+		}
+		b = appendMessage(b, 5, ib)
+	}
+	for _, l := range m.LogMessages {
+		b = appendString(b, 6, l)
+	}
+	for _, tb := range m.PreTokenBalances {
+		b = appendMessage(b, 7, tb.Marshal())
+	}
+	for _, tb := range m.PostTokenBalances {
+		b = appendMessage(b, 8, tb.Marshal())
+	}
+	for _, a := range m.LoadedWritableAddresses {
+		b = appendBytes(b, 12, a)
+	}
+	for _, a := range m.LoadedReadonlyAddresses {
+		b = appendBytes(b, 13, a)
+	}
+	if m.ComputeUnitsConsumed != nil {
+		b = appendVarint(b, 16, *m.ComputeUnitsConsumed)
+	}
+	return b
+}
+
+func (m *TransactionStatusMeta) Unmarshal(b []byte) error {
+	return fields(b, func(f field) error {
+		var err error
+		switch f.num {
+		case 1:
+			m.Err = []byte{}
+			err = fields(f.b, func(ef field) error {
+				if ef.num == 1 {
+					m.Err = ef.b
+				}
+				return nil
+			})
+		case 2:
+			m.Fee = f.v
+		case 3:
+			var vs []uint64
+			vs, err = f.uint64s()
+			m.PreBalances = append(m.PreBalances, vs...)
+		case 4:
+			var vs []uint64
+			vs, err = f.uint64s()
+			m.PostBalances = append(m.PostBalances, vs...)
+		case 5:
+			inner := &InnerInstructions{}
+			m.InnerInstructions = append(m.InnerInstructions, inner)
+			err = inner.Unmarshal(f.b)
+		case 6:
+			m.LogMessages = append(m.LogMessages, string(f.b))
+		case 7:
+			tb := &TokenBalance{}
+			m.PreTokenBalances = append(m.PreTokenBalances, tb)
+			err = tb.Unmarshal(f.b)
+		case 8:
+			tb := &TokenBalance{}
+			m.PostTokenBalances = append(m.PostTokenBalances, tb)
+			err = tb.Unmarshal(f.b)
+		case 12:
+			m.LoadedWritableAddresses = append(m.LoadedWritableAddresses, f.b)
+		case 13:
+			m.LoadedReadonlyAddresses = append(m.LoadedReadonlyAddresses, f.b)
+		case 16:
+			v := f.v
+			m.ComputeUnitsConsumed = &v
+		}
+		return err
+	})
+}
+
+func (m *InnerInstructions) Unmarshal(b []byte) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.Index = uint32(f.v)
+		case 2:
+			ix := &InnerInstruction{}
+			m.Instructions = append(m.Instructions, ix)
+			return fields(f.b, func(xf field) error {
+				switch xf.num {
+				case 1:
+					ix.ProgramIDIndex = uint32(xf.v)
+				case 2:
+					ix.Accounts = xf.b
+				case 3:
+					ix.Data = xf.b
+				case 4:
+					v := uint32(xf.v)
+					ix.StackHeight = &v
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+}
+
+func (m *TokenBalance) Marshal() []byte {
+	var b []byte
+	b = appendVarint(b, 1, uint64(m.AccountIndex))
+	b = appendString(b, 2, m.Mint)
+	if m.UiTokenAmount != nil {
+		var ub []byte
+		ub = appendDouble(ub, 1, m.UiTokenAmount.UiAmount)
+		ub = appendVarint(ub, 2, uint64(m.UiTokenAmount.Decimals))
+		ub = appendString(ub, 3, m.UiTokenAmount.Amount)
+		ub = appendString(ub, 4, m.UiTokenAmount.UiAmountString)
+		b = appendMessage(b, 3, ub)
+	}
+	b = appendString(b, 4, m.Owner)
+	return appendString(b, 5, m.ProgramID)
+}
+
+func (m *TokenBalance) Unmarshal(b []byte) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.AccountIndex = uint32(f.v)
+		case 2:
+			m.Mint = string(f.b)
+		case 3:
+			m.UiTokenAmount = &UiTokenAmount{}
+			return fields(f.b, func(uf field) error {
+				switch uf.num {
+				case 1:
+					m.UiTokenAmount.UiAmount = uf.double()
+				case 2:
+					m.UiTokenAmount.Decimals = uint32(uf.v)
+				case 3:
+					m.UiTokenAmount.Amount = string(uf.b)
+				case 4:
+					m.UiTokenAmount.UiAmountString = string(uf.b)
+				}
+				return nil
+			})
+		case 4:
+			m.Owner = string(f.b)
+		case 5:
+			m.ProgramID = string(f.b)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,148 @@
+// Package geyserpb is a hand written subset of the Yellowstone gRPC (geyser.proto and
+// solana-storage.proto) messages, covering transaction subscriptions only. It encodes
+// and decodes the protobuf wire format directly, so no generated code is required.
+package geyserpb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ServiceName and SubscribeMethod identify the bidirectional Subscribe stream.
+const (
+	ServiceName     = "geyser.Geyser"
+	SubscribeMethod = "/geyser.Geyser/Subscribe"
+)
+
+var errTruncated = errors.New("geyserpb: truncated message")
+
+// field is a single decoded field of a message.
+type field struct {
+	num protowire.Number
+	typ protowire.Type
+	v   uint64 // varint and fixed values
+	b   []byte // length delimited values
+}
+
+// fields calls fn for every field in b.
+func fields(b []byte, fn func(f field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		f := field{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			f.v, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v32 uint32
+			v32, n = protowire.ConsumeFixed32(b)
+			f.v = uint64(v32)
+		case protowire.BytesType:
+			f.b, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := fn(f); err != nil {
+			return fmt.Errorf("field %d: %w", num, err)
+		}
+	}
+	return nil
+}
+
+// uint64s decodes a repeated uint64 field, packed or not.
+func (f field) uint64s() ([]uint64, error) {
+	if f.typ == protowire.VarintType {
+		return []uint64{f.v}, nil
+	}
+
+	var out []uint64
+	b := f.b
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, errTruncated
+		}
+		out = append(out, v)
+		b = b[n:]
+	}
+	return out, nil
+}
+
+func (f field) double() float64 {
+	return math.Float64frombits(f.v)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendVarint(b, num, 1)
+}
+
+// appendOptionalBool also encodes false, for proto3 optional fields.
+func appendOptionalBool(b []byte, num protowire.Number, v *bool) []byte {
+	if v == nil {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, protowire.EncodeBool(*v))
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	return appendBytes(b, num, []byte(v))
+}
+
+// appendMessage always encodes the message, even when it is empty.
+func appendMessage(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendPacked(b []byte, num protowire.Number, vs []uint64) []byte {
+	if len(vs) == 0 {
+		return b
+	}
+	var packed []byte
+	for _, v := range vs {
+		packed = protowire.AppendVarint(packed, v)
+	}
+	return appendBytes(b, num, packed)
+}
+
+func appendDouble(b []byte, num protowire.Number, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
@@ -0,0 +1,261 @@
+// Package geysertest provides a local fake Yellowstone gRPC server for tests.
+package geysertest
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest/geyserpb"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Server accepts Subscribe streams and broadcasts the updates passed to Send.
+type Server struct {
+	Addr string // host:port the server listens on
+
+	srv *grpc.Server
+
+	mutex    sync.Mutex
+	streams  []chan []byte
+	requests []*geyserpb.SubscribeRequest
+	tokens   []string
+
+	subscribed chan struct{}
+}
+
+// NewServer starts a server listening on a random local port.
+func NewServer() (*Server, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Addr:       lis.Addr().String(),
+		srv:        grpc.NewServer(grpc.ForceServerCodec(geyserpb.Codec{})),
+		subscribed: make(chan struct{}, 16),
+	}
+
+	s.srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: geyserpb.ServiceName,
+		HandlerType: (*any)(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "Subscribe",
+			Handler:       s.subscribe,
+			ServerStreams: true,
+			ClientStreams: true,
+		}},
+	}, nil)
+
+	go s.srv.Serve(lis)
+
+	return s, nil
+}
+
+// Subscribed is signalled every time a client sent its subscribe request.
+func (s *Server) Subscribed() <-chan struct{} {
+	return s.subscribed
+}
+
+// Requests returns every subscribe request (including pings) received so far.
+func (s *Server) Requests() []*geyserpb.SubscribeRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*geyserpb.SubscribeRequest(nil), s.requests...)
+}
+
+// Tokens returns the x-token header of every stream.
+func (s *Server) Tokens() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.tokens...)
+}
+
+// Send broadcasts an update to all connected streams.
+func (s *Server) Send(update *geyserpb.SubscribeUpdate) {
+	frame := update.Marshal()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, stream := range s.streams {
+		stream <- frame
+	}
+}
+
+// SendTransaction broadcasts a transaction update built from the given transaction and meta.
+func (s *Server) SendTransaction(slot uint64, tx *solana.Transaction, meta *rpc.TransactionMeta) {
+	s.Send(&geyserpb.SubscribeUpdate{
+		Filters:     []string{"test"},
+		Transaction: TransactionUpdate(slot, tx, meta),
+	})
+}
+
+func (s *Server) Close() {
+	s.srv.Stop()
+}
+
+func (s *Server) subscribe(_ any, stream grpc.ServerStream) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	token := ""
+	if v := md.Get("x-token"); len(v) > 0 {
+		token = v[0]
+	}
+
+	frames := make(chan []byte, 64)
+
+	s.mutex.Lock()
+	s.streams = append(s.streams, frames)
+	s.tokens = append(s.tokens, token)
+	s.mutex.Unlock()
+
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			var frame []byte
+			if err := stream.RecvMsg(&frame); err != nil {
+				recvErr <- err
+				return
+			}
+
+			var req geyserpb.SubscribeRequest
+			if err := req.Unmarshal(frame); err != nil {
+				recvErr <- err
+				return
+			}
+
+			s.mutex.Lock()
+			s.requests = append(s.requests, &req)
+			s.mutex.Unlock()
+
+			if req.Ping == nil {
+				s.subscribed <- struct{}{}
+			}
+		}
+	}()
+
+	for {
+		select {
+		case frame := <-frames:
+			if err := stream.SendMsg(&frame); err != nil {
+				return err
+			}
+		case err := <-recvErr:
+			return ignoreEOF(err)
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func ignoreEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// TransactionUpdate converts a transaction and its meta into the geyser representation.
+func TransactionUpdate(slot uint64, tx *solana.Transaction, meta *rpc.TransactionMeta) *geyserpb.SubscribeUpdateTransaction {
+	msg := &geyserpb.Message{
+		Header: &geyserpb.MessageHeader{
+			NumRequiredSignatures:       uint32(tx.Message.Header.NumRequiredSignatures),
+			NumReadonlySignedAccounts:   uint32(tx.Message.Header.NumReadonlySignedAccounts),
+			NumReadonlyUnsignedAccounts: uint32(tx.Message.Header.NumReadonlyUnsignedAccounts),
+		},
+		RecentBlockhash: tx.Message.RecentBlockhash[:],
+		Versioned:       tx.Message.IsVersioned(),
+	}
+	for _, k := range tx.Message.AccountKeys {
+		msg.AccountKeys = append(msg.AccountKeys, k.Bytes())
+	}
+	for _, ix := range tx.Message.Instructions {
+		msg.Instructions = append(msg.Instructions, &geyserpb.CompiledInstruction{
+			ProgramIDIndex: uint32(ix.ProgramIDIndex),
+			Accounts:       accountBytes(ix.Accounts),
+			Data:           ix.Data,
+		})
+	}
+	for _, l := range tx.Message.AddressTableLookups {
+		msg.AddressTableLookups = append(msg.AddressTableLookups, &geyserpb.AddressTableLookup{
+			AccountKey:      l.AccountKey.Bytes(),
+			WritableIndexes: l.WritableIndexes,
+			ReadonlyIndexes: l.ReadonlyIndexes,
+		})
+	}
+
+	gtx := &geyserpb.Transaction{Message: msg}
+	for _, sig := range tx.Signatures {
+		gtx.Signatures = append(gtx.Signatures, sig[:])
+	}
+
+	gmeta := &geyserpb.TransactionStatusMeta{
+		Fee:                  meta.Fee,
+		PreBalances:          meta.PreBalances,
+		PostBalances:         meta.PostBalances,
+		LogMessages:          meta.LogMessages,
+		PreTokenBalances:     tokenBalances(meta.PreTokenBalances),
+		PostTokenBalances:    tokenBalances(meta.PostTokenBalances),
+		ComputeUnitsConsumed: meta.ComputeUnitsConsumed,
+	}
+	if meta.Err != nil {
+		gmeta.Err = []byte{1}
+	}
+	for _, k := range meta.LoadedAddresses.Writable {
+		gmeta.LoadedWritableAddresses = append(gmeta.LoadedWritableAddresses, k.Bytes())
+	}
+	for _, k := range meta.LoadedAddresses.ReadOnly {
+		gmeta.LoadedReadonlyAddresses = append(gmeta.LoadedReadonlyAddresses, k.Bytes())
+	}
+
+	var sig []byte
+	if len(tx.Signatures) > 0 {
+		sig = tx.Signatures[0][:]
+	}
+
+	return &geyserpb.SubscribeUpdateTransaction{
+		Transaction: &geyserpb.TransactionInfo{
+			Signature:   sig,
+			Transaction: gtx,
+			Meta:        gmeta,
+		},
+		Slot: slot,
+	}
+}
+
+func tokenBalances(balances []rpc.TokenBalance) []*geyserpb.TokenBalance {
+	var out []*geyserpb.TokenBalance
+	for _, b := range balances {
+		tb := &geyserpb.TokenBalance{
+			AccountIndex: uint32(b.AccountIndex),
+			Mint:         b.Mint.String(),
+		}
+		if b.Owner != nil {
+			tb.Owner = b.Owner.String()
+		}
+		if b.UiTokenAmount != nil {
+			tb.UiTokenAmount = &geyserpb.UiTokenAmount{
+				Decimals:       uint32(b.UiTokenAmount.Decimals),
+				Amount:         b.UiTokenAmount.Amount,
+				UiAmountString: b.UiTokenAmount.UiAmountString,
+			}
+			if b.UiTokenAmount.UiAmount != nil {
+				tb.UiTokenAmount.UiAmount = *b.UiTokenAmount.UiAmount
+			}
+		}
+		out = append(out, tb)
+	}
+	return out
+}
+
+func accountBytes(accounts []uint16) []byte {
+	out := make([]byte, len(accounts))
+	for i, idx := range accounts {
+		out[i] = byte(idx)
+	}
+	return out
+}
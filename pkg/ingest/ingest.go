@@ -0,0 +1,43 @@
+package ingest
+
+import (
+	"context"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Event is a transaction candidate delivered by a Source.
+type Event struct {
+	Signature solana.Signature
+	Slot      uint64
+	Logs      []string
+	Source    string // Name of the source that delivered the event
+
+	// Full transaction, only set by sources that deliver it directly (e.g. Geyser).
+	// When nil the transaction has to be fetched over RPC.
+	Transaction *rpc.GetTransactionResult
+	Tx          *solana.Transaction
+}
+
+// LogFilter decides based on the transaction logs whether an event is forwarded.
+type LogFilter func(logs []string) bool
+
+// Source delivers transactions mentioning a program.
+type Source interface {
+	Name() string
+
+	// Subscribe forwards every transaction mentioning program that passes filter to ch.
+	// It blocks until ctx is cancelled (returning nil) or the subscription fails.
+	Subscribe(ctx context.Context, program solana.PublicKey, filter LogFilter, ch chan<- Event) error
+}
+
+// forward sends ev to ch unless ctx is cancelled first.
+func forward(ctx context.Context, ch chan<- Event, ev Event) bool {
+	select {
+	case ch <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
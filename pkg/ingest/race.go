@@ -0,0 +1,304 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go"
+)
+
+const (
+	raceWindow     = 30 * time.Second // How long other endpoints get to deliver a signature
+	raceMinSamples = 10               // Lag samples before an endpoint can be dropped
+	raceRetryDelay = 3 * time.Second  // Before a failed endpoint is reconnected
+)
+
+// RaceSource subscribes on several sources at once and forwards the first arrival of
+// every signature. It measures how far every endpoint lags behind the first arrival
+// and drops an endpoint that lags more than MaxLag for Cooldown.
+// A failed endpoint is reconnected while the others keep running, the subscription
+// only fails once no endpoint is connected anymore.
+type RaceSource struct {
+	Sources  []Source
+	MaxLag   time.Duration // Average lag after which an endpoint is dropped, 0 never drops
+	Cooldown time.Duration // How long a dropped endpoint sits out
+
+	mutex sync.Mutex
+	stats map[string]*EndpointStats
+}
+
+// EndpointStats counts the arrivals of a single endpoint across all subscriptions.
+type EndpointStats struct {
+	Name     string
+	First    uint64        // Signatures this endpoint delivered first
+	Behind   uint64        // Signatures delivered after another endpoint
+	Missed   uint64        // Signatures never delivered within the race window
+	Dropped  uint64        // Times it was dropped for lagging
+	TotalLag time.Duration // Summed lag of the Behind signatures
+}
+
+// AvgLag is the average lag behind the first arrival, the first arrivals count as 0.
+func (s EndpointStats) AvgLag() time.Duration {
+	if s.First+s.Behind == 0 {
+		return 0
+	}
+	return s.TotalLag / time.Duration(s.First+s.Behind)
+}
+
+func NewRaceSource(sources []Source, maxLag time.Duration, cooldown time.Duration) *RaceSource {
+	return &RaceSource{
+		Sources:  sources,
+		MaxLag:   maxLag,
+		Cooldown: cooldown,
+		stats:    make(map[string]*EndpointStats),
+	}
+}
+
+func (s *RaceSource) Name() string {
+	names := make([]string, len(s.Sources))
+	for i, src := range s.Sources {
+		names[i] = src.Name()
+	}
+	return strings.Join(names, ", ")
+}
+
+// Stats returns the counters of every endpoint, sorted by name.
+func (s *RaceSource) Stats() []EndpointStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	list := make([]EndpointStats, 0, len(s.stats))
+	for _, stats := range s.stats {
+		list = append(list, *stats)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+func (s *RaceSource) update(name string, fn func(stats *EndpointStats)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stats == nil {
+		s.stats = make(map[string]*EndpointStats)
+	}
+	stats, ok := s.stats[name]
+	if !ok {
+		stats = &EndpointStats{Name: name}
+		s.stats[name] = stats
+	}
+	fn(stats)
+}
+
+type raceEndpoint struct {
+	source      Source
+	cancel      context.CancelFunc
+	connected   bool
+	dropped     bool
+	connectedAt time.Time
+	lag         time.Duration // Moving average of this subscription
+	samples     int
+}
+
+type raceArrival struct {
+	endpoint int
+	ev       Event
+	at       time.Time
+}
+
+type raceExit struct {
+	endpoint int
+	err      error
+}
+
+type firstArrival struct {
+	at   time.Time
+	seen map[int]bool
+}
+
+func (s *RaceSource) Subscribe(ctx context.Context, program solana.PublicKey, filter LogFilter, ch chan<- Event) error {
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	arrivals := make(chan raceArrival)
+	exits := make(chan raceExit, len(s.Sources))
+	restarts := make(chan int)
+
+	endpoints := make([]*raceEndpoint, len(s.Sources))
+	start := func(i int) {
+		ep := endpoints[i]
+		epCtx, epCancel := context.WithCancel(subCtx)
+		ep.cancel = epCancel
+		ep.connected = true
+		ep.dropped = false
+		ep.connectedAt = time.Now()
+		ep.lag, ep.samples = 0, 0
+
+		events := make(chan Event)
+		go func() {
+			for {
+				select {
+				case ev := <-events:
+					select {
+					case arrivals <- raceArrival{endpoint: i, ev: ev, at: time.Now()}:
+					case <-epCtx.Done():
+						return
+					}
+				case <-epCtx.Done():
+					return
+				}
+			}
+		}()
+		go func() {
+			err := ep.source.Subscribe(epCtx, program, filter, events)
+			epCancel()
+			exits <- raceExit{endpoint: i, err: err}
+		}()
+	}
+	restartAfter := func(i int, delay time.Duration) {
+		time.AfterFunc(delay, func() {
+			select {
+			case restarts <- i:
+			case <-subCtx.Done():
+			}
+		})
+	}
+
+	for i, src := range s.Sources {
+		endpoints[i] = &raceEndpoint{source: src}
+		start(i)
+	}
+
+	pending := make(map[solana.Signature]*firstArrival)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case arrival := <-arrivals:
+			ep := endpoints[arrival.endpoint]
+			if ep.dropped {
+				continue
+			}
+			name := ep.source.Name()
+
+			first, ok := pending[arrival.ev.Signature]
+			if !ok {
+				pending[arrival.ev.Signature] = &firstArrival{at: arrival.at, seen: map[int]bool{arrival.endpoint: true}}
+				s.update(name, func(stats *EndpointStats) { stats.First++ })
+				s.sample(endpoints, arrival.endpoint, 0)
+
+				if !forward(ctx, ch, arrival.ev) {
+					return nil
+				}
+				continue
+			}
+			if first.seen[arrival.endpoint] {
+				continue
+			}
+			first.seen[arrival.endpoint] = true
+
+			lag := arrival.at.Sub(first.at)
+			s.update(name, func(stats *EndpointStats) {
+				stats.Behind++
+				stats.TotalLag += lag
+			})
+			s.sample(endpoints, arrival.endpoint, lag)
+
+		case <-ticker.C:
+			// Endpoints that never delivered a signature lag at least the whole window
+			now := time.Now()
+			for signature, first := range pending {
+				if now.Sub(first.at) < raceWindow {
+					continue
+				}
+				delete(pending, signature)
+
+				for i, ep := range endpoints {
+					if first.seen[i] || !ep.connected || ep.dropped || ep.connectedAt.After(first.at) {
+						continue
+					}
+					s.update(ep.source.Name(), func(stats *EndpointStats) { stats.Missed++ })
+					s.sample(endpoints, i, raceWindow)
+				}
+			}
+
+		case exit := <-exits:
+			ep := endpoints[exit.endpoint]
+			ep.connected = false
+			if ctx.Err() != nil {
+				return nil
+			}
+			if ep.dropped {
+				restartAfter(exit.endpoint, s.Cooldown)
+				continue
+			}
+
+			err := exit.err
+			if err == nil {
+				err = fmt.Errorf("subscription ended")
+			}
+
+			connected := 0
+			for _, other := range endpoints {
+				if other.connected {
+					connected++
+				}
+			}
+			if connected == 0 {
+				return err
+			}
+
+			color.New(color.FgYellow).Printf("%s failed, reconnecting: %v\n", ep.source.Name(), err)
+			restartAfter(exit.endpoint, raceRetryDelay)
+
+		case i := <-restarts:
+			if os.Getenv("DEBUG") == "1" {
+				fmt.Printf("%s is reconnecting\n", endpoints[i].source.Name())
+			}
+			start(i)
+		}
+	}
+}
+
+// sample adds a lag sample to the moving average of the endpoint and drops it
+// once it lags too far behind, as long as another endpoint is still connected.
+func (s *RaceSource) sample(endpoints []*raceEndpoint, i int, lag time.Duration) {
+	ep := endpoints[i]
+	if ep.samples == 0 {
+		ep.lag = lag
+	} else {
+		ep.lag += (lag - ep.lag) / 8
+	}
+	ep.samples++
+
+	if s.MaxLag <= 0 || ep.samples < raceMinSamples || ep.lag <= s.MaxLag {
+		return
+	}
+
+	others := 0
+	for j, other := range endpoints {
+		if j != i && other.connected && !other.dropped {
+			others++
+		}
+	}
+	if others == 0 {
+		return
+	}
+
+	ep.dropped = true
+	ep.cancel()
+	s.update(ep.source.Name(), func(stats *EndpointStats) { stats.Dropped++ })
+
+	color.New(color.FgYellow).Printf("%s lags %v behind, dropped for %v\n", ep.source.Name(), ep.lag.Round(time.Millisecond), s.Cooldown)
+}
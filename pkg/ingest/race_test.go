@@ -0,0 +1,140 @@
+package ingest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
+	"github.com/gagliardetto/solana-go"
+)
+
+// endpointSource delivers the events sent to it until it fails or is cancelled.
+type endpointSource struct {
+	name   string
+	events chan ingest.Event
+	fail   chan error
+	done   chan struct{} // Closed once the subscription returned
+}
+
+func newEndpointSource(name string) *endpointSource {
+	return &endpointSource{
+		name:   name,
+		events: make(chan ingest.Event),
+		fail:   make(chan error, 1),
+		done:   make(chan struct{}, 1),
+	}
+}
+
+func (s *endpointSource) Name() string { return s.name }
+
+func (s *endpointSource) Subscribe(ctx context.Context, program solana.PublicKey, filter ingest.LogFilter, ch chan<- ingest.Event) error {
+	defer func() { s.done <- struct{}{} }()
+	for {
+		select {
+		case ev := <-s.events:
+			ev.Source = s.name
+			select {
+			case ch <- ev:
+			case <-ctx.Done():
+				return nil
+			}
+		case err := <-s.fail:
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func Test_RaceSource(t *testing.T) {
+	fast := newEndpointSource("fast")
+	slow := newEndpointSource("slow")
+	race := ingest.NewRaceSource([]ingest.Source{fast, slow}, 10*time.Millisecond, time.Hour)
+
+	ch := make(chan ingest.Event, 32)
+	done := make(chan error, 1)
+	go func() {
+		done <- race.Subscribe(context.Background(), solana.PublicKey{}, func([]string) bool { return true }, ch)
+	}()
+
+	// The slow endpoint delivers every signature 20ms later, until it is dropped
+	dropped := false
+	for i := 0; i < 12; i++ {
+		ev := ingest.Event{Signature: solana.Signature{byte(i + 1)}}
+		fast.events <- ev
+		time.Sleep(20 * time.Millisecond)
+		if dropped {
+			continue
+		}
+		select {
+		case slow.events <- ev:
+		case <-slow.done:
+			dropped = true
+		}
+	}
+
+	if len(ch) != 12 {
+		t.Fatalf("forwarded %d events, want 12", len(ch))
+	}
+	for len(ch) > 0 {
+		if ev := <-ch; ev.Source != "fast" {
+			t.Errorf("%s forwarded from %s", ev.Signature, ev.Source)
+		}
+	}
+
+	stats := race.Stats()
+	if len(stats) != 2 || stats[0].Name != "fast" || stats[1].Name != "slow" {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if stats[0].First != 12 || stats[0].AvgLag() != 0 {
+		t.Errorf("unexpected fast stats %+v", stats[0])
+	}
+	if stats[1].First != 0 || stats[1].Behind != 10 || stats[1].Dropped != 1 || stats[1].AvgLag() < 20*time.Millisecond {
+		t.Errorf("unexpected slow stats %+v", stats[1])
+	}
+
+	// The last connected endpoint failing ends the subscription
+	fast.fail <- errors.New("disconnected")
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "disconnected" {
+			t.Errorf("unexpected subscribe error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription did not end")
+	}
+}
+
+func Test_RaceSourceReconnect(t *testing.T) {
+	a := newEndpointSource("a")
+	b := newEndpointSource("b")
+	race := ingest.NewRaceSource([]ingest.Source{a, b}, 0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan ingest.Event, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- race.Subscribe(ctx, solana.PublicKey{}, func([]string) bool { return true }, ch)
+	}()
+
+	// One endpoint failing does not interrupt the other
+	a.fail <- errors.New("disconnected")
+	<-a.done
+	b.events <- ingest.Event{Signature: solana.Signature{1}}
+
+	select {
+	case ev := <-ch:
+		if ev.Source != "b" {
+			t.Errorf("forwarded from %s", ev.Source)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not forwarded")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("unexpected subscribe error: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package ingest
+
+import (
+	"context"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+// LogsSource uses logsSubscribe on a websocket endpoint. It only delivers the
+// signature and logs, the transaction itself has to be fetched over RPC.
+type LogsSource struct {
+	WsUrl string
+	Label string // Optional, tells endpoints apart when racing several
+}
+
+func NewLogsSource(wsUrl string) *LogsSource {
+	return &LogsSource{WsUrl: wsUrl}
+}
+
+func (s *LogsSource) Name() string {
+	if s.Label != "" {
+		return s.Label
+	}
+	return "websocket"
+}
+
+func (s *LogsSource) Subscribe(ctx context.Context, program solana.PublicKey, filter LogFilter, ch chan<- Event) error {
+	client, err := ws.Connect(ctx, s.WsUrl)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	sub, err := client.LogsSubscribeMentions(
+		program,
+		rpc.CommitmentConfirmed,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Recv does not take a context, so receive on a separate goroutine
+	results := make(chan *ws.LogResult)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			got, err := sub.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+
+			select {
+			case results <- got:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var lastSignature solana.Signature
+	for {
+		var got *ws.LogResult
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-recvErr:
+			sub.Unsubscribe()
+			return err
+		case got = <-results:
+		}
+
+		if got.Value.Signature == lastSignature {
+			continue
+		}
+
+		lastSignature = got.Value.Signature
+
+		if !filter(got.Value.Logs) {
+			continue
+		}
+
+		ev := Event{
+			Signature: got.Value.Signature,
+			Slot:      got.Context.Slot,
+			Logs:      got.Value.Logs,
+			Source:    s.Name(),
+		}
+		if !forward(ctx, ch, ev) {
+			return nil
+		}
+	}
+}
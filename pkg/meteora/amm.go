@@ -0,0 +1,186 @@
+package meteora
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// The permissionless pool initialisations of the dynamic AMM. The config variants take the
+// config account second, the accounts after the pool move up by one.
+var ammInitializeLayouts = []struct {
+	name          string
+	discriminator []byte
+	curveType     bool // Arguments start with the curve type
+	feeTier       bool // Arguments contain trade_fee_bps before the amounts
+	config        bool
+}{
+	{"initialize_permissionless_pool", []byte{0x76, 0xad, 0x29, 0x9d, 0xad, 0x48, 0x61, 0x67}, true, false, false},
+	{"initialize_permissionless_pool_with_fee_tier", []byte{0x06, 0x87, 0x44, 0x93, 0xe5, 0x52, 0xa9, 0x71}, true, true, false},
+	{"initialize_permissionless_constant_product_pool_with_config", []byte{0x07, 0xa6, 0x8a, 0xab, 0xce, 0xab, 0xec, 0xf4}, false, false, true},
+	{"initialize_permissionless_constant_product_pool_with_config2", []byte{0x30, 0x95, 0xdc, 0x82, 0x3d, 0x0b, 0x09, 0xb2}, false, false, true},
+}
+
+// Accounts of the permissionless pool initialisations without config
+const (
+	ammPoolIndex        = 0 // Not shifted
+	ammConfigIndex      = 1 // Config variants only, not shifted
+	ammLpMintIndex      = 1
+	ammTokenAMintIndex  = 2
+	ammTokenBMintIndex  = 3
+	ammATokenVaultIndex = 6
+	ammBTokenVaultIndex = 7
+	ammPayerPoolLpIndex = 14
+	ammPayerIndex       = 17
+	ammAccountsLen      = 26
+)
+
+const (
+	ammCurveConstantProduct = 0  // Borsh variant of CurveType
+	ammDefaultFeeBps        = 25 // Trade fee of pools created without fee tier or config, fixed by the program
+)
+
+// destructAmmInfo fills info from a permissionless pool initialisation. The deposited amounts
+// are arguments, the tokens end up in the vaults of the vault program that all pools of a mint share.
+func destructAmmInfo(ctx context.Context, instr solana.CompiledInstruction, keys solana.PublicKeySlice, rpcTx *rpc.GetTransactionResult, info *MeteoraInfo) bool {
+	data := []byte(instr.Data)
+	if len(data) < 8 {
+		return false
+	}
+
+	for _, layout := range ammInitializeLayouts {
+		if !bytes.Equal(data[:8], layout.discriminator) {
+			continue
+		}
+
+		if len(instr.Accounts) < ammAccountsLen {
+			color.New(color.FgYellow).Printf("[METEORA] destructAmmInfo -> Required accounts length for %s not met (%d)\n", layout.name, len(instr.Accounts))
+			return false
+		}
+
+		args := data[8:]
+		if layout.curveType {
+			if len(args) < 1 || args[0] != ammCurveConstantProduct {
+				return false // Stable pools are not token launches
+			}
+			args = args[1:]
+		}
+
+		feeBps := float64(ammDefaultFeeBps)
+		if layout.feeTier {
+			if len(args) < 8 {
+				return false
+			}
+			feeBps = float64(binary.LittleEndian.Uint64(args))
+			args = args[8:]
+		}
+
+		// token_a_amount u64, token_b_amount u64
+		if len(args) < 16 {
+			return false
+		}
+
+		shift := 0
+		if layout.config {
+			shift = 1
+		}
+		account := func(i int) solana.PublicKey {
+			return utils.InstrAccount(keys, instr, i+shift)
+		}
+
+		info.Pool = utils.InstrAccount(keys, instr, ammPoolIndex)
+		info.LPMint = account(ammLpMintIndex)
+		info.BaseMint = account(ammTokenAMintIndex)
+		info.QuoteMint = account(ammTokenBMintIndex)
+		info.BaseVault = account(ammATokenVaultIndex)
+		info.QuoteVault = account(ammBTokenVaultIndex)
+		info.LPAccount = account(ammPayerPoolLpIndex)
+		info.Caller = account(ammPayerIndex)
+
+		if info.Pool.IsZero() || info.BaseMint.IsZero() || info.QuoteMint.IsZero() {
+			return false
+		}
+
+		info.Params = &PoolParams{FeeBps: feeBps}
+		if layout.config {
+			info.Params.Config = utils.InstrAccount(keys, instr, ammConfigIndex)
+
+			fee, err := getConfigFeeBps(ctx, info.Params.Config)
+			if err != nil {
+				color.New(color.FgYellow).Printf("[METEORA] destructAmmInfo -> Failed to get config %s: %v\n", info.Params.Config, err)
+				info.Params.FeeBps = 0
+			} else {
+				info.Params.FeeBps = fee
+			}
+		}
+
+		decimalsA, _ := mintDecimals(rpcTx, info.BaseMint)
+		decimalsB, _ := mintDecimals(rpcTx, info.QuoteMint)
+
+		info.InitBaseAmount = binary.LittleEndian.Uint64(args)
+		info.InitQuoteAmount = binary.LittleEndian.Uint64(args[8:])
+		info.BaseLiquidity = float64(info.InitBaseAmount) / math.Pow10(int(decimalsA))
+		info.QuoteLiquidity = float64(info.InitQuoteAmount) / math.Pow10(int(decimalsB))
+
+		info.orient()
+		if info.BaseLiquidity > 0 {
+			info.Params.Price = info.QuoteLiquidity / info.BaseLiquidity
+		}
+
+		return true
+	}
+
+	return false // Swap, deposit, withdraw...
+}
+
+// Configs are shared by all pools created with them, there are only a handful of them.
+var configFees = make(map[solana.PublicKey]float64)
+var configFeesMutex = &sync.Mutex{}
+
+// Offsets in the Config account: discriminator, then the pool fees
+const (
+	configTradeFeeNumeratorOffset   = 8
+	configTradeFeeDenominatorOffset = configTradeFeeNumeratorOffset + 8
+)
+
+func getConfigFeeBps(ctx context.Context, key solana.PublicKey) (float64, error) {
+	configFeesMutex.Lock()
+	fee, ok := configFees[key]
+	configFeesMutex.Unlock()
+	if ok {
+		return fee, nil
+	}
+
+	accounts, err := utils.GetMultipleAccounts_S(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if accounts[0] == nil {
+		return 0, rpc.ErrNotFound
+	}
+
+	data := accounts[0].Data.GetBinary()
+	if len(data) < configTradeFeeDenominatorOffset+8 {
+		return 0, errors.New("config account too short")
+	}
+	numerator := binary.LittleEndian.Uint64(data[configTradeFeeNumeratorOffset:])
+	denominator := binary.LittleEndian.Uint64(data[configTradeFeeDenominatorOffset:])
+	if denominator == 0 {
+		return 0, errors.New("config without trade fee denominator")
+	}
+	fee = float64(numerator) / float64(denominator) * 10_000
+
+	configFeesMutex.Lock()
+	configFees[key] = fee
+	configFeesMutex.Unlock()
+
+	return fee, nil
+}
@@ -0,0 +1,130 @@
+package meteora
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Anchor discriminator of the DLMM initialize_lb_pair instruction, sha256("global:initialize_lb_pair")[:8]
+var dlmmInitializeLbPairDiscriminator = []byte{0x2d, 0x9a, 0xed, 0xd2, 0xdd, 0x0f, 0xa6, 0x5c}
+
+// Accounts of the DLMM initialize_lb_pair instruction
+const (
+	dlmmLbPairIndex          = 0
+	dlmmTokenMintXIndex      = 2
+	dlmmTokenMintYIndex      = 3
+	dlmmReserveXIndex        = 4
+	dlmmReserveYIndex        = 5
+	dlmmPresetParameterIndex = 7
+	dlmmFunderIndex          = 8
+	dlmmAccountsLen          = 14
+)
+
+// destructDlmmInfo fills info from an initialize_lb_pair instruction. The pair is usually
+// funded by a separate transaction, the liquidity is only known when it is funded right away.
+func destructDlmmInfo(ctx context.Context, instr solana.CompiledInstruction, keys solana.PublicKeySlice, rpcTx *rpc.GetTransactionResult, info *MeteoraInfo) bool {
+	data := []byte(instr.Data)
+	// active_id i32, bin_step u16
+	if len(data) < 14 || !bytes.Equal(data[:8], dlmmInitializeLbPairDiscriminator) {
+		return false // Swap, add liquidity...
+	}
+
+	if len(instr.Accounts) < dlmmAccountsLen {
+		color.New(color.FgYellow).Printf("[METEORA] destructDlmmInfo -> Required accounts length for instruction not met (%d)\n", len(instr.Accounts))
+		return false
+	}
+
+	account := func(i int) solana.PublicKey {
+		return utils.InstrAccount(keys, instr, i)
+	}
+
+	info.Pool = account(dlmmLbPairIndex)
+	info.BaseMint = account(dlmmTokenMintXIndex)
+	info.QuoteMint = account(dlmmTokenMintYIndex)
+	info.BaseVault = account(dlmmReserveXIndex)
+	info.QuoteVault = account(dlmmReserveYIndex)
+	info.Caller = account(dlmmFunderIndex)
+
+	if info.Pool.IsZero() || info.BaseMint.IsZero() || info.QuoteMint.IsZero() {
+		return false
+	}
+
+	info.Params = &PoolParams{
+		Config:   account(dlmmPresetParameterIndex),
+		ActiveID: int32(binary.LittleEndian.Uint32(data[8:])),
+		BinStep:  binary.LittleEndian.Uint16(data[12:]),
+	}
+
+	balances := tokenBalances(keys, rpcTx)
+	decimalsX, _ := mintDecimals(rpcTx, info.BaseMint)
+	decimalsY, _ := mintDecimals(rpcTx, info.QuoteMint)
+
+	info.InitBaseAmount = parseAmount(balances[info.BaseVault])
+	info.InitQuoteAmount = parseAmount(balances[info.QuoteVault])
+	info.BaseLiquidity = float64(info.InitBaseAmount) / math.Pow10(int(decimalsX))
+	info.QuoteLiquidity = float64(info.InitQuoteAmount) / math.Pow10(int(decimalsY))
+
+	// Price of token X in token Y at the active bin
+	price := math.Pow(1+float64(info.Params.BinStep)/10_000, float64(info.Params.ActiveID)) * math.Pow10(int(decimalsX)-int(decimalsY))
+
+	info.orient()
+	if info.Swapped {
+		price = 1 / price
+	}
+	info.Params.Price = price
+
+	baseFactor, err := getPresetBaseFactor(ctx, info.Params.Config)
+	if err != nil {
+		color.New(color.FgYellow).Printf("[METEORA] destructDlmmInfo -> Failed to get preset parameter %s: %v\n", info.Params.Config, err)
+	} else {
+		// base_factor * bin_step * 10 in units of 1e-9
+		info.Params.FeeBps = float64(baseFactor) * float64(info.Params.BinStep) / 10_000
+	}
+
+	return true
+}
+
+// Preset parameters are shared by all pairs of a bin step and fee, there are only a handful of them.
+var presetBaseFactors = make(map[solana.PublicKey]uint16)
+var presetBaseFactorsMutex = &sync.Mutex{}
+
+// Offset in the PresetParameter account: discriminator, bin_step
+const presetBaseFactorOffset = 8 + 2
+
+func getPresetBaseFactor(ctx context.Context, key solana.PublicKey) (uint16, error) {
+	presetBaseFactorsMutex.Lock()
+	baseFactor, ok := presetBaseFactors[key]
+	presetBaseFactorsMutex.Unlock()
+	if ok {
+		return baseFactor, nil
+	}
+
+	accounts, err := utils.GetMultipleAccounts_S(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if accounts[0] == nil {
+		return 0, rpc.ErrNotFound
+	}
+
+	data := accounts[0].Data.GetBinary()
+	if len(data) < presetBaseFactorOffset+2 {
+		return 0, errors.New("preset parameter account too short")
+	}
+	baseFactor = binary.LittleEndian.Uint16(data[presetBaseFactorOffset:])
+
+	presetBaseFactorsMutex.Lock()
+	presetBaseFactors[key] = baseFactor
+	presetBaseFactorsMutex.Unlock()
+
+	return baseFactor, nil
+}
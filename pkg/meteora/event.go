@@ -0,0 +1,58 @@
+package meteora
+
+import (
+	"strconv"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+)
+
+// PoolEvent converts the pool into the venue independent event, the pool parameters are
+// attached as an extra.
+func (info *MeteoraInfo) PoolEvent() *events.PoolEvent {
+	ev := &events.PoolEvent{
+		Venue:          events.VenueMeteora,
+		Kind:           string(info.PoolType),
+		ProgramID:      info.ProgramID,
+		Pool:           info.Pool,
+		BaseMint:       info.BaseMint,
+		QuoteMint:      info.QuoteMint,
+		BaseVault:      info.BaseVault,
+		QuoteVault:     info.QuoteVault,
+		LPMint:         info.LPMint,
+		LPAccount:      info.LPAccount,
+		SharedVaults:   info.PoolType == PoolTypeDynamicAmm, // Vaults of the vault program
+		BaseLiquidity:  info.BaseLiquidity,
+		QuoteLiquidity: info.QuoteLiquidity,
+		OpenTime:       info.Timestamp, // Trading starts right away
+		Creator:        info.Caller,
+		TxID:           info.TxID,
+		Slot:           info.Slot,
+		TxTime:         info.TxTime,
+		Timestamp:      info.Timestamp,
+		Source:         info,
+	}
+
+	// Nil pointers would end up as non-nil interfaces
+	if info.Params != nil {
+		ev.Extras = append(ev.Extras, info.Params)
+	}
+
+	return ev
+}
+
+func (m *PoolParams) Title() string {
+	return "Pool Parameters"
+}
+
+func (m *PoolParams) Fields() []events.Field {
+	feeTier := m.FeeTier()
+	if m.BinStep > 0 {
+		feeTier += " (bin step " + strconv.Itoa(int(m.BinStep)) + ")"
+	}
+
+	fields := []events.Field{{Name: "Fee Tier", Value: feeTier}}
+	if m.Price > 0 {
+		fields = append(fields, events.Field{Name: "Initial Price", Value: strconv.FormatFloat(m.Price, 'g', 6, 64)})
+	}
+	return fields
+}
@@ -0,0 +1,83 @@
+package meteora
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/gagliardetto/solana-go"
+)
+
+// PoolType is the Meteora program that created a pool.
+type PoolType string
+
+const (
+	PoolTypeDlmm       PoolType = "DLMM"
+	PoolTypeDynamicAmm PoolType = "Dynamic AMM"
+)
+
+// PoolTypeOf returns the pool type created by program, empty for other programs.
+func PoolTypeOf(program solana.PublicKey) PoolType {
+	switch program.String() {
+	case utils.METEORA_DLMM_PROGRAM_ID:
+		return PoolTypeDlmm
+	case utils.METEORA_AMM_PROGRAM_ID:
+		return PoolTypeDynamicAmm
+	}
+	return ""
+}
+
+// MeteoraInfo is a pool created by the DLMM or the dynamic AMM program.
+type MeteoraInfo struct {
+	ProgramID  solana.PublicKey
+	PoolType   PoolType
+	Pool       solana.PublicKey // LB pair of DLMM pools
+	LPMint     solana.PublicKey // Dynamic AMM only, DLMM positions have no mint
+	BaseMint   solana.PublicKey
+	QuoteMint  solana.PublicKey
+	BaseVault  solana.PublicKey // Reserves of DLMM pairs, token vaults of the vault program for dynamic AMM pools
+	QuoteVault solana.PublicKey
+	LPAccount  solana.PublicKey // Dynamic AMM only, token account the creator received the LP tokens in
+
+	BaseLiquidity   float64
+	QuoteLiquidity  float64
+	InitBaseAmount  uint64 // Raw amounts deposited at creation
+	InitQuoteAmount uint64
+
+	Caller    solana.PublicKey
+	TxID      solana.Signature
+	Slot      uint64
+	TxTime    time.Time // Block time
+	Timestamp time.Time // Detection time
+	Swapped   bool      // Whether the pair was created in reverse order
+
+	Params *PoolParams
+}
+
+// orient makes SOL or USDC the quote of the pair, the liquidity, vaults and initial
+// amounts follow the mints.
+func (info *MeteoraInfo) orient() {
+	if !utils.IsQuoteMint(info.BaseMint) {
+		return
+	}
+
+	info.BaseMint, info.QuoteMint = info.QuoteMint, info.BaseMint
+	info.BaseVault, info.QuoteVault = info.QuoteVault, info.BaseVault
+	info.BaseLiquidity, info.QuoteLiquidity = info.QuoteLiquidity, info.BaseLiquidity
+	info.InitBaseAmount, info.InitQuoteAmount = info.InitQuoteAmount, info.InitBaseAmount
+	info.Swapped = true
+}
+
+// PoolParams are the fee and price parameters of a pool.
+type PoolParams struct {
+	Config   solana.PublicKey // Preset parameter of a DLMM pair or config of a dynamic AMM pool, zero when none
+	BinStep  uint16           // DLMM only, price step between bins in basis points
+	ActiveID int32            // DLMM only, bin of the initial price
+	FeeBps   float64          // Base trade fee in basis points, 0 when the config could not be fetched
+	Price    float64          // Initial price of the base mint in the quote mint
+}
+
+// FeeTier formats the base trade fee as a percentage.
+func (m *PoolParams) FeeTier() string {
+	return strconv.FormatFloat(m.FeeBps/100, 'f', -1, 64) + "%"
+}
@@ -0,0 +1,116 @@
+package meteora
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs/rpctest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func Test_parseDlmm(t *testing.T) {
+	ctx := context.Background()
+
+	replay := rpctest.Use(t, "testdata/dlmm_initialize_lb_pair.json")
+
+	if !dlmmLogFilter([]string{"Program log: Instruction: InitializeLbPair"}) {
+		t.Error("initialize_lb_pair did not pass the filter")
+	}
+	if dlmmLogFilter([]string{"Program log: Instruction: InitializeCustomizablePermissionlessLbPair"}) {
+		t.Error("customizable pair passed the filter")
+	}
+
+	info := parseTransaction(ctx, solana.MustSignatureFromBase58("4peMjAbojA6AH9jBpb5VBBYrmrvq8cdG56JMaC9NbdQS4M9m5Nz5Y7MWnnYpqrhPAfAhpvfgynnis3V9p7mKYet7"))
+	if info == nil {
+		t.Fatalf("info is nil, misses: %v", replay.Misses())
+	}
+
+	if ev := info.PoolEvent(); info.PoolType != PoolTypeDlmm || ev.Venue != events.VenueMeteora || ev.Label() != "Meteora DLMM" {
+		t.Errorf("pool type: got %s (%s)", info.PoolType, ev.Label())
+	}
+	if info.Pool.String() != "6GS7sXirfdrKot7Dcy9xuU6moJwP6bM9knxVHNzk3F5f" {
+		t.Errorf("pair: got %s", info.Pool)
+	}
+	if info.BaseMint.String() != "3xz5UuG2XSPh5UiZ9vRDnFfbGeRoAndnV72eA8cE9Frh" || info.QuoteMint != solana.WrappedSol || info.Swapped {
+		t.Errorf("mints: got %s/%s", info.BaseMint, info.QuoteMint)
+	}
+	// Funded one-sided, the SOL comes in with the swaps
+	if info.BaseLiquidity != 1_000_000 || info.QuoteLiquidity != 0 {
+		t.Errorf("liquidity: got %v/%v", info.BaseLiquidity, info.QuoteLiquidity)
+	}
+	if info.Slot != 268_000_050 || info.PoolEvent().Creator != info.Caller || info.Caller.IsZero() {
+		t.Errorf("unexpected creation %d by %s", info.Slot, info.Caller)
+	}
+
+	m := info.Params
+	if m == nil {
+		t.Fatal("pool parameters missing")
+	}
+	if m.Config.String() != "CYENAnAhgaVStWKBFH9tNE4oSXwJ2k5Bd8ab7ZfCbvSk" || m.BinStep != 100 || m.ActiveID != -500 {
+		t.Errorf("unexpected pair %+v", m)
+	}
+	if m.FeeBps != 100 || m.FeeTier() != "1%" {
+		t.Errorf("fee: got %v bps", m.FeeBps)
+	}
+	// 1.01^-500 per bin, adjusted for the 6 and 9 decimals
+	if math.Abs(m.Price-6.907376181289456e-06) > 1e-15 {
+		t.Errorf("price: got %v", m.Price)
+	}
+}
+
+func Test_parseDynamicAmm(t *testing.T) {
+	ctx := context.Background()
+
+	replay := rpctest.Use(t, "testdata/amm_with_config.json")
+
+	if !ammLogFilter([]string{"Program log: Instruction: InitializePermissionlessConstantProductPoolWithConfig"}) {
+		t.Error("pool with config did not pass the filter")
+	}
+	if ammLogFilter([]string{"Program log: Instruction: Swap"}) {
+		t.Error("swap passed the filter")
+	}
+
+	info := parseTransaction(ctx, solana.MustSignatureFromBase58("2Qug9ZYR3Fv34LCLhDtqgzTq7EnyQMMfChy4rWe9siBaYDps17eGt5EbvzBbhUufTi2QShBeCSjiG8wnZn37Mb1G"))
+	if info == nil {
+		t.Fatalf("info is nil, misses: %v", replay.Misses())
+	}
+
+	if info.PoolType != PoolTypeDynamicAmm || info.Pool.String() != "ADa5KDj2zyhgE4gc7MUYyRBXUa1VqCi6o2QiGD1jFyqg" {
+		t.Errorf("unexpected pool %s (%s)", info.Pool, info.PoolType)
+	}
+	// SOL is token A, the pair is turned around
+	if info.BaseMint.String() != "Gm98disxnRhuYgokvoeKuaa7uCb6Jj5agunBcAFqyfso" || info.QuoteMint != solana.WrappedSol || !info.Swapped {
+		t.Errorf("mints: got %s/%s", info.BaseMint, info.QuoteMint)
+	}
+	if info.BaseVault.String() != "8dW94vu7ajpyJGejk21TiWP2otH568MVh68LF7ikkKz4" || info.QuoteVault.String() != "6U2nAiZG8U41Mu5JzbhkmAT5SyBxBuL9ymJmWV7Ym89w" {
+		t.Errorf("vaults: got %s/%s", info.BaseVault, info.QuoteVault)
+	}
+	if info.BaseLiquidity != 500_000_000 || info.QuoteLiquidity != 50 {
+		t.Errorf("liquidity: got %v/%v", info.BaseLiquidity, info.QuoteLiquidity)
+	}
+	if info.InitBaseAmount != 500_000_000_000_000 || info.InitQuoteAmount != 50_000_000_000 {
+		t.Errorf("amounts: got %d/%d", info.InitBaseAmount, info.InitQuoteAmount)
+	}
+	if info.LPMint.String() != "8WyCfQFckzkAdpiqLUwdjrZnbyrMvEfXPe5NAoGGXsv1" || info.LPAccount.String() != "Hj2sZwF6mXz8H1CAHhpLKkG9RCpSVqA23v52Emfi8Pf" {
+		t.Errorf("lp: got %s/%s", info.LPMint, info.LPAccount)
+	}
+	if info.Caller.String() != "9TvWjT8bHxHTNCqAnhg6keHp24p8Gp4854hxFpcD2JMt" {
+		t.Errorf("caller: got %s", info.Caller)
+	}
+
+	m := info.Params
+	if m == nil {
+		t.Fatal("pool parameters missing")
+	}
+	if m.Config.String() != "EdJndown4oxHUchR3aLUtJwr8cgL2jvjN4a1z93MBEEk" || m.FeeBps != 100 {
+		t.Errorf("config: got %s with %v bps", m.Config, m.FeeBps)
+	}
+	if m.FeeTier() != "1%" || m.BinStep != 0 {
+		t.Errorf("fee tier: got %s (bin step %d)", m.FeeTier(), m.BinStep)
+	}
+	if math.Abs(m.Price-1e-7) > 1e-18 {
+		t.Errorf("price: got %v, want 1e-7", m.Price)
+	}
+}
@@ -0,0 +1,129 @@
+package meteora
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ProcessMessages parses every signature from rChn and forwards the detected pools to sendChn.
+// Once ctx is cancelled the remaining signatures are drained without being processed,
+// their number is returned.
+func ProcessMessages(ctx context.Context, rChn <-chan ingest.Event, sendChn chan<- *events.PoolEvent) int {
+	abandoned := 0
+
+	for msg := range rChn {
+		if ctx.Err() != nil {
+			abandoned++
+			continue
+		}
+
+		info := parseEvent(ctx, msg)
+		if info == nil {
+			continue
+		}
+
+		sendChn <- info.PoolEvent()
+	}
+
+	fmt.Printf("Meteora processing out...\n")
+
+	return abandoned
+}
+
+// parseEvent uses the transaction delivered by the source, or fetches it if there is none.
+func parseEvent(ctx context.Context, ev ingest.Event) *MeteoraInfo {
+	if ev.Transaction == nil || ev.Tx == nil {
+		return parseTransaction(ctx, ev.Signature)
+	}
+
+	return parseResult(ctx, ev.Transaction, ev.Tx)
+}
+
+func parseTransaction(ctx context.Context, signature solana.Signature) *MeteoraInfo {
+	rpcTx, tx, err := utils.GetConfirmedTransaction_S(ctx, signature)
+	if err != nil {
+		fmt.Printf("Meteora -> parseTransaction: %v\nhttps://solscan.io/tx/%s\n", err, signature.String())
+		return nil
+	}
+
+	return parseResult(ctx, rpcTx, tx)
+}
+
+func parseResult(ctx context.Context, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) *MeteoraInfo {
+	if rpcTx.Meta.Err != nil {
+		return nil // Pool was never created.
+	}
+
+	keys := utils.AccountKeys(rpcTx, tx)
+
+	for _, instr := range tx.Message.Instructions {
+		program, err := tx.Message.Program(instr.ProgramIDIndex)
+		if err != nil {
+			continue // Program account index out of range.
+		}
+
+		info := MeteoraInfo{
+			ProgramID: program,
+			PoolType:  PoolTypeOf(program),
+		}
+
+		var wasFound bool
+		switch program.String() {
+		case utils.METEORA_DLMM_PROGRAM_ID:
+			wasFound = destructDlmmInfo(ctx, instr, keys, rpcTx, &info)
+		case utils.METEORA_AMM_PROGRAM_ID:
+			wasFound = destructAmmInfo(ctx, instr, keys, rpcTx, &info)
+		default:
+			continue // Not called by meteora.
+		}
+		if !wasFound {
+			continue
+		}
+
+		info.TxID = tx.Signatures[0]
+		info.Slot = rpcTx.Slot
+		info.TxTime = utils.BlockTime(rpcTx)
+		info.Timestamp = time.Now()
+
+		return &info
+	}
+
+	return nil
+}
+
+// tokenBalances returns the raw amount and decimals after tx of the token accounts, by account.
+func tokenBalances(keys solana.PublicKeySlice, rpcTx *rpc.GetTransactionResult) map[solana.PublicKey]rpc.UiTokenAmount {
+	balances := make(map[solana.PublicKey]rpc.UiTokenAmount)
+	for _, postBalance := range rpcTx.Meta.PostTokenBalances {
+		if int(postBalance.AccountIndex) >= len(keys) || postBalance.UiTokenAmount == nil {
+			continue
+		}
+		balances[keys[postBalance.AccountIndex]] = *postBalance.UiTokenAmount
+	}
+	return balances
+}
+
+// mintDecimals returns the decimals of mint from any token account in the balances of tx.
+func mintDecimals(rpcTx *rpc.GetTransactionResult, mint solana.PublicKey) (uint8, bool) {
+	for _, balances := range [][]rpc.TokenBalance{rpcTx.Meta.PostTokenBalances, rpcTx.Meta.PreTokenBalances} {
+		for _, balance := range balances {
+			if balance.Mint == mint && balance.UiTokenAmount != nil {
+				return balance.UiTokenAmount.Decimals, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func parseAmount(amount rpc.UiTokenAmount) uint64 {
+	raw, _ := strconv.ParseUint(amount.Amount, 10, 64)
+	return raw
+}
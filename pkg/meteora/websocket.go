@@ -0,0 +1,66 @@
+package meteora
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Programs the monitor subscribes to, each with the filter for its pool creation logs.
+var programs = []struct {
+	id     string
+	filter ingest.LogFilter
+}{
+	{utils.METEORA_DLMM_PROGRAM_ID, dlmmLogFilter},
+	{utils.METEORA_AMM_PROGRAM_ID, ammLogFilter},
+}
+
+// Start forwards every pool candidate from src to ch until ctx is cancelled or one of the
+// subscriptions fails, the others are then stopped as well.
+func Start(ctx context.Context, src ingest.Source, ch chan<- ingest.Event) error {
+	fmt.Printf("Starting Meteora monitor (%s)\n", src.Name())
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(programs))
+	for _, p := range programs {
+		go func() {
+			errs <- src.Subscribe(ctx, solana.MustPublicKeyFromBase58(p.id), p.filter, ch)
+		}()
+	}
+
+	err := <-errs
+	cancel()
+	for i := 1; i < len(programs); i++ {
+		if e := <-errs; err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+func dlmmLogFilter(logs []string) bool {
+	for _, log := range logs {
+		// Exact match, the permission and customizable variants have other accounts
+		if log == utils.METEORA_DLMM_IDENTIFIER {
+			return true
+		}
+	}
+	return false
+}
+
+func ammLogFilter(logs []string) bool {
+	for _, log := range logs {
+		// Prefix of all permissionless pool initialisations
+		if strings.HasPrefix(log, utils.METEORA_AMM_IDENTIFIER) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,32 +1,222 @@
 package openbook
 
 import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
 	"sync"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
 )
 
-// Map where key is mint address string and value is *OpenbookInfo
-var openbookCache = make(map[string]*OpenbookInfo)
+// CacheOptions configures the market cache.
+type CacheOptions struct {
+	TTL        time.Duration // Entries older than this are dropped, 0 keeps them until evicted
+	MaxEntries int           // Least recently used entries are evicted beyond this, 0 is unbounded
+	Path       string        // File the cache is persisted to, empty disables persistence
+}
+
+type cacheEntry struct {
+	Mint     string        `json:"mint"`
+	Info     *OpenbookInfo `json:"info"`
+	StoredAt time.Time     `json:"storedAt"`
+
+	element *list.Element
+}
+
+// Map where key is mint address string and value is the cached market
+var openbookCache = make(map[string]*cacheEntry)
+var openbookCacheOrder = list.New() // Least recently used at the front
 var openbookCacheMutex = &sync.Mutex{}
+var openbookCacheDirty bool
+
+var cacheOptions = CacheOptions{
+	TTL:        24 * time.Hour,
+	MaxEntries: 50_000,
+}
+
+// CacheOptionsFromEnv reads OPENBOOK_CACHE_TTL (minutes), OPENBOOK_CACHE_SIZE and
+// OPENBOOK_CACHE_PATH, unset values keep the defaults.
+func CacheOptionsFromEnv() CacheOptions {
+	opts := cacheOptions
+	opts.Path = os.Getenv("OPENBOOK_CACHE_PATH")
+
+	if v := utils.StI64(os.Getenv("OPENBOOK_CACHE_TTL")); v >= 0 {
+		opts.TTL = time.Duration(v) * time.Minute
+	}
+	if v := utils.StI64(os.Getenv("OPENBOOK_CACHE_SIZE")); v >= 0 {
+		opts.MaxEntries = int(v)
+	}
+
+	return opts
+}
+
+// InitialiseCache applies opts and loads the entries persisted by a previous run.
+// A missing file is not an error.
+func InitialiseCache(opts CacheOptions) error {
+	openbookCacheMutex.Lock()
+	defer openbookCacheMutex.Unlock()
+
+	cacheOptions = opts
+	openbookCache = make(map[string]*cacheEntry)
+	openbookCacheOrder.Init()
+	openbookCacheDirty = false
+
+	if opts.Path == "" {
+		return nil
+	}
+
+	raw, err := os.ReadFile(opts.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var entries []*cacheEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return fmt.Errorf("openbook cache %s: %w", opts.Path, err)
+	}
+
+	// Entries are stored least recently used first, so the order survives the restart
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.Info == nil || expired(entry, now) {
+			continue
+		}
+		entry.Info.Version = VersionOf(entry.Info.ProgramID) // Missing in files written before v2 support
+		insert(entry)
+	}
+	evict()
+
+	fmt.Printf("Openbook cache loaded (%d markets)\n", len(openbookCache))
 
-// TODO: Add a TTL for the cache (and flush unused entries)
+	return nil
+}
 
 // SetOpenbookInfo sets the OpenbookInfo for the given mint address.
 func SetOpenbookInfo(mintAddress string, info *OpenbookInfo) {
 	openbookCacheMutex.Lock()
 	defer openbookCacheMutex.Unlock()
 
-	openbookCache[mintAddress] = info
+	if entry, ok := openbookCache[mintAddress]; ok {
+		openbookCacheOrder.Remove(entry.element)
+		delete(openbookCache, mintAddress)
+	}
+
+	insert(&cacheEntry{Mint: mintAddress, Info: info, StoredAt: time.Now()})
+	evict()
+
+	openbookCacheDirty = true
 }
 
-// GetOpenbookInfo returns the OpenbookInfo for the given mint address.
+// GetOpenbookInfo returns the OpenbookInfo for the given mint address. The entry
+// stays cached, so every caller gets the same market.
 func GetOpenbookInfo(mintAddress string) *OpenbookInfo {
 	openbookCacheMutex.Lock()
 	defer openbookCacheMutex.Unlock()
 
-	if info, ok := openbookCache[mintAddress]; ok {
+	entry, ok := openbookCache[mintAddress]
+	if !ok {
+		return nil
+	}
+
+	if expired(entry, time.Now()) {
+		openbookCacheOrder.Remove(entry.element)
 		delete(openbookCache, mintAddress)
-		return info
+		openbookCacheDirty = true
+		return nil
 	}
 
-	return nil
+	openbookCacheOrder.MoveToBack(entry.element)
+	return entry.Info
+}
+
+// FlushCache writes the cache to the configured path if it changed since the last flush.
+func FlushCache() error {
+	openbookCacheMutex.Lock()
+	if cacheOptions.Path == "" || !openbookCacheDirty {
+		openbookCacheMutex.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	entries := make([]*cacheEntry, 0, len(openbookCache))
+	for e := openbookCacheOrder.Front(); e != nil; e = e.Next() {
+		entry := e.Value.(*cacheEntry)
+		if !expired(entry, now) {
+			entries = append(entries, entry)
+		}
+	}
+	path := cacheOptions.Path
+	openbookCacheDirty = false
+
+	raw, err := json.Marshal(entries)
+	openbookCacheMutex.Unlock()
+
+	if err == nil {
+		err = utils.WriteFileAtomic(path, raw)
+	}
+	if err != nil {
+		openbookCacheMutex.Lock()
+		openbookCacheDirty = true
+		openbookCacheMutex.Unlock()
+	}
+
+	return err
+}
+
+// PersistCache flushes the cache every interval until ctx is cancelled.
+// Flush once more on shutdown, after the last market was processed.
+func PersistCache(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := FlushCache(); err != nil {
+				color.New(color.FgYellow).Printf("Openbook cache -> failed to persist: %v\n", err)
+			}
+		}
+	}
+}
+
+func insert(entry *cacheEntry) {
+	entry.element = openbookCacheOrder.PushBack(entry)
+	openbookCache[entry.Mint] = entry
+}
+
+// evict drops expired entries first, then the least recently used ones.
+func evict() {
+	if cacheOptions.MaxEntries <= 0 || len(openbookCache) <= cacheOptions.MaxEntries {
+		return
+	}
+
+	now := time.Now()
+	for e := openbookCacheOrder.Front(); e != nil; {
+		next := e.Next()
+		if entry := e.Value.(*cacheEntry); expired(entry, now) {
+			openbookCacheOrder.Remove(e)
+			delete(openbookCache, entry.Mint)
+		}
+		e = next
+	}
+
+	for len(openbookCache) > cacheOptions.MaxEntries {
+		entry := openbookCacheOrder.Remove(openbookCacheOrder.Front()).(*cacheEntry)
+		delete(openbookCache, entry.Mint)
+	}
+}
+
+func expired(entry *cacheEntry, now time.Time) bool {
+	return cacheOptions.TTL > 0 && now.Sub(entry.StoredAt) > cacheOptions.TTL
 }
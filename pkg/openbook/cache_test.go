@@ -0,0 +1,169 @@
+package openbook
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs/rpctest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func resetCache(t *testing.T, opts CacheOptions) {
+	if err := InitialiseCache(opts); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { InitialiseCache(CacheOptions{TTL: 24 * time.Hour, MaxEntries: 50_000}) })
+}
+
+func Test_cacheReads(t *testing.T) {
+	resetCache(t, CacheOptions{TTL: time.Hour})
+
+	info := &OpenbookInfo{Costs: 2.8}
+	SetOpenbookInfo("mint", info)
+
+	// Every hook reads the same market
+	for i := 0; i < 2; i++ {
+		if got := GetOpenbookInfo("mint"); got != info {
+			t.Fatalf("read %d: got %v", i, got)
+		}
+	}
+
+	openbookCache["mint"].StoredAt = time.Now().Add(-2 * time.Hour)
+	if got := GetOpenbookInfo("mint"); got != nil {
+		t.Errorf("expired entry was returned")
+	}
+	if len(openbookCache) != 0 {
+		t.Errorf("expired entry was not removed")
+	}
+}
+
+func Test_cacheEviction(t *testing.T) {
+	resetCache(t, CacheOptions{TTL: time.Hour, MaxEntries: 2})
+
+	SetOpenbookInfo("a", &OpenbookInfo{})
+	SetOpenbookInfo("b", &OpenbookInfo{})
+	GetOpenbookInfo("a") // b is now the least recently used
+	SetOpenbookInfo("c", &OpenbookInfo{})
+
+	if GetOpenbookInfo("b") != nil || GetOpenbookInfo("a") == nil || GetOpenbookInfo("c") == nil {
+		t.Errorf("least recently used entry was not evicted")
+	}
+
+	// Expired entries go before the least recently used one
+	openbookCache["c"].StoredAt = time.Now().Add(-2 * time.Hour)
+	SetOpenbookInfo("d", &OpenbookInfo{})
+
+	if GetOpenbookInfo("a") == nil || GetOpenbookInfo("d") == nil || len(openbookCache) != 2 {
+		t.Errorf("expired entry was not evicted first")
+	}
+}
+
+func Test_cachePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	resetCache(t, CacheOptions{TTL: time.Hour, Path: path})
+
+	info := &OpenbookInfo{
+		Market:   solana.NewWallet().PublicKey(),
+		BaseMint: solana.NewWallet().PublicKey(),
+		TxTime:   time.Unix(1716990000, 0),
+		Slot:     267_000_009,
+		Costs:    2.8,
+	}
+	SetOpenbookInfo(info.BaseMint.String(), info)
+	SetOpenbookInfo("stale", &OpenbookInfo{})
+	openbookCache["stale"].StoredAt = time.Now().Add(-2 * time.Hour)
+
+	if err := FlushCache(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart
+	resetCache(t, CacheOptions{TTL: time.Hour, Path: path})
+
+	got := GetOpenbookInfo(info.BaseMint.String())
+	if got == nil || got.Market != info.Market || got.Costs != 2.8 || got.Slot != info.Slot || !got.TxTime.Equal(info.TxTime) {
+		t.Fatalf("market not restored: %+v", got)
+	}
+	if len(openbookCache) != 1 {
+		t.Errorf("expired entry was persisted")
+	}
+}
+
+func Test_LookupOpenbookInfo(t *testing.T) {
+	ctx := context.Background()
+	resetCache(t, CacheOptions{TTL: time.Hour})
+	replay := rpctest.Use(t, "testdata/*.json")
+
+	mint := solana.MustPublicKeyFromBase58("G8w3fv64iZscNSKXDagMdeEEAaLQF4r7tZDWBEE3EzhD")
+
+	// The market against SOL is picked over the one against another mint listed before it
+	info, err := LookupOpenbookInfo(ctx, mint, solana.PublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Market.String() != "JAUGYdzrj2jxU9Ao2WiDE1eArqi6bXyKfth3FWJjSurC" || info.Costs != 2.8 {
+		t.Errorf("unexpected market %s with costs %v", info.Market, info.Costs)
+	}
+
+	// The resolved market is cached
+	if GetOpenbookInfo(mint.String()) != info {
+		t.Errorf("resolved market was not cached")
+	}
+
+	missing := solana.MustPublicKeyFromBase58("9cWWqD8AfV72wPtTvbY84QsrRNyuLHiHJ9jgqN2dBEAg")
+	if _, err := LookupOpenbookInfo(ctx, missing, solana.PublicKey{}); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("expected ErrMarketNotFound, got %v", err)
+	}
+
+	if misses := replay.Misses(); len(misses) > 0 {
+		t.Errorf("unexpected requests: %v", misses)
+	}
+}
+
+func Test_LookupOpenbookInfoByMarket(t *testing.T) {
+	ctx := context.Background()
+	resetCache(t, CacheOptions{TTL: time.Hour})
+	replay := rpctest.Use(t, "testdata/*.json")
+
+	mint := solana.MustPublicKeyFromBase58("G8w3fv64iZscNSKXDagMdeEEAaLQF4r7tZDWBEE3EzhD")
+	market := solana.MustPublicKeyFromBase58("JAUGYdzrj2jxU9Ao2WiDE1eArqi6bXyKfth3FWJjSurC")
+
+	// Another market of the same mint was cached first
+	SetOpenbookInfo(mint.String(), &OpenbookInfo{Market: solana.NewWallet().PublicKey(), BaseMint: mint, Costs: 0.4})
+
+	// The market of the pool is read directly, without searching by mint
+	info, err := LookupOpenbookInfo(ctx, mint, market)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Market != market || info.Costs != 2.8 || info.BaseVault.IsZero() || info.VaultSigner.IsZero() {
+		t.Errorf("incomplete market %+v", info)
+	}
+	if info.Caller.IsZero() || !info.TxTime.Equal(time.Unix(1716990000, 0)) {
+		t.Errorf("creation metadata missing: %+v", info)
+	}
+	if GetOpenbookInfo(mint.String()) != info {
+		t.Errorf("market of the pool did not replace the cached one")
+	}
+
+	// A market that does not trade the mint is not cached for it
+	other := solana.NewWallet().PublicKey()
+	if _, err := LookupOpenbookInfo(ctx, other, market); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("expected ErrMarketNotFound for another mint, got %v", err)
+	}
+	if GetOpenbookInfo(other.String()) != nil {
+		t.Errorf("market cached for the wrong mint")
+	}
+
+	notMarket := solana.MustPublicKeyFromBase58("9cWWqD8AfV72wPtTvbY84QsrRNyuLHiHJ9jgqN2dBEAg")
+	if _, err := ResolveMarketAccount(ctx, notMarket); !errors.Is(err, ErrMarketNotFound) {
+		t.Errorf("expected ErrMarketNotFound for a token mint, got %v", err)
+	}
+
+	if misses := replay.Misses(); len(misses) > 0 {
+		t.Errorf("unexpected requests: %v", misses)
+	}
+}
@@ -0,0 +1,64 @@
+package openbook
+
+import (
+	"strconv"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+)
+
+// MarketEvent converts the market into the venue independent event, the lot sizes,
+// fees and oracle are attached as an extra.
+func (info *OpenbookInfo) MarketEvent() *events.MarketEvent {
+	ev := &events.MarketEvent{
+		Venue:      events.VenueOpenbook,
+		ProgramID:  info.ProgramID,
+		Market:     info.Market,
+		BaseMint:   info.BaseMint,
+		QuoteMint:  info.QuoteMint,
+		BaseVault:  info.BaseVault,
+		QuoteVault: info.QuoteVault,
+		Costs:      info.Costs,
+		Creator:    info.Caller,
+		TxID:       info.TxID,
+		Slot:       info.Slot,
+		TxTime:     info.TxTime,
+		Timestamp:  info.Timestamp,
+		Extras:     []events.Extra{marketExtra{info}},
+		Source:     info,
+	}
+	if info.Version == VersionV2 {
+		ev.Kind = string(info.Version)
+	}
+
+	return ev
+}
+
+type marketExtra struct {
+	info *OpenbookInfo
+}
+
+func (m marketExtra) Title() string {
+	return "Market"
+}
+
+func (m marketExtra) Fields() []events.Field {
+	params := m.info.Params
+
+	fields := []events.Field{{Name: "Lot Sizes", Value: utils.I64tS(params.BaseLotSize) + " / " + utils.I64tS(params.QuoteLotSize)}}
+	if m.info.Version == VersionV2 {
+		// Fees are in millionths
+		fields = append(fields, events.Field{
+			Name:  "Fees",
+			Value: strconv.FormatFloat(float64(params.MakerFee)/10_000, 'f', 2, 64) + "% maker, " + strconv.FormatFloat(float64(params.TakerFee)/10_000, 'f', 2, 64) + "% taker",
+		})
+		if !params.OracleA.IsZero() {
+			fields = append(fields, events.Field{
+				Name:  "Oracle",
+				Value: params.OracleA.String(),
+				Link:  "https://solscan.io/account/" + params.OracleA.String(),
+			})
+		}
+	}
+	return fields
+}
@@ -2,15 +2,225 @@ package openbook
 
 import (
 	"context"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs"
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs/rpctest"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
 	"github.com/gagliardetto/solana-go"
 )
 
 func Test_parseTransaction(t *testing.T) {
 	ctx := context.Background()
 
+	rpctest.Use(t, "testdata/*.json")
+
+	tests := []struct {
+		fixture   string
+		signature string
+		want      *OpenbookInfo // nil when the transaction must be ignored
+	}{
+		{
+			fixture:   "sol_quote",
+			signature: "2eMPHTu9Uw1QN1QSo3gEisPv2DoNHrV35go8FSFoY9QM2s95VYpi11JQC3HNUrt6ekn8romi7NUdC6kgqrr1pi3x",
+			want: &OpenbookInfo{
+				BaseMint:  solana.MustPublicKeyFromBase58("G8w3fv64iZscNSKXDagMdeEEAaLQF4r7tZDWBEE3EzhD"),
+				QuoteMint: solana.WrappedSol,
+				Slot:      267_000_009,
+			},
+		},
+		{
+			fixture:   "sol_base_swapped",
+			signature: "3L71azxh7FiY7reccpzjExQvnx1Vc9f5ZCcJAhQqfiZUPbEV2LNGVtv6Nb5ofKMsEKwURxAXi5g1kWLjRNfxfY25",
+			want: &OpenbookInfo{
+				BaseMint:  solana.MustPublicKeyFromBase58("GHSBhK9MRg8g4WkcKgT6ZLhX2VFWPiGpQTQH5CXGSDtT"),
+				QuoteMint: solana.WrappedSol,
+				Slot:      267_000_016,
+				Swapped:   true,
+			},
+		},
+		{
+			fixture:   "usdc_quote",
+			signature: "RC6yFgD9tVX3rrM9sVsRqcfGxfAyhipEY4mk8CActmjZRgMGN2cYGBLEBJyCaySGVXyMQZMJujFso7C5HAsGK1t",
+			want: &OpenbookInfo{
+				BaseMint:  solana.MustPublicKeyFromBase58("ttA7j2rDyB1zFbfPyQiWuhdi7XW3hY3NZbbG6TWj3SF"),
+				QuoteMint: utils.USDC_MINT_PUBKEY,
+				Slot:      267_000_010,
+			},
+		},
+		{
+			fixture:   "usdc_base_swapped",
+			signature: "JcmxLgGAZAANdWVt2MBZQiR8toRKewRyntsDzHUc96iC35UNawYHDkrtjaGLsww9J8LSR7B8C5eTHG5APvCDSHn",
+			want: &OpenbookInfo{
+				BaseMint:  solana.MustPublicKeyFromBase58("FYzmrGCZMztSwSRGVxbXVsjFKFK4vKfRGP4Kdi4Wp4LP"),
+				QuoteMint: utils.USDC_MINT_PUBKEY,
+				Slot:      267_000_017,
+				Swapped:   true,
+			},
+		},
+		{
+			fixture:   "lookup_table",
+			signature: "5gu27kMXYfc9TLWF9PgE299vfnMV78WZgKfThApLvKrnhxyh4K5Fbx5wKWKc4JXdtmBWCYpPy8d1Ux1UsX8Jswtd",
+			want: &OpenbookInfo{
+				Market:    solana.MustPublicKeyFromBase58("J6BmNJ113ArHaU7knvNEZ2jBEJ4bhFwVd8G7G1P1fPBz"),
+				BaseMint:  solana.MustPublicKeyFromBase58("HG9WKQSUkQSoskMtAgCCy5VBVTinR6SxLZvACstbt5rw"),
+				QuoteMint: solana.WrappedSol,
+				Slot:      267_000_012,
+			},
+		},
+		{
+			// Lookup table markets are only reported with a SOL quote
+			fixture:   "lookup_table_usdc",
+			signature: "5VXPydxxM47F2cEBTmRmLS3dUH72kSQr8YgYkYRNXEKA3yaoB67dAYHqfnLSYHLPWqfQSyKei9unpDmU3wYwr9Qp",
+		},
+		{
+			fixture:   "v2_create_market",
+			signature: "mVrceRtP5BS3eCUKHZKF5QXp66hdvZR9Ks2w1yaJrsRQA2iZ64zSXhdDsUAwGMCjXBSWoRhsQeSE6xBkZ2LJ42s",
+			want: &OpenbookInfo{
+				Version:   VersionV2,
+				Market:    solana.MustPublicKeyFromBase58("AzMyNixw9a2aRHgNYnWotC17pKqaRq4MAfwLxJoF4nsh"),
+				BaseMint:  solana.MustPublicKeyFromBase58("Bos1mQZfbkdhxEoMCx3SFB4WuAR8xdwjj9nTWU5RNMCq"),
+				QuoteMint: solana.WrappedSol,
+				Slot:      267_000_020,
+				Swapped:   true,
+			},
+		},
+		{
+			fixture:   "failed",
+			signature: "3oSHwiJ6pxWboXGebQ1kwqjvAbAxhZbQ6ncP8Um3gWoyVd5PXyeJyJbLpV665beDwsW8hz1BP5j38QeXHmzYMWY",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			signature := solana.MustSignatureFromBase58(tt.signature)
+			info := parseTransaction(ctx, signature)
+
+			if tt.want == nil {
+				if info != nil {
+					t.Fatalf("expected no market, got %+v", info)
+				}
+				return
+			}
+			if info == nil {
+				t.Fatal("info is nil")
+			}
+
+			if info.BaseMint != tt.want.BaseMint || info.QuoteMint != tt.want.QuoteMint {
+				t.Errorf("mints: got %s/%s, want %s/%s", info.BaseMint, info.QuoteMint, tt.want.BaseMint, tt.want.QuoteMint)
+			}
+			want := tt.want.Version
+			if want == "" {
+				want = VersionV3
+			}
+			if info.Version != want {
+				t.Errorf("version: got %q, want %q", info.Version, want)
+			}
+			if info.Swapped != tt.want.Swapped {
+				t.Errorf("swapped: got %v, want %v", info.Swapped, tt.want.Swapped)
+			}
+			if !tt.want.Market.IsZero() && info.Market != tt.want.Market {
+				t.Errorf("market: got %s, want %s", info.Market, tt.want.Market)
+			}
+			if info.TxID != signature || info.Slot != tt.want.Slot {
+				t.Errorf("tx: got %s at %d, want %s at %d", info.TxID, info.Slot, signature, tt.want.Slot)
+			}
+			if !info.TxTime.Equal(time.Unix(1716990000, 0)) {
+				t.Errorf("tx time: got %v", info.TxTime)
+			}
+			if info.Costs != 2.8 {
+				t.Errorf("costs: got %v, want 2.8", info.Costs)
+			}
+			if info.Market.IsZero() || info.EventQueue.IsZero() || info.Bids.IsZero() || info.Asks.IsZero() || info.VaultSigner.IsZero() {
+				t.Errorf("market accounts missing: %+v", info)
+			}
+		})
+	}
+}
+
+func Test_destructInfo(t *testing.T) {
+	ctx := context.Background()
+
+	rpctest.Use(t, "testdata/sol_quote.json", "testdata/sol_base_swapped.json")
+
+	// The vaults follow the mints when the pair is swapped
+	for _, sig := range []string{
+		"2eMPHTu9Uw1QN1QSo3gEisPv2DoNHrV35go8FSFoY9QM2s95VYpi11JQC3HNUrt6ekn8romi7NUdC6kgqrr1pi3x",
+		"3L71azxh7FiY7reccpzjExQvnx1Vc9f5ZCcJAhQqfiZUPbEV2LNGVtv6Nb5ofKMsEKwURxAXi5g1kWLjRNfxfY25",
+	} {
+		rpcTx, tx, err := utils.GetConfirmedTransaction_S(ctx, solana.MustSignatureFromBase58(sig))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		instr := tx.Message.Instructions[len(tx.Message.Instructions)-1]
+		var info OpenbookInfo
+		if !destructInfo(instr, rpcTx, tx, &info) {
+			t.Fatal("instruction not recognised")
+		}
+
+		baseVault := tx.Message.AccountKeys[instr.Accounts[5]]
+		quoteVault := tx.Message.AccountKeys[instr.Accounts[6]]
+		if info.Swapped {
+			baseVault, quoteVault = quoteVault, baseVault
+		}
+		if info.BaseVault != baseVault || info.QuoteVault != quoteVault {
+			t.Errorf("%s: vaults got %s/%s, want %s/%s", sig, info.BaseVault, info.QuoteVault, baseVault, quoteVault)
+		}
+
+		signer, err := solana.CreateProgramAddress([][]byte{info.Market.Bytes(), instr.Data[23:31]}, solana.MustPublicKeyFromBase58(utils.OPENBOOK_PRGRAM_ID))
+		if err != nil || info.VaultSigner != signer {
+			t.Errorf("%s: vault signer got %s, want %s (%v)", sig, info.VaultSigner, signer, err)
+		}
+	}
+}
+
+func Test_destructV2Info(t *testing.T) {
+	ctx := context.Background()
+
+	rpctest.Use(t, "testdata/v2_create_market.json")
+
+	info := parseTransaction(ctx, solana.MustSignatureFromBase58("mVrceRtP5BS3eCUKHZKF5QXp66hdvZR9Ks2w1yaJrsRQA2iZ64zSXhdDsUAwGMCjXBSWoRhsQeSE6xBkZ2LJ42s"))
+	if info == nil {
+		t.Fatal("info is nil")
+	}
+
+	// The lot sizes follow the mints, SOL was created as base
+	want := MarketParams{
+		Name:              "SOL-FIX",
+		BaseLotSize:       1,
+		QuoteLotSize:      1_000_000,
+		MakerFee:          -200,
+		TakerFee:          400,
+		OracleA:           solana.MustPublicKeyFromBase58("2g4fJBfE5MjT5WCzwJghXMCzJCu62GXSG1yF5SAimSBr"),
+		ConfFilter:        0.1,
+		MaxStalenessSlots: 100,
+	}
+	if info.Params != want {
+		t.Errorf("params:\n got %+v\nwant %+v", info.Params, want)
+	}
+
+	if info.Caller != solana.MustPublicKeyFromBase58("6MsV4mkumwLwHpAuDCjfur5eEMbBapA2G4bJaFnJEZ3W") {
+		t.Errorf("caller: got %s", info.Caller)
+	}
+	if info.VaultSigner != solana.MustPublicKeyFromBase58("7sYgNRANguhXZ6zohcQHn2HqDiKBYMd5jQQNoZihDet1") || info.EventQueue != solana.MustPublicKeyFromBase58("mT9rPeF652NfeqU6mpytapXUDeg8Hn3PqcXg5eiDPKc") {
+		t.Errorf("market authority/event heap: got %s/%s", info.VaultSigner, info.EventQueue)
+	}
+	if info.BaseVault != solana.MustPublicKeyFromBase58("2dhktUCJMuapXExHd3ZKFNvitG4p1L1AioYn5DjZW8Ad") || info.QuoteVault != solana.MustPublicKeyFromBase58("3t1YWF8QSxSQTKHkrm9tVPSuTiXB14CUfhbnn9kiihfB") {
+		t.Errorf("vaults: got %s/%s", info.BaseVault, info.QuoteVault)
+	}
+}
+
+// Test_parseTransactionLive runs against mainnet, set INCLUDE_SOLANA_BETA_MAINNET_RPC=1 to enable it.
+func Test_parseTransactionLive(t *testing.T) {
+	if os.Getenv("INCLUDE_SOLANA_BETA_MAINNET_RPC") != "1" {
+		t.Skip("mainnet RPC not enabled")
+	}
+
+	ctx := context.Background()
+
 	rpcs.Initialise([]string{})
 
 	info := parseTransaction(ctx, solana.MustSignatureFromBase58("3od1BuAnH6KY2qQA73LoLCT4t2aL2MC14MFxv4uVb4daMgwZzjgKW2HgYkGHHj4DFCa52Zuu42M8QRAeg4gR4v9k"))
@@ -2,30 +2,55 @@ package openbook
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"os"
 	"time"
 
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
 	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
 	"github.com/fatih/color"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
-func ProcessMessages(rChn <-chan solana.Signature, sendChn chan<- *OpenbookInfo) {
-	ctx := context.Background()
+// ProcessMessages parses every signature from rChn and forwards the detected markets to sendChn.
+// Once ctx is cancelled the remaining signatures are drained without being processed,
+// their number is returned.
+func ProcessMessages(ctx context.Context, rChn <-chan ingest.Event, sendChn chan<- *events.MarketEvent) int {
+	abandoned := 0
 
 	for msg := range rChn {
-		info := parseTransaction(ctx, msg)
+		if ctx.Err() != nil {
+			abandoned++
+			continue
+		}
+
+		info := parseEvent(ctx, msg)
 		if info == nil {
 			continue
 		}
 
-		SetOpenbookInfo(info.BaseMint.String(), info)
-		sendChn <- info
+		// Raydium AMM v4 pools are only built on v3 markets
+		if info.Version == VersionV3 {
+			SetOpenbookInfo(info.BaseMint.String(), info)
+		}
+		sendChn <- info.MarketEvent()
 	}
 
 	fmt.Printf("Openbook processing out...\n")
+
+	return abandoned
+}
+
+// parseEvent uses the transaction delivered by the source, or fetches it if there is none.
+func parseEvent(ctx context.Context, ev ingest.Event) *OpenbookInfo {
+	if ev.Transaction == nil || ev.Tx == nil {
+		return parseTransaction(ctx, ev.Signature)
+	}
+
+	return parseResult(ev.Transaction, ev.Tx)
 }
 
 func parseTransaction(ctx context.Context, signature solana.Signature) *OpenbookInfo {
@@ -40,8 +65,12 @@ func parseTransaction(ctx context.Context, signature solana.Signature) *Openbook
 		return nil
 	}
 
-	if (len(tx.Message.Instructions)) < 6 {
-		return nil
+	return parseResult(rpcTx, tx)
+}
+
+func parseResult(rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) *OpenbookInfo {
+	if rpcTx.Meta.Err != nil {
+		return nil // Market was never created.
 	}
 
 	for _, instr := range tx.Message.Instructions {
@@ -50,18 +79,23 @@ func parseTransaction(ctx context.Context, signature solana.Signature) *Openbook
 			continue // Program account index out of range.
 		}
 
-		if program.String() != utils.OPENBOOK_PRGRAM_ID {
-			continue // Not called by openbook.
-		}
-
-		if len(instr.Accounts) < 10 {
-			continue // Not enough accounts for InitializeMarket instruction.
-		}
-
 		info := OpenbookInfo{
 			ProgramID: program,
+			Version:   VersionOf(program),
+		}
+
+		var wasFound bool
+		switch info.Version {
+		case VersionV3:
+			if len(tx.Message.Instructions) < 6 || len(instr.Accounts) < 10 {
+				continue // Not enough accounts for InitializeMarket instruction.
+			}
+			wasFound = destructInfo(instr, rpcTx, tx, &info)
+		case VersionV2:
+			wasFound = destructV2Info(instr, rpcTx, tx, &info)
+		default:
+			continue // Not called by openbook.
 		}
-		wasFound := destructInfo(instr, rpcTx, tx, &info)
 
 		if wasFound {
 			// Set the costs (this should be safe)
@@ -127,16 +161,19 @@ func destructInfo(instr solana.CompiledInstruction, rpcTx *rpc.GetTransactionRes
 		return false
 	}
 
-	if info.BaseMint == solana.WrappedSol {
-		info.BaseVault, info.QuoteVault = info.QuoteVault, info.BaseVault
-		info.BaseMint, info.QuoteMint = info.QuoteMint, info.BaseMint
-		info.Swapped = true
-	} else if info.BaseMint == utils.USDC_MINT_PUBKEY {
-		info.BaseMint, info.QuoteMint = info.QuoteMint, info.BaseMint
-		info.BaseVault, info.QuoteVault = info.QuoteVault, info.BaseVault
-		info.Swapped = true
+	if len(instr.Data) < 31 {
+		return false
+	}
+
+	// base_lot_size, quote_lot_size, fee_rate_bps, vault_signer_nonce
+	info.Params = MarketParams{
+		BaseLotSize:  int64(binary.LittleEndian.Uint64(instr.Data[5:13])),
+		QuoteLotSize: int64(binary.LittleEndian.Uint64(instr.Data[13:21])),
+		FeeRateBps:   binary.LittleEndian.Uint16(instr.Data[21:23]),
 	}
 
+	orientPair(info)
+
 	info.Caller = tx.Message.AccountKeys[0] // Should be ok, but not sure.
 	info.TxID = tx.Signatures[0]
 
@@ -155,8 +192,20 @@ func destructInfo(instr solana.CompiledInstruction, rpcTx *rpc.GetTransactionRes
 	info.VaultSigner = vaultsigner
 
 	info.Slot = rpcTx.Slot
-	info.TxTime = rpcTx.BlockTime.Time()
+	info.TxTime = utils.BlockTime(rpcTx)
 	info.Timestamp = time.Now()
 
 	return true
 }
+
+// orientPair makes SOL or USDC the quote of the market, the vaults and lot sizes follow the mints.
+func orientPair(info *OpenbookInfo) {
+	if info.BaseMint != solana.WrappedSol && info.BaseMint != utils.USDC_MINT_PUBKEY {
+		return
+	}
+
+	info.BaseMint, info.QuoteMint = info.QuoteMint, info.BaseMint
+	info.BaseVault, info.QuoteVault = info.QuoteVault, info.BaseVault
+	info.Params.BaseLotSize, info.Params.QuoteLotSize = info.Params.QuoteLotSize, info.Params.BaseLotSize
+	info.Swapped = true
+}
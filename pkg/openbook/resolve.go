@@ -0,0 +1,274 @@
+package openbook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Layout of an OpenBook v3 (serum) market account
+const (
+	marketAccountSize     = 388
+	marketBaseMintOffset  = 53
+	marketQuoteMintOffset = 85
+)
+
+const signaturesPageSize = 1000
+const signaturesMaxPages = 10
+
+// Searching the markets of a mint scans the whole program, which is slow (or disabled)
+// on many RPC providers. It is bounded by marketScanTimeout and at most marketCandidatesMax
+// of the markets found are resolved.
+const (
+	marketScanTimeout   = 15 * time.Second
+	marketCandidatesMax = 5
+)
+
+var ErrMarketNotFound = errors.New("openbook market not found")
+
+// Resolver is called by LookupOpenbookInfo on a cache miss, e.g. for markets created
+// before the monitor started. market is zero when the caller does not know it.
+var Resolver func(ctx context.Context, mint solana.PublicKey, market solana.PublicKey) (*OpenbookInfo, error) = resolve
+
+// LookupOpenbookInfo returns the cached market of the mint, resolving and caching it on a miss.
+// Pass the market account when it is known (e.g. from the Raydium pool), otherwise it is
+// searched for by mint. A mint can have several markets, a cached market other than the
+// one passed is resolved again and replaced.
+func LookupOpenbookInfo(ctx context.Context, mint solana.PublicKey, market solana.PublicKey) (*OpenbookInfo, error) {
+	if info := GetOpenbookInfo(mint.String()); info != nil && (market.IsZero() || info.Market == market) {
+		return info, nil
+	}
+
+	info, err := Resolver(ctx, mint, market)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.BaseMint != mint && info.QuoteMint != mint {
+		return nil, fmt.Errorf("%w: market %s does not trade %s", ErrMarketNotFound, info.Market, mint)
+	}
+
+	SetOpenbookInfo(mint.String(), info)
+	return info, nil
+}
+
+func resolve(ctx context.Context, mint solana.PublicKey, market solana.PublicKey) (*OpenbookInfo, error) {
+	if market.IsZero() {
+		return ResolveMarket(ctx, mint)
+	}
+	return ResolveMarketAccount(ctx, market)
+}
+
+// ResolveMarket finds the markets of the mint on chain and resolves them with
+// ResolveMarketAccount. Anyone can create a market for any mint, so the oldest of the
+// markets against SOL (or USDC, or anything else when there are none) is returned.
+func ResolveMarket(ctx context.Context, mint solana.PublicKey) (*OpenbookInfo, error) {
+	scanCtx, scanCancel := context.WithTimeout(ctx, marketScanTimeout)
+	markets, err := findMarkets_S(scanCtx, mint)
+	scanCancel()
+	if err != nil {
+		return nil, err
+	}
+
+	var oldest *OpenbookInfo
+	for _, market := range markets {
+		info, resolveErr := ResolveMarketAccount(ctx, market)
+		if resolveErr != nil {
+			err = resolveErr
+			continue
+		}
+		if oldest == nil || info.Slot < oldest.Slot {
+			oldest = info
+		}
+	}
+	if oldest == nil {
+		return nil, err
+	}
+
+	return oldest, nil
+}
+
+// ResolveMarketAccount checks that market is an openbook market and parses its creation
+// transaction, which gives the same info (including Costs) as a live detection.
+func ResolveMarketAccount(ctx context.Context, market solana.PublicKey) (*OpenbookInfo, error) {
+	account := getAccountInfo_S(ctx, market)
+	if account == nil || account.Value == nil {
+		return nil, fmt.Errorf("%w: failed to get account %s", ErrMarketNotFound, market)
+	}
+	if account.Value.Owner.String() != utils.OPENBOOK_PRGRAM_ID || len(account.Value.Data.GetBinary()) != marketAccountSize {
+		return nil, fmt.Errorf("%w: %s is not an openbook market", ErrMarketNotFound, market)
+	}
+
+	signature, err := findCreationSignature_S(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+
+	info := parseTransaction(ctx, signature)
+	if info == nil || info.Market != market {
+		return nil, fmt.Errorf("%w: creation transaction %s of %s not recognised", ErrMarketNotFound, signature, market)
+	}
+
+	return info, nil
+}
+
+func getAccountInfo_S(ctx context.Context, account solana.PublicKey) *rpc.GetAccountInfoResult {
+	for i := 0; i < 5; i++ {
+		wrapped_ctx, wrapped_cancel := context.WithTimeout(ctx, 5*time.Second)
+		result, err := rpcs.Hedge(wrapped_ctx, func(ctx context.Context, client *rpc.Client) (*rpc.GetAccountInfoResult, error) {
+			return client.GetAccountInfo(ctx, account)
+		})
+		wrapped_cancel()
+
+		if errors.Is(err, rpc.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			if os.Getenv("DEBUG") == "1" {
+				color.New(color.FgYellow).Printf("getAccountInfo_S -> Failed to get account info, retrying (%d): %v\n", i+1, err)
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		return result
+	}
+
+	return nil
+}
+
+// findMarkets_S returns the markets with the mint as base, or as quote for markets created
+// in reverse. Only the markets against the best counter mint are returned, sorted by address,
+// at most marketCandidatesMax.
+func findMarkets_S(ctx context.Context, mint solana.PublicKey) ([]solana.PublicKey, error) {
+	for _, offset := range []uint64{marketBaseMintOffset, marketQuoteMintOffset} {
+		accounts, err := getMarketAccounts_S(ctx, mint, offset)
+		if err != nil {
+			return nil, err
+		}
+		if len(accounts) == 0 {
+			continue
+		}
+
+		counterOffset := marketQuoteMintOffset - marketBaseMintOffset
+		if offset == marketQuoteMintOffset {
+			counterOffset = 0
+		}
+
+		best := len(counterRanks)
+		var markets []solana.PublicKey
+		for _, account := range accounts {
+			data := account.Account.Data.GetBinary()
+			if len(data) < counterOffset+32 {
+				continue
+			}
+
+			rank := counterRank(solana.PublicKeyFromBytes(data[counterOffset : counterOffset+32]))
+			if rank < best {
+				best, markets = rank, nil
+			}
+			if rank == best {
+				markets = append(markets, account.Pubkey)
+			}
+		}
+		if len(markets) == 0 {
+			continue
+		}
+
+		sort.Slice(markets, func(i, j int) bool {
+			return bytes.Compare(markets[i][:], markets[j][:]) < 0
+		})
+		return markets[:min(len(markets), marketCandidatesMax)], nil
+	}
+
+	return nil, fmt.Errorf("%w: no market for %s", ErrMarketNotFound, mint)
+}
+
+// Counter mints of a market, best first
+var counterRanks = []solana.PublicKey{solana.WrappedSol, utils.USDC_MINT_PUBKEY}
+
+func counterRank(mint solana.PublicKey) int {
+	for i, counter := range counterRanks {
+		if mint == counter {
+			return i
+		}
+	}
+	return len(counterRanks)
+}
+
+func getMarketAccounts_S(ctx context.Context, mint solana.PublicKey, offset uint64) (rpc.GetProgramAccountsResult, error) {
+	var err error
+
+	for i := 0; i < 5; i++ {
+		client := rpcs.BorrowClient()
+
+		wrapped_ctx, wrapped_cancel := context.WithTimeout(ctx, 10*time.Second)
+		// Only the base and quote mints are needed
+		sliceOffset, sliceLength := uint64(marketBaseMintOffset), uint64(marketQuoteMintOffset+32-marketBaseMintOffset)
+
+		var accounts rpc.GetProgramAccountsResult
+		accounts, err = client.GetProgramAccountsWithOpts(wrapped_ctx, solana.MustPublicKeyFromBase58(utils.OPENBOOK_PRGRAM_ID), &rpc.GetProgramAccountsOpts{
+			Commitment: rpc.CommitmentConfirmed,
+			Encoding:   solana.EncodingBase64,
+			DataSlice:  &rpc.DataSlice{Offset: &sliceOffset, Length: &sliceLength},
+			Filters: []rpc.RPCFilter{
+				{DataSize: marketAccountSize},
+				{Memcmp: &rpc.RPCFilterMemcmp{Offset: offset, Bytes: mint.Bytes()}},
+			},
+		})
+		wrapped_cancel()
+
+		if err != nil {
+			if os.Getenv("DEBUG") == "1" {
+				color.New(color.FgYellow).Printf("getMarketAccounts_S -> Failed to get market accounts, retrying (%d): %v\n", i+1, err)
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		return accounts, nil
+	}
+
+	return nil, fmt.Errorf("failed to get market accounts: %w", err)
+}
+
+// findCreationSignature_S pages back through the history of the market, the oldest
+// signature is the transaction that created it.
+func findCreationSignature_S(ctx context.Context, market solana.PublicKey) (solana.Signature, error) {
+	var before solana.Signature
+
+	for page := 0; page < signaturesMaxPages; page++ {
+		signatures, err := utils.GetSignaturesForAddress_S(ctx, market, before, signaturesPageSize)
+		if err != nil {
+			return solana.Signature{}, err
+		}
+
+		if len(signatures) == 0 {
+			if before.IsZero() {
+				return solana.Signature{}, fmt.Errorf("%w: no transactions for %s", ErrMarketNotFound, market)
+			}
+			return before, nil
+		}
+
+		before = signatures[len(signatures)-1].Signature
+		if len(signatures) < signaturesPageSize {
+			return before, nil
+		}
+	}
+
+	return solana.Signature{}, fmt.Errorf("%w: history of %s exceeds %d signatures", ErrMarketNotFound, market, signaturesMaxPages*signaturesPageSize)
+}
@@ -0,0 +1,130 @@
+package openbook
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Anchor discriminator of the OpenBook v2 create_market instruction, sha256("global:create_market")[:8]
+var v2CreateMarketDiscriminator = []byte{0x67, 0xe2, 0x61, 0xeb, 0xc8, 0xbc, 0xfb, 0xfe}
+
+// Accounts of the OpenBook v2 create_market instruction
+const (
+	v2MarketIndex          = 0
+	v2MarketAuthorityIndex = 1
+	v2BidsIndex            = 2
+	v2AsksIndex            = 3
+	v2EventHeapIndex       = 4
+	v2PayerIndex           = 5
+	v2BaseVaultIndex       = 6
+	v2QuoteVaultIndex      = 7
+	v2BaseMintIndex        = 8
+	v2QuoteMintIndex       = 9
+	v2OracleAIndex         = 13
+	v2OracleBIndex         = 14
+	v2AccountsLen          = 21
+)
+
+// createMarketArgs are the borsh encoded arguments following the discriminator.
+type createMarketArgs struct {
+	Name         string
+	OracleConfig struct {
+		ConfFilter        float32
+		MaxStalenessSlots *uint32 `bin:"optional"`
+	}
+	QuoteLotSize int64
+	BaseLotSize  int64
+	MakerFee     int64
+	TakerFee     int64
+	TimeExpiry   int64
+}
+
+func v2LogFilter(logs []string) bool {
+	for _, log := range logs {
+		// Exact match, other programs may log instructions ending in CreateMarket
+		if log == utils.OPENBOOK_V2_IDENTIFIER {
+			return true
+		}
+	}
+	return false
+}
+
+// destructV2Info fills info from an OpenBook v2 create_market instruction. The market
+// authority PDA takes the place of the v3 vault signer and the event heap that of the event queue.
+func destructV2Info(instr solana.CompiledInstruction, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction, info *OpenbookInfo) bool {
+	data := []byte(instr.Data)
+	if len(data) < 8 || !bytes.Equal(data[:8], v2CreateMarketDiscriminator) {
+		return false // Place order, consume events...
+	}
+
+	if len(instr.Accounts) < v2AccountsLen {
+		color.New(color.FgYellow).Printf("[OPENBOOK] destructV2Info -> Required accounts length for instruction not met (%d)\n", len(instr.Accounts))
+		return false
+	}
+
+	var args createMarketArgs
+	if err := bin.NewBorshDecoder(data[8:]).Decode(&args); err != nil {
+		fmt.Printf("Openbook: failed to decode create_market of %s: %v\n", tx.Signatures[0], err)
+		return false
+	}
+
+	keys := utils.AccountKeys(rpcTx, tx)
+	account := func(i int) solana.PublicKey {
+		return utils.InstrAccount(keys, instr, i)
+	}
+	// Optional accounts that are not passed are set to the program id
+	optional := func(i int) solana.PublicKey {
+		if key := account(i); key != info.ProgramID {
+			return key
+		}
+		return solana.PublicKey{}
+	}
+
+	info.Market = account(v2MarketIndex)
+	info.VaultSigner = account(v2MarketAuthorityIndex)
+	info.Bids = account(v2BidsIndex)
+	info.Asks = account(v2AsksIndex)
+	info.EventQueue = account(v2EventHeapIndex)
+	info.BaseVault = account(v2BaseVaultIndex)
+	info.QuoteVault = account(v2QuoteVaultIndex)
+	info.BaseMint = account(v2BaseMintIndex)
+	info.QuoteMint = account(v2QuoteMintIndex)
+
+	if info.BaseMint.IsZero() || info.QuoteMint.IsZero() {
+		return false
+	}
+
+	info.Params = MarketParams{
+		Name:              args.Name,
+		BaseLotSize:       args.BaseLotSize,
+		QuoteLotSize:      args.QuoteLotSize,
+		MakerFee:          args.MakerFee,
+		TakerFee:          args.TakerFee,
+		TimeExpiry:        args.TimeExpiry,
+		OracleA:           optional(v2OracleAIndex),
+		OracleB:           optional(v2OracleBIndex),
+		ConfFilter:        args.OracleConfig.ConfFilter,
+		MaxStalenessSlots: -1,
+	}
+	if args.OracleConfig.MaxStalenessSlots != nil {
+		info.Params.MaxStalenessSlots = int64(*args.OracleConfig.MaxStalenessSlots)
+	}
+
+	orientPair(info)
+
+	info.Caller = account(v2PayerIndex)
+	info.TxID = tx.Signatures[0]
+
+	info.Slot = rpcTx.Slot
+	info.TxTime = utils.BlockTime(rpcTx)
+	info.Timestamp = time.Now()
+
+	return true
+}
@@ -6,15 +6,51 @@ import (
 	"strings"
 	"time"
 
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
 	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
 	"github.com/gagliardetto/solana-go"
-	"github.com/gagliardetto/solana-go/rpc"
-	"github.com/gagliardetto/solana-go/rpc/ws"
 )
 
+// Version of the openbook program a market was created on.
+type Version string
+
+const (
+	VersionV3 Version = "v3" // Serum fork, the markets Raydium AMM v4 pools are built on
+	VersionV2 Version = "v2" // Anchor rewrite
+)
+
+// VersionOf returns the version of the openbook program, empty for other programs.
+func VersionOf(program solana.PublicKey) Version {
+	switch program.String() {
+	case utils.OPENBOOK_PRGRAM_ID:
+		return VersionV3
+	case utils.OPENBOOK_V2_PROGRAM_ID:
+		return VersionV2
+	}
+	return ""
+}
+
+// MarketParams are the trading parameters a market was created with, lot sizes
+// follow the mints when the pair is swapped.
+type MarketParams struct {
+	Name         string // v2 only
+	BaseLotSize  int64
+	QuoteLotSize int64
+	FeeRateBps   uint16 // v3 only
+
+	MakerFee          int64            // v2 only, in millionths, negative for a rebate
+	TakerFee          int64            // v2 only, in millionths
+	TimeExpiry        int64            // v2 only, unix time the market expires, 0 for never
+	OracleA           solana.PublicKey // v2 only, zero without oracle
+	OracleB           solana.PublicKey // v2 only, zero without second oracle
+	ConfFilter        float32          // v2 only, oracle confidence filter
+	MaxStalenessSlots int64            // v2 only, -1 when staleness is not checked
+}
+
 type OpenbookInfo struct {
 	// Initialize Market Instruction Data
-	ProgramID  solana.PublicKey // always OpenBook v3
+	ProgramID  solana.PublicKey // OpenBook v3 or v2
+	Version    Version          // Derived from ProgramID
 	Market     solana.PublicKey // serum market address
 	EventQueue solana.PublicKey // serum event queue address
 	Bids       solana.PublicKey // serum bids address
@@ -35,45 +71,43 @@ type OpenbookInfo struct {
 	// Initialize Market Instruction Extra
 	VaultSigner solana.PublicKey // Vault signer; this value is provided by separate RPC call.
 
-	Costs float64 // Costs of openbook creation
+	Costs  float64      // Costs of openbook creation
+	Params MarketParams // Lot sizes, fees and oracles
 }
 
-func Start(ctx context.Context, wsUrl string, ch chan<- solana.Signature) error {
-	client, err := ws.Connect(ctx, wsUrl)
-	if err != nil {
-		return err
-	}
+// Programs the monitor subscribes to, each with the filter for its market creation logs.
+var programs = []struct {
+	id     string
+	filter ingest.LogFilter
+}{
+	{utils.OPENBOOK_PRGRAM_ID, logFilter},
+	{utils.OPENBOOK_V2_PROGRAM_ID, v2LogFilter},
+}
 
-	fmt.Printf("Starting Openbook monitor\n")
+// Start forwards every market candidate from src to ch until ctx is cancelled or one of the
+// subscriptions fails, the others are then stopped as well.
+func Start(ctx context.Context, src ingest.Source, ch chan<- ingest.Event) error {
+	fmt.Printf("Starting Openbook monitor (%s)\n", src.Name())
 
-	openbook := solana.MustPublicKeyFromBase58(utils.OPENBOOK_PRGRAM_ID)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	sub, err := client.LogsSubscribeMentions(
-		openbook,
-		rpc.CommitmentConfirmed,
-	)
-	if err != nil {
-		return err
+	errs := make(chan error, len(programs))
+	for _, p := range programs {
+		go func() {
+			errs <- src.Subscribe(ctx, solana.MustPublicKeyFromBase58(p.id), p.filter, ch)
+		}()
 	}
-	defer sub.Unsubscribe()
-
-	var lastSignature string
-	for {
-		got, err := sub.Recv()
-		if err != nil {
-			return err
-		}
 
-		if got.Value.Signature.String() == lastSignature {
-			continue
-		}
-
-		lastSignature = got.Value.Signature.String()
-
-		if logFilter(got.Value.Logs) {
-			ch <- got.Value.Signature
+	err := <-errs
+	cancel()
+	for i := 1; i < len(programs); i++ {
+		if e := <-errs; err == nil {
+			err = e
 		}
 	}
+
+	return err
 }
 
 func logFilter(logs []string) bool {
@@ -0,0 +1,61 @@
+package orca
+
+import (
+	"strconv"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+)
+
+// PoolEvent converts the pool into the venue independent event, the concentrated
+// liquidity parameters are attached as an extra.
+func (info *WhirlpoolInfo) PoolEvent() *events.PoolEvent {
+	ev := &events.PoolEvent{
+		Venue:          events.VenueOrca,
+		Kind:           "Whirlpool",
+		ProgramID:      info.ProgramID,
+		Pool:           info.Pool,
+		BaseMint:       info.BaseMint,
+		QuoteMint:      info.QuoteMint,
+		BaseVault:      info.BaseVault,
+		QuoteVault:     info.QuoteVault,
+		LPMint:         info.LPMint,
+		LPAccount:      info.LPAccount,
+		BaseLiquidity:  info.BaseLiquidity,
+		QuoteLiquidity: info.QuoteLiquidity,
+		OpenTime:       info.OpenTime(),
+		Creator:        info.Caller,
+		TxID:           info.TxID,
+		Slot:           info.Slot,
+		TxTime:         info.TxTime,
+		Timestamp:      info.Timestamp,
+		Source:         info,
+	}
+
+	// Nil pointers would end up as non-nil interfaces
+	if info.Params != nil {
+		ev.Extras = append(ev.Extras, info.Params)
+	}
+
+	return ev
+}
+
+func (p *PoolParams) Title() string {
+	return "Concentrated Liquidity"
+}
+
+func (p *PoolParams) Fields() []events.Field {
+	var fields []events.Field
+	if p.FeeRate > 0 {
+		fields = append(fields, events.Field{Name: "Fee Tier", Value: p.FeePercent() + " (tick spacing " + strconv.Itoa(int(p.TickSpacing)) + ")"})
+	} else {
+		fields = append(fields, events.Field{Name: "Tick Spacing", Value: strconv.Itoa(int(p.TickSpacing))})
+	}
+	if p.Price > 0 {
+		fields = append(fields, events.Field{Name: "Initial Price", Value: strconv.FormatFloat(p.Price, 'g', 6, 64)})
+	}
+	return append(fields, events.Field{
+		Name:  "First Position",
+		Value: "Ticks " + strconv.Itoa(int(p.TickLower)) + " to " + strconv.Itoa(int(p.TickUpper)),
+		Link:  "https://solscan.io/tx/" + p.PositionTxID.String(),
+	})
+}
@@ -0,0 +1,78 @@
+package orca
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// WhirlpoolInfo is a Whirlpool that was created and funded by its first position.
+type WhirlpoolInfo struct {
+	ProgramID  solana.PublicKey
+	Pool       solana.PublicKey
+	LPMint     solana.PublicKey // Position NFT of the first position
+	BaseMint   solana.PublicKey
+	QuoteMint  solana.PublicKey
+	BaseVault  solana.PublicKey
+	QuoteVault solana.PublicKey
+	LPAccount  solana.PublicKey // Token account holding the position NFT
+
+	BaseLiquidity   float64
+	QuoteLiquidity  float64
+	InitBaseAmount  uint64 // Raw amounts deposited by the first position
+	InitQuoteAmount uint64
+
+	Caller    solana.PublicKey // Funder of the pool
+	TxID      solana.Signature // Pool initialisation
+	Slot      uint64
+	TxTime    time.Time // Block time
+	Timestamp time.Time // Detection time
+	Swapped   bool      // Whether the pair was created in reverse order
+
+	Params *PoolParams
+}
+
+// OpenTime is when trading starts, Whirlpools trade as soon as they are created. The
+// detection time stands in for an unknown block time.
+func (info *WhirlpoolInfo) OpenTime() time.Time {
+	if info.TxTime.IsZero() {
+		return info.Timestamp
+	}
+	return info.TxTime
+}
+
+// orient makes SOL or USDC the quote of the pair, the liquidity, vaults and initial
+// amounts follow the mints.
+func (info *WhirlpoolInfo) orient() {
+	if !utils.IsQuoteMint(info.BaseMint) {
+		return
+	}
+
+	info.BaseMint, info.QuoteMint = info.QuoteMint, info.BaseMint
+	info.BaseVault, info.QuoteVault = info.QuoteVault, info.BaseVault
+	info.BaseLiquidity, info.QuoteLiquidity = info.QuoteLiquidity, info.BaseLiquidity
+	info.InitBaseAmount, info.InitQuoteAmount = info.InitQuoteAmount, info.InitBaseAmount
+	info.Swapped = true
+}
+
+// PoolParams are the concentrated liquidity parameters of a pool and its first position.
+type PoolParams struct {
+	FeeTier      solana.PublicKey
+	SqrtPriceX64 bin.Uint128 // Initial sqrt price of token B per token A, Q64.64
+	Price        float64     // Initial price of the base mint in the quote mint
+	TickSpacing  uint16
+	FeeRate      uint32 // In hundredths of a basis point, 3000 is 0.3%, 0 when the fee tier could not be fetched
+
+	// First position
+	TickLower    int32
+	TickUpper    int32
+	PositionTxID solana.Signature
+}
+
+// FeePercent formats the fee rate as a percentage.
+func (p *PoolParams) FeePercent() string {
+	return strconv.FormatFloat(float64(p.FeeRate)/10_000, 'f', -1, 64) + "%"
+}
@@ -0,0 +1,165 @@
+package orca
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs"
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs/rpctest"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+func Test_parseWhirlpool(t *testing.T) {
+	ctx := context.Background()
+
+	replay := rpctest.Use(t, "testdata/whirlpool_*.json")
+	t.Cleanup(func() {
+		whirlpoolPendingMutex.Lock()
+		clear(whirlpoolPending)
+		whirlpoolPendingMutex.Unlock()
+	})
+
+	position := solana.MustSignatureFromBase58("4fzzuYxbcGiFih8SddNMWNYAMhMFUggoitEAZYCcT4z6wLDuWD7wPdb41cs8N5fKuz3hKzPhpawr7Jg9G35Kurzq")
+	openPositionLogs := []string{"Program log: Instruction: OpenPositionWithMetadata"}
+
+	if !whirlpoolLogFilter([]string{"Program log: Instruction: InitializePoolV2"}) {
+		t.Error("initialize_pool_v2 did not pass the filter")
+	}
+
+	// Positions on pools the monitor has not seen created are ignored
+	if whirlpoolLogFilter(openPositionLogs) {
+		t.Error("open position passed the filter without pending pools")
+	}
+	if info := parseTransaction(ctx, position); info != nil {
+		t.Fatalf("expected no pool, got %+v", info)
+	}
+
+	// The pool waits for its first position
+	if info := parseTransaction(ctx, solana.MustSignatureFromBase58("37wENFSCy4TBo4uZD1eT8xYCm8Q4aSF4p6zvCybh7rjmceqt6YLzhd8vBRTpkqh6LwCZ2LnR1fjujHt3iFLTj2JR")); info != nil {
+		t.Fatalf("expected pending pool, got %+v", info)
+	}
+	if pendingWhirlpools() != 1 || !whirlpoolLogFilter(openPositionLogs) {
+		t.Fatalf("pool not pending (%d), misses: %v", pendingWhirlpools(), replay.Misses())
+	}
+
+	info := parseTransaction(ctx, position)
+	if info == nil {
+		t.Fatal("info is nil")
+	}
+	if pendingWhirlpools() != 0 {
+		t.Error("pool still pending")
+	}
+
+	if ev := info.PoolEvent(); ev.Venue != events.VenueOrca || ev.Label() != "Orca Whirlpool" || info.Pool.String() != "D5WhC6g8KSKXyhE2gUAJXw8eR5YWbSBrGag3zstBbXdh" {
+		t.Errorf("unexpected pool %s (%s)", info.Pool, ev.Label())
+	}
+	// SOL is token A, the pair is turned around
+	if info.BaseMint.String() != "HiuhKbnPKCioArRFt8yyX2B7edbTzfaHRCQNXqyLn1dP" || info.QuoteMint != solana.WrappedSol || !info.Swapped {
+		t.Errorf("mints: got %s/%s", info.BaseMint, info.QuoteMint)
+	}
+	if info.BaseVault.String() != "GvE1u6E4q1szv33Cu7WUMisAWjhVz1h3M82dj3bnUc1m" || info.QuoteVault.String() != "D397x3EcSe35L1csLeWJUt1sMR7cdeaj7A7rzb2tFCAa" {
+		t.Errorf("vaults: got %s/%s", info.BaseVault, info.QuoteVault)
+	}
+	if info.BaseLiquidity != 100_000 || info.QuoteLiquidity != 10 {
+		t.Errorf("liquidity: got %v/%v", info.BaseLiquidity, info.QuoteLiquidity)
+	}
+	if info.LPMint.String() != "AFfetwwGHvY5wBtwxTtBHTnAhEzQvdQtC8etwQw5Jhkn" || info.LPAccount.String() != "7S6YoJsF3GHAPnWxBh6WwA72jUgDV9LE2tjnNCxqXLNe" {
+		t.Errorf("position: got %s/%s", info.LPMint, info.LPAccount)
+	}
+	if info.Caller.String() != "5BBLUX7SPiGJpqDYi8A8bZiGUTAASBHaRLC9XSeNVsZ2" || info.Slot != 268_000_070 {
+		t.Errorf("unexpected creation %d by %s", info.Slot, info.Caller)
+	}
+	if info.InitBaseAmount != 100_000_000_000 || info.InitQuoteAmount != 10_000_000_000 || info.PoolEvent().OpenTime.Unix() != 1717000100 {
+		t.Errorf("unexpected amounts %d/%d", info.InitBaseAmount, info.InitQuoteAmount)
+	}
+
+	clmm := info.Params
+	if clmm == nil {
+		t.Fatal("pool parameters missing")
+	}
+	if clmm.FeeTier.String() != "HRwRtRK2MNinDHNSuDrKJscgh3H1kwQxZCwKce3vBvam" || clmm.TickSpacing != 64 || clmm.FeeRate != 3000 || clmm.FeePercent() != "0.3%" {
+		t.Errorf("fee tier: got %s, %d / %d", clmm.FeeTier, clmm.TickSpacing, clmm.FeeRate)
+	}
+	if clmm.TickLower != -2048 || clmm.TickUpper != 2048 || clmm.PositionTxID != position {
+		t.Errorf("unexpected position %+v", clmm)
+	}
+	// 10 000 tokens per SOL
+	if math.Abs(clmm.Price-0.0001) > 1e-12 {
+		t.Errorf("price: got %v, want 0.0001", clmm.Price)
+	}
+}
+
+func Test_addPendingWhirlpool(t *testing.T) {
+	t.Cleanup(func() {
+		whirlpoolPendingMutex.Lock()
+		clear(whirlpoolPending)
+		whirlpoolPendingMutex.Unlock()
+	})
+
+	// As many pending pools as allowed, the first one waiting the longest
+	pools := make([]solana.PublicKey, pendingMax)
+	whirlpoolPendingMutex.Lock()
+	for i := range pools {
+		pools[i] = solana.NewWallet().PublicKey()
+		whirlpoolPending[pools[i]] = pendingWhirlpool{info: &WhirlpoolInfo{Pool: pools[i]}, added: time.Now().Add(time.Duration(i-pendingMax) * time.Second)}
+	}
+	whirlpoolPendingMutex.Unlock()
+
+	fresh := solana.NewWallet().PublicKey()
+	addPendingWhirlpool(&WhirlpoolInfo{Pool: fresh})
+
+	if pendingWhirlpools() != pendingMax {
+		t.Errorf("pending: got %d, want %d", pendingWhirlpools(), pendingMax)
+	}
+	if claimPendingWhirlpool(pools[0]) != nil {
+		t.Error("oldest pool kept")
+	}
+	if claimPendingWhirlpool(fresh) == nil || claimPendingWhirlpool(pools[1]) == nil {
+		t.Error("newer pool dropped")
+	}
+}
+
+func Test_parseWhirlpoolWithoutFeeTier(t *testing.T) {
+	ctx := context.Background()
+
+	// The fee tier account cannot be fetched
+	fixture, err := rpctest.Load("testdata/whirlpool_create.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, call := range fixture.Calls {
+		if call.Method == "getMultipleAccounts" {
+			fixture.Calls[i].Result, fixture.Calls[i].Error = nil, &jsonrpc.RPCError{Code: -32602, Message: "Invalid param"}
+		}
+	}
+	rpcs.SetClients(rpctest.NewReplay(fixture).Client())
+	t.Cleanup(func() {
+		rpcs.SetClients()
+		whirlpoolPendingMutex.Lock()
+		clear(whirlpoolPending)
+		whirlpoolPendingMutex.Unlock()
+	})
+	feeTierRatesMutex.Lock()
+	clear(feeTierRates)
+	feeTierRatesMutex.Unlock()
+
+	if info := parseTransaction(ctx, solana.MustSignatureFromBase58("37wENFSCy4TBo4uZD1eT8xYCm8Q4aSF4p6zvCybh7rjmceqt6YLzhd8vBRTpkqh6LwCZ2LnR1fjujHt3iFLTj2JR")); info != nil {
+		t.Fatalf("expected pending pool, got %+v", info)
+	}
+	pending := claimPendingWhirlpool(solana.MustPublicKeyFromBase58("D5WhC6g8KSKXyhE2gUAJXw8eR5YWbSBrGag3zstBbXdh"))
+	if pending == nil {
+		t.Fatal("pool not pending")
+	}
+
+	// The tick spacing is an argument of the instruction
+	if pending.Params.TickSpacing != 64 || pending.Params.FeeRate != 0 {
+		t.Errorf("got tick spacing %d, fee rate %d", pending.Params.TickSpacing, pending.Params.FeeRate)
+	}
+	if fields := pending.Params.Fields(); fields[0].Name != "Tick Spacing" || fields[0].Value != "64" {
+		t.Errorf("fields: got %+v", fields)
+	}
+}
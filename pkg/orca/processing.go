@@ -0,0 +1,85 @@
+package orca
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ProcessMessages parses every signature from rChn and forwards the funded pools to sendChn.
+// Once ctx is cancelled the remaining signatures are drained without being processed,
+// their number is returned.
+func ProcessMessages(ctx context.Context, rChn <-chan ingest.Event, sendChn chan<- *events.PoolEvent) int {
+	abandoned := 0
+
+	for msg := range rChn {
+		if ctx.Err() != nil {
+			abandoned++
+			continue
+		}
+
+		info := parseEvent(ctx, msg)
+		if info == nil {
+			continue
+		}
+
+		sendChn <- info.PoolEvent()
+	}
+
+	fmt.Printf("Orca processing out...\n")
+
+	return abandoned
+}
+
+// parseEvent uses the transaction delivered by the source, or fetches it if there is none.
+func parseEvent(ctx context.Context, ev ingest.Event) *WhirlpoolInfo {
+	if ev.Transaction == nil || ev.Tx == nil {
+		return parseTransaction(ctx, ev.Signature)
+	}
+
+	return parseResult(ctx, ev.Transaction, ev.Tx)
+}
+
+func parseTransaction(ctx context.Context, signature solana.Signature) *WhirlpoolInfo {
+	rpcTx, tx, err := utils.GetConfirmedTransaction_S(ctx, signature)
+	if err != nil {
+		fmt.Printf("Orca -> parseTransaction: %v\nhttps://solscan.io/tx/%s\n", err, signature.String())
+		return nil
+	}
+
+	return parseResult(ctx, rpcTx, tx)
+}
+
+// parseResult registers the pools created by tx and returns the first pending pool that
+// tx opens a position on.
+func parseResult(ctx context.Context, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) *WhirlpoolInfo {
+	if rpcTx.Meta.Err != nil {
+		return nil // Pool was never created.
+	}
+
+	keys := utils.AccountKeys(rpcTx, tx)
+
+	var opened *WhirlpoolInfo
+	for _, instr := range tx.Message.Instructions {
+		program, err := tx.Message.Program(instr.ProgramIDIndex)
+		if err != nil || program.String() != utils.WHIRLPOOL_PROGRAM_ID {
+			continue
+		}
+
+		if info := destructPool(ctx, instr, keys, rpcTx, tx); info != nil {
+			addPendingWhirlpool(info)
+			continue
+		}
+
+		if opened == nil {
+			opened = destructPosition(instr, keys, rpcTx, tx)
+		}
+	}
+
+	return opened
+}
@@ -0,0 +1,40 @@
+package orca
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Start forwards every Whirlpool candidate from src to ch until ctx is cancelled or the
+// subscription fails.
+func Start(ctx context.Context, src ingest.Source, ch chan<- ingest.Event) error {
+	fmt.Printf("Starting Orca monitor (%s)\n", src.Name())
+
+	return src.Subscribe(ctx, solana.MustPublicKeyFromBase58(utils.WHIRLPOOL_PROGRAM_ID), whirlpoolLogFilter, ch)
+}
+
+func whirlpoolLogFilter(logs []string) bool {
+	for _, log := range logs {
+		// Exact match, other instructions start with InitializePool as well
+		if log == utils.WHIRLPOOL_IDENTIFIER || log == utils.WHIRLPOOL_IDENTIFIER+"V2" {
+			return true
+		}
+	}
+
+	// Positions are opened all the time, they only matter while a pool waits for its first one
+	if pendingWhirlpools() == 0 {
+		return false
+	}
+	for _, log := range logs {
+		for _, identifier := range openPositionLogs {
+			if log == identifier {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,305 @@
+package orca
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// The pool initialisations only differ in the token badges and the bumps before the arguments.
+var initializePoolLayouts = []struct {
+	name          string
+	discriminator []byte
+	accountsLen   int
+	argsOffset    int // Start of tick_spacing u16, initial_sqrt_price u128
+	funder        int
+}{
+	{"initialize_pool", []byte{0x5f, 0xb4, 0x0a, 0xac, 0x54, 0xae, 0xe8, 0x28}, 11, 9, 3},
+	{"initialize_pool_v2", []byte{0xcf, 0x2d, 0x57, 0xf2, 0x1b, 0x3f, 0xcc, 0x43}, 14, 8, 5},
+}
+
+// Accounts of the pool initialisations, the same in both layouts
+const (
+	tokenMintAIndex = 1
+	tokenMintBIndex = 2
+)
+
+// Accounts of the pool initialisations relative to the funder
+const (
+	whirlpoolOffset = iota + 1
+	tokenVaultAOffset
+	tokenVaultBOffset
+	feeTierOffset
+)
+
+// The open position instructions only differ in the accounts around the pool and their bumps.
+var openPositionLayouts = []struct {
+	name          string
+	discriminator []byte
+	accountsLen   int
+	ticksOffset   int // Start of tick_lower_index i32, tick_upper_index i32
+	tokenAccount  int
+	pool          int
+}{
+	{"open_position", []byte{0x87, 0x80, 0x2f, 0x4d, 0x0f, 0x98, 0xf0, 0x31}, 10, 9, 4, 5},
+	{"open_position_with_metadata", []byte{0xf2, 0x1d, 0x86, 0x30, 0x3a, 0x6e, 0x0e, 0x3c}, 13, 10, 5, 6},
+	{"open_position_with_token_extensions", []byte{0xd4, 0x2f, 0x5f, 0x5c, 0x72, 0x66, 0x83, 0xfa}, 10, 8, 4, 5},
+}
+
+// The position NFT is the Whirlpool counterpart of the LP tokens.
+const positionMintIndex = 3
+
+var openPositionLogs = []string{
+	"Program log: Instruction: OpenPosition",
+	"Program log: Instruction: OpenPositionWithMetadata",
+	"Program log: Instruction: OpenPositionWithTokenExtensions",
+}
+
+// Pools are only reported once their first position is opened, the liquidity is usually
+// deposited in the same transaction. Created pools wait here for it, pools that are not
+// funded within pendingTTL are dropped.
+const (
+	pendingTTL = 30 * time.Minute
+	pendingMax = 1_000
+)
+
+type pendingWhirlpool struct {
+	info  *WhirlpoolInfo
+	added time.Time
+}
+
+var whirlpoolPending = make(map[solana.PublicKey]pendingWhirlpool)
+var whirlpoolPendingMutex = &sync.Mutex{}
+
+func addPendingWhirlpool(info *WhirlpoolInfo) {
+	whirlpoolPendingMutex.Lock()
+	defer whirlpoolPendingMutex.Unlock()
+
+	now := time.Now()
+	for pool, pending := range whirlpoolPending {
+		if now.Sub(pending.added) > pendingTTL {
+			delete(whirlpoolPending, pool)
+		}
+	}
+
+	// Still full, the pools waiting the longest are the least likely to be funded
+	for len(whirlpoolPending) >= pendingMax {
+		var oldest solana.PublicKey
+		for pool, pending := range whirlpoolPending {
+			if oldest.IsZero() || pending.added.Before(whirlpoolPending[oldest].added) {
+				oldest = pool
+			}
+		}
+		delete(whirlpoolPending, oldest)
+	}
+
+	whirlpoolPending[info.Pool] = pendingWhirlpool{info: info, added: now}
+}
+
+// claimPendingWhirlpool removes the pool from the pending pools, nil if it was not pending.
+func claimPendingWhirlpool(pool solana.PublicKey) *WhirlpoolInfo {
+	whirlpoolPendingMutex.Lock()
+	defer whirlpoolPendingMutex.Unlock()
+
+	pending, ok := whirlpoolPending[pool]
+	if !ok || time.Since(pending.added) > pendingTTL {
+		return nil
+	}
+	delete(whirlpoolPending, pool)
+
+	return pending.info
+}
+
+func pendingWhirlpools() int {
+	whirlpoolPendingMutex.Lock()
+	defer whirlpoolPendingMutex.Unlock()
+	return len(whirlpoolPending)
+}
+
+// destructPool decodes a pool initialisation, nil for any other instruction. The liquidity
+// is only known once a position is opened.
+func destructPool(ctx context.Context, instr solana.CompiledInstruction, keys solana.PublicKeySlice, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) *WhirlpoolInfo {
+	data := []byte(instr.Data)
+	if len(data) < 8 {
+		return nil
+	}
+
+	for _, layout := range initializePoolLayouts {
+		if !bytes.Equal(data[:8], layout.discriminator) {
+			continue
+		}
+
+		args := data[layout.argsOffset:]
+		if len(args) < 18 {
+			return nil
+		}
+		if len(instr.Accounts) < layout.accountsLen {
+			color.New(color.FgYellow).Printf("[ORCA] destructPool -> Required accounts length for %s not met (%d)\n", layout.name, len(instr.Accounts))
+			return nil
+		}
+
+		account := func(i int) solana.PublicKey {
+			return utils.InstrAccount(keys, instr, i)
+		}
+
+		info := &WhirlpoolInfo{
+			ProgramID:  solana.MustPublicKeyFromBase58(utils.WHIRLPOOL_PROGRAM_ID),
+			Pool:       account(layout.funder + whirlpoolOffset),
+			BaseMint:   account(tokenMintAIndex),
+			QuoteMint:  account(tokenMintBIndex),
+			BaseVault:  account(layout.funder + tokenVaultAOffset),
+			QuoteVault: account(layout.funder + tokenVaultBOffset),
+			Caller:     account(layout.funder),
+			TxID:       tx.Signatures[0],
+			Slot:       rpcTx.Slot,
+			TxTime:     utils.BlockTime(rpcTx),
+			Timestamp:  time.Now(),
+			Params: &PoolParams{
+				FeeTier:     account(layout.funder + feeTierOffset),
+				TickSpacing: binary.LittleEndian.Uint16(args),
+				SqrtPriceX64: bin.Uint128{
+					Lo: binary.LittleEndian.Uint64(args[2:]),
+					Hi: binary.LittleEndian.Uint64(args[10:]),
+				},
+			},
+		}
+		if info.Pool.IsZero() || info.BaseMint.IsZero() || info.QuoteMint.IsZero() {
+			return nil
+		}
+
+		feeRate, err := getFeeTierRate(ctx, info.Params.FeeTier)
+		if err != nil {
+			color.New(color.FgYellow).Printf("[ORCA] destructPool -> Failed to get fee tier %s: %v\n", info.Params.FeeTier, err)
+		} else {
+			info.Params.FeeRate = uint32(feeRate)
+		}
+
+		return info
+	}
+
+	return nil
+}
+
+// destructPosition completes a pending pool with the first position opened on it.
+func destructPosition(instr solana.CompiledInstruction, keys solana.PublicKeySlice, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) *WhirlpoolInfo {
+	data := []byte(instr.Data)
+
+	for _, layout := range openPositionLayouts {
+		if len(data) < layout.ticksOffset+8 || !bytes.Equal(data[:8], layout.discriminator) {
+			continue
+		}
+		if len(instr.Accounts) < layout.accountsLen {
+			color.New(color.FgYellow).Printf("[ORCA] destructPosition -> Required accounts length for %s not met (%d)\n", layout.name, len(instr.Accounts))
+			return nil
+		}
+
+		pending := claimPendingWhirlpool(utils.InstrAccount(keys, instr, layout.pool))
+		if pending == nil {
+			return nil // Not a new pool
+		}
+
+		info := *pending
+		params := *pending.Params
+		info.Params = &params
+
+		info.LPMint = utils.InstrAccount(keys, instr, positionMintIndex)
+		info.LPAccount = utils.InstrAccount(keys, instr, layout.tokenAccount)
+		info.Params.TickLower = int32(binary.LittleEndian.Uint32(data[layout.ticksOffset:]))
+		info.Params.TickUpper = int32(binary.LittleEndian.Uint32(data[layout.ticksOffset+4:]))
+		info.Params.PositionTxID = tx.Signatures[0]
+
+		// Deposited amounts, the vaults were empty before the first position
+		decimals := [2]uint8{}
+		for _, postBalance := range rpcTx.Meta.PostTokenBalances {
+			if int(postBalance.AccountIndex) >= len(keys) || postBalance.UiTokenAmount == nil {
+				continue
+			}
+
+			amount, err := strconv.ParseUint(postBalance.UiTokenAmount.Amount, 10, 64)
+			if err != nil {
+				continue
+			}
+			uiAmount := float64(amount) / math.Pow10(int(postBalance.UiTokenAmount.Decimals))
+
+			switch keys[postBalance.AccountIndex] {
+			case info.BaseVault:
+				info.BaseLiquidity = uiAmount
+				info.InitBaseAmount = amount
+				decimals[0] = postBalance.UiTokenAmount.Decimals
+			case info.QuoteVault:
+				info.QuoteLiquidity = uiAmount
+				info.InitQuoteAmount = amount
+				decimals[1] = postBalance.UiTokenAmount.Decimals
+			}
+		}
+
+		// Price of token A in token B
+		sqrtPrice := float64(info.Params.SqrtPriceX64.Hi) + float64(info.Params.SqrtPriceX64.Lo)/math.Pow(2, 64)
+		price := sqrtPrice * sqrtPrice * math.Pow10(int(decimals[0])-int(decimals[1]))
+
+		info.orient()
+
+		info.Params.Price = price
+		if info.Swapped && price > 0 {
+			info.Params.Price = 1 / price
+		}
+
+		if os.Getenv("DEBUG") == "1" {
+			color.New(color.FgBlue).Printf("[ORCA] Whirlpool %s funded by %s\n", info.Pool, info.Params.PositionTxID)
+		}
+
+		return &info
+	}
+
+	return nil
+}
+
+// Fee tiers are shared by all pools of a tick spacing, there are only a handful of them.
+var feeTierRates = make(map[solana.PublicKey]uint16)
+var feeTierRatesMutex = &sync.Mutex{}
+
+// Offset in the FeeTier account: discriminator, whirlpools_config, tick_spacing
+const feeTierDefaultFeeRateOffset = 8 + 32 + 2
+
+// getFeeTierRate returns the fee rate of the pools created with the fee tier, in hundredths
+// of a basis point.
+func getFeeTierRate(ctx context.Context, key solana.PublicKey) (uint16, error) {
+	feeTierRatesMutex.Lock()
+	rate, ok := feeTierRates[key]
+	feeTierRatesMutex.Unlock()
+	if ok {
+		return rate, nil
+	}
+
+	accounts, err := utils.GetMultipleAccounts_S(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if accounts[0] == nil {
+		return 0, rpc.ErrNotFound
+	}
+
+	data := accounts[0].Data.GetBinary()
+	if len(data) < feeTierDefaultFeeRateOffset+2 {
+		return 0, errors.New("fee tier account too short")
+	}
+	rate = binary.LittleEndian.Uint16(data[feeTierDefaultFeeRateOffset:])
+
+	feeTierRatesMutex.Lock()
+	feeTierRates[key] = rate
+	feeTierRatesMutex.Unlock()
+
+	return rate, nil
+}
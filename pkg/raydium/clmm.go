@@ -0,0 +1,386 @@
+package raydium
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Anchor discriminators of the CLMM instructions, sha256("global:<name>")[:8]
+var clmmCreatePoolDiscriminator = []byte{0xe9, 0x92, 0xd1, 0x8e, 0xcf, 0x68, 0x40, 0xbc}
+
+// Accounts of the CLMM create_pool instruction
+const (
+	clmmCreatorIndex          = 0
+	clmmAmmConfigIndex        = 1
+	clmmPoolStateIndex        = 2
+	clmmToken0MintIndex       = 3
+	clmmToken1MintIndex       = 4
+	clmmToken0VaultIndex      = 5
+	clmmToken1VaultIndex      = 6
+	clmmCreatePoolAccountsLen = 13
+)
+
+// The open position instructions only differ in the accounts around the pool.
+var clmmOpenPositionLayouts = []struct {
+	name          string
+	discriminator []byte
+	accountsLen   int
+	pool          int
+}{
+	{"open_position", []byte{0x87, 0x80, 0x2f, 0x4d, 0x0f, 0x98, 0xf0, 0x31}, 19, 5},
+	{"open_position_v2", []byte{0x4d, 0xb8, 0x4a, 0xd6, 0x70, 0x56, 0xf1, 0xc7}, 22, 5},
+	{"open_position_with_token22_nft", []byte{0x4d, 0xff, 0xae, 0x52, 0x7d, 0x1d, 0xc9, 0x2e}, 20, 4},
+}
+
+// The position NFT is the CLMM counterpart of the LP tokens.
+const (
+	clmmPositionNftMintIndex    = 2
+	clmmPositionNftAccountIndex = 3
+)
+
+var clmmOpenPositionLogs = []string{
+	"Program log: Instruction: OpenPosition",
+	"Program log: Instruction: OpenPositionV2",
+	"Program log: Instruction: OpenPositionWithToken22Nft",
+}
+
+// ClmmInfo is what a concentrated liquidity pool has on top of RaydiumInfo.
+type ClmmInfo struct {
+	AmmConfig    solana.PublicKey
+	SqrtPriceX64 bin.Uint128 // Initial sqrt price of token 1 per token 0, Q64.64
+	Price        float64     // Initial price of the base mint in the quote mint
+	TickSpacing  uint16      // 0 when the amm config could not be fetched
+	TradeFeeRate uint32      // In hundredths of a basis point, 2500 is 0.25%
+
+	// First position
+	TickLower    int32
+	TickUpper    int32
+	PositionTxID solana.Signature
+}
+
+// FeeTier formats the trade fee rate as a percentage.
+func (c *ClmmInfo) FeeTier() string {
+	return strconv.FormatFloat(float64(c.TradeFeeRate)/10_000, 'f', -1, 64) + "%"
+}
+
+func (c *ClmmInfo) Title() string {
+	return "Concentrated Liquidity"
+}
+
+func (c *ClmmInfo) Fields() []events.Field {
+	var fields []events.Field
+	if c.TickSpacing > 0 {
+		fields = append(fields, events.Field{Name: "Fee Tier", Value: c.FeeTier() + " (tick spacing " + strconv.Itoa(int(c.TickSpacing)) + ")"})
+	}
+	if c.Price > 0 {
+		fields = append(fields, events.Field{Name: "Initial Price", Value: strconv.FormatFloat(c.Price, 'g', 6, 64)})
+	}
+	return append(fields, events.Field{
+		Name:  "First Position",
+		Value: "Ticks " + strconv.Itoa(int(c.TickLower)) + " to " + strconv.Itoa(int(c.TickUpper)),
+		Link:  "https://solscan.io/tx/" + c.PositionTxID.String(),
+	})
+}
+
+// Pools are only reported once their first position deposits liquidity. Created pools
+// wait here for it, pools that are not funded within clmmPendingTTL are dropped.
+const (
+	clmmPendingTTL = 30 * time.Minute
+	clmmPendingMax = 1_000
+)
+
+type pendingClmmPool struct {
+	info  *RaydiumInfo
+	added time.Time
+}
+
+var clmmPending = make(map[solana.PublicKey]pendingClmmPool)
+var clmmPendingMutex = &sync.Mutex{}
+
+func addPendingClmm(info *RaydiumInfo) {
+	clmmPendingMutex.Lock()
+	defer clmmPendingMutex.Unlock()
+
+	now := time.Now()
+	for pool, pending := range clmmPending {
+		if now.Sub(pending.added) > clmmPendingTTL {
+			delete(clmmPending, pool)
+		}
+	}
+
+	// Still full, the pools waiting the longest are the least likely to be funded
+	for len(clmmPending) >= clmmPendingMax {
+		var oldest solana.PublicKey
+		for pool, pending := range clmmPending {
+			if oldest.IsZero() || pending.added.Before(clmmPending[oldest].added) {
+				oldest = pool
+			}
+		}
+		delete(clmmPending, oldest)
+	}
+
+	clmmPending[info.AmmID] = pendingClmmPool{info: info, added: now}
+}
+
+// claimPendingClmm removes the pool from the pending pools, nil if it was not pending.
+func claimPendingClmm(pool solana.PublicKey) *RaydiumInfo {
+	clmmPendingMutex.Lock()
+	defer clmmPendingMutex.Unlock()
+
+	pending, ok := clmmPending[pool]
+	if !ok || time.Since(pending.added) > clmmPendingTTL {
+		return nil
+	}
+	delete(clmmPending, pool)
+
+	return pending.info
+}
+
+func pendingClmmPools() int {
+	clmmPendingMutex.Lock()
+	defer clmmPendingMutex.Unlock()
+	return len(clmmPending)
+}
+
+func clmmLogFilter(logs []string) bool {
+	for _, log := range logs {
+		if log == utils.RAYDIUM_CLMM_IDENTIFIER {
+			return true
+		}
+	}
+
+	// Positions are opened all the time, they only matter while a pool waits for its first one
+	if pendingClmmPools() == 0 {
+		return false
+	}
+	for _, log := range logs {
+		for _, identifier := range clmmOpenPositionLogs {
+			if log == identifier {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseClmm registers the pools created by tx and returns the first pending pool that
+// tx opens a position on.
+func parseClmm(ctx context.Context, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) *RaydiumInfo {
+	keys := utils.AccountKeys(rpcTx, tx)
+
+	var opened *RaydiumInfo
+	for _, instr := range tx.Message.Instructions {
+		program, err := tx.Message.Program(instr.ProgramIDIndex)
+		if err != nil || program.String() != utils.RAYDIUM_CLMM_PROGRAM_ID {
+			continue
+		}
+
+		data := []byte(instr.Data)
+		if len(data) < 8 {
+			continue
+		}
+
+		if bytes.Equal(data[:8], clmmCreatePoolDiscriminator) {
+			if info := destructClmmPool(ctx, instr, keys, rpcTx, tx); info != nil {
+				addPendingClmm(info)
+			}
+			continue
+		}
+
+		if opened == nil {
+			opened = destructClmmPosition(instr, keys, rpcTx, tx)
+		}
+	}
+
+	return opened
+}
+
+// destructClmmPool decodes create_pool, the liquidity is only known once a position is opened.
+func destructClmmPool(ctx context.Context, instr solana.CompiledInstruction, keys solana.PublicKeySlice, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) *RaydiumInfo {
+	data := []byte(instr.Data)
+	if len(data) < 32 { // sqrt_price_x64 u128, open_time u64
+		return nil
+	}
+	if len(instr.Accounts) < clmmCreatePoolAccountsLen {
+		color.New(color.FgYellow).Printf("[RAYDIUM] destructClmmPool -> Required accounts length for instruction not met (%d)", len(instr.Accounts))
+		return nil
+	}
+
+	account := func(i int) solana.PublicKey {
+		return utils.InstrAccount(keys, instr, i)
+	}
+
+	info := &RaydiumInfo{
+		ProgramID:            solana.MustPublicKeyFromBase58(utils.RAYDIUM_CLMM_PROGRAM_ID),
+		PoolType:             PoolTypeClmm,
+		AmmID:                account(clmmPoolStateIndex),
+		BaseMint:             account(clmmToken0MintIndex),
+		QuoteMint:            account(clmmToken1MintIndex),
+		PoolCoinTokenAccount: account(clmmToken0VaultIndex),
+		PoolPcTokenAccount:   account(clmmToken1VaultIndex),
+		Caller:               account(clmmCreatorIndex),
+		TxID:                 tx.Signatures[0],
+		Slot:                 rpcTx.Slot,
+		TxTime:               utils.BlockTime(rpcTx),
+		Timestamp:            time.Now(),
+		Metadata: RaydiumMetadata{
+			OpenTime: binary.LittleEndian.Uint64(data[24:]),
+		},
+		Clmm: &ClmmInfo{
+			AmmConfig: account(clmmAmmConfigIndex),
+			SqrtPriceX64: bin.Uint128{
+				Lo: binary.LittleEndian.Uint64(data[8:]),
+				Hi: binary.LittleEndian.Uint64(data[16:]),
+			},
+		},
+	}
+	if info.AmmID.IsZero() || info.BaseMint.IsZero() || info.QuoteMint.IsZero() {
+		return nil
+	}
+
+	config, err := getClmmConfig(ctx, info.Clmm.AmmConfig)
+	if err != nil {
+		color.New(color.FgYellow).Printf("[RAYDIUM] destructClmmPool -> Failed to get amm config %s: %v\n", info.Clmm.AmmConfig, err)
+	} else {
+		info.Clmm.TickSpacing = config.tickSpacing
+		info.Clmm.TradeFeeRate = config.tradeFeeRate
+	}
+
+	return info
+}
+
+// destructClmmPosition completes a pending pool with the first position opened on it.
+func destructClmmPosition(instr solana.CompiledInstruction, keys solana.PublicKeySlice, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) *RaydiumInfo {
+	data := []byte(instr.Data)
+
+	for _, layout := range clmmOpenPositionLayouts {
+		// tick_lower_index i32, tick_upper_index i32, ...
+		if len(data) < 16 || !bytes.Equal(data[:8], layout.discriminator) {
+			continue
+		}
+		if len(instr.Accounts) < layout.accountsLen {
+			color.New(color.FgYellow).Printf("[RAYDIUM] destructClmmPosition -> Required accounts length for %s not met (%d)", layout.name, len(instr.Accounts))
+			return nil
+		}
+
+		pending := claimPendingClmm(utils.InstrAccount(keys, instr, layout.pool))
+		if pending == nil {
+			return nil // Not a new pool
+		}
+
+		info := *pending
+		clmm := *pending.Clmm
+		info.Clmm = &clmm
+
+		info.LPTokenAddress = utils.InstrAccount(keys, instr, clmmPositionNftMintIndex)
+		info.AmmLiquidityCreator = utils.InstrAccount(keys, instr, clmmPositionNftAccountIndex)
+		info.Clmm.TickLower = int32(binary.LittleEndian.Uint32(data[8:]))
+		info.Clmm.TickUpper = int32(binary.LittleEndian.Uint32(data[12:]))
+		info.Clmm.PositionTxID = tx.Signatures[0]
+
+		// Deposited amounts, the vaults were empty before the first position
+		decimals := [2]uint8{}
+		for _, postBalance := range rpcTx.Meta.PostTokenBalances {
+			if int(postBalance.AccountIndex) >= len(keys) || postBalance.UiTokenAmount == nil {
+				continue
+			}
+
+			amount, err := strconv.ParseUint(postBalance.UiTokenAmount.Amount, 10, 64)
+			if err != nil {
+				continue
+			}
+			uiAmount := float64(amount) / math.Pow10(int(postBalance.UiTokenAmount.Decimals))
+
+			switch keys[postBalance.AccountIndex] {
+			case info.PoolCoinTokenAccount:
+				info.BaseMintLiquidity = uiAmount
+				info.Metadata.InitCoinAmount = amount
+				decimals[0] = postBalance.UiTokenAmount.Decimals
+			case info.PoolPcTokenAccount:
+				info.QuoteMintLiquidity = uiAmount
+				info.Metadata.InitPcAmount = amount
+				decimals[1] = postBalance.UiTokenAmount.Decimals
+			}
+		}
+
+		// Price of token 0 in token 1
+		sqrtPrice := float64(info.Clmm.SqrtPriceX64.Hi) + float64(info.Clmm.SqrtPriceX64.Lo)/math.Pow(2, 64)
+		price := sqrtPrice * sqrtPrice * math.Pow10(int(decimals[0])-int(decimals[1]))
+
+		OrientPair(&info)
+
+		info.Clmm.Price = price
+		if info.Swapped && price > 0 {
+			info.Clmm.Price = 1 / price
+		}
+
+		if os.Getenv("DEBUG") == "1" {
+			color.New(color.FgBlue).Printf("[RAYDIUM] CLMM pool %s funded by %s\n", info.AmmID, info.Clmm.PositionTxID)
+		}
+
+		return &info
+	}
+
+	return nil
+}
+
+type clmmConfig struct {
+	tickSpacing  uint16
+	tradeFeeRate uint32
+}
+
+// Amm configs are shared by all pools of a fee tier, there are only a handful of them.
+var clmmConfigs = make(map[solana.PublicKey]clmmConfig)
+var clmmConfigsMutex = &sync.Mutex{}
+
+// Offsets in the AmmConfig account: discriminator, bump, index, owner, protocol_fee_rate
+const (
+	clmmConfigTradeFeeRateOffset = 8 + 1 + 2 + 32 + 4
+	clmmConfigTickSpacingOffset  = clmmConfigTradeFeeRateOffset + 4
+)
+
+func getClmmConfig(ctx context.Context, key solana.PublicKey) (clmmConfig, error) {
+	clmmConfigsMutex.Lock()
+	config, ok := clmmConfigs[key]
+	clmmConfigsMutex.Unlock()
+	if ok {
+		return config, nil
+	}
+
+	accounts, err := utils.GetMultipleAccounts_S(ctx, key)
+	if err != nil {
+		return clmmConfig{}, err
+	}
+	if accounts[0] == nil {
+		return clmmConfig{}, rpc.ErrNotFound
+	}
+
+	data := accounts[0].Data.GetBinary()
+	if len(data) < clmmConfigTickSpacingOffset+2 {
+		return clmmConfig{}, errors.New("amm config account too short")
+	}
+	config = clmmConfig{
+		tradeFeeRate: binary.LittleEndian.Uint32(data[clmmConfigTradeFeeRateOffset:]),
+		tickSpacing:  binary.LittleEndian.Uint16(data[clmmConfigTickSpacingOffset:]),
+	}
+
+	clmmConfigsMutex.Lock()
+	clmmConfigs[key] = config
+	clmmConfigsMutex.Unlock()
+
+	return config, nil
+}
@@ -0,0 +1,102 @@
+package raydium
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Anchor discriminator of the CPMM initialize instruction, sha256("global:initialize")[:8]
+var cpmmInitializeDiscriminator = []byte{0xaf, 0xaf, 0x6d, 0x1f, 0x0d, 0x98, 0x9b, 0xed}
+
+// Accounts of the CPMM initialize instruction
+const (
+	cpmmCreatorIndex     = 0
+	cpmmPoolStateIndex   = 3
+	cpmmToken0MintIndex  = 4
+	cpmmToken1MintIndex  = 5
+	cpmmLpMintIndex      = 6
+	cpmmCreatorLpIndex   = 9
+	cpmmToken0VaultIndex = 10
+	cpmmToken1VaultIndex = 11
+	cpmmAccountsLen      = 20
+)
+
+func cpmmLogFilter(logs []string) bool {
+	for _, log := range logs {
+		// Exact match, the token program logs InitializeAccount and InitializeMint
+		if log == utils.RAYDIUM_CPMM_IDENTIFIER {
+			return true
+		}
+	}
+	return false
+}
+
+// destructCpmmInfo fills info from a CPMM initialize instruction. The CPMM pool has no
+// openbook market, token 0 and 1 are ordered by mint address and either may be a Token-2022 mint.
+func destructCpmmInfo(instr solana.CompiledInstruction, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction, info *RaydiumInfo) bool {
+	data := []byte(instr.Data)
+	if len(data) < 32 || !bytes.Equal(data[:8], cpmmInitializeDiscriminator) {
+		return false // Swap, deposit, withdraw...
+	}
+
+	if len(instr.Accounts) < cpmmAccountsLen {
+		color.New(color.FgYellow).Printf("[RAYDIUM] destructCpmmInfo -> Required accounts length for instruction not met (%d)", len(instr.Accounts))
+		return false
+	}
+
+	keys := utils.AccountKeys(rpcTx, tx)
+	account := func(i int) solana.PublicKey {
+		return utils.InstrAccount(keys, instr, i)
+	}
+
+	info.PoolType = PoolTypeCpmm
+	info.AmmID = account(cpmmPoolStateIndex)
+	info.LPTokenAddress = account(cpmmLpMintIndex)
+	info.BaseMint = account(cpmmToken0MintIndex)
+	info.QuoteMint = account(cpmmToken1MintIndex)
+	info.PoolCoinTokenAccount = account(cpmmToken0VaultIndex)
+	info.PoolPcTokenAccount = account(cpmmToken1VaultIndex)
+	info.AmmLiquidityCreator = account(cpmmCreatorLpIndex)
+
+	// The vaults are owned by a PDA of the program, so match them by account
+	for _, postBalance := range rpcTx.Meta.PostTokenBalances {
+		if int(postBalance.AccountIndex) >= len(keys) || postBalance.UiTokenAmount == nil || postBalance.UiTokenAmount.UiAmount == nil {
+			continue
+		}
+
+		switch keys[postBalance.AccountIndex] {
+		case info.PoolCoinTokenAccount:
+			info.BaseMintLiquidity = *postBalance.UiTokenAmount.UiAmount
+		case info.PoolPcTokenAccount:
+			info.QuoteMintLiquidity = *postBalance.UiTokenAmount.UiAmount
+		}
+	}
+
+	if info.BaseMint.IsZero() || info.QuoteMint.IsZero() {
+		return false
+	}
+
+	// init_amount_0, init_amount_1, open_time
+	info.Metadata = RaydiumMetadata{
+		InitCoinAmount: binary.LittleEndian.Uint64(data[8:]),
+		InitPcAmount:   binary.LittleEndian.Uint64(data[16:]),
+		OpenTime:       binary.LittleEndian.Uint64(data[24:]),
+	}
+
+	OrientPair(info)
+
+	info.Caller = account(cpmmCreatorIndex)
+	info.TxID = tx.Signatures[0]
+
+	info.Slot = rpcTx.Slot
+	info.TxTime = utils.BlockTime(rpcTx)
+	info.Timestamp = time.Now()
+
+	return true
+}
@@ -0,0 +1,44 @@
+package raydium
+
+import (
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+)
+
+// PoolEvent converts the pool into the venue independent event, the venue specific
+// information is attached as extras.
+func (info *RaydiumInfo) PoolEvent() *events.PoolEvent {
+	ev := &events.PoolEvent{
+		Venue:          events.VenueRaydium,
+		Kind:           info.PoolType.Label(),
+		Origin:         string(info.Origin),
+		ProgramID:      info.ProgramID,
+		Pool:           info.AmmID,
+		BaseMint:       info.BaseMint,
+		QuoteMint:      info.QuoteMint,
+		BaseVault:      info.PoolCoinTokenAccount,
+		QuoteVault:     info.PoolPcTokenAccount,
+		LPMint:         info.LPTokenAddress,
+		LPAccount:      info.AmmLiquidityCreator,
+		BaseLiquidity:  info.BaseMintLiquidity,
+		QuoteLiquidity: info.QuoteMintLiquidity,
+		OpenTime:       time.Unix(int64(info.Metadata.OpenTime), 0),
+		Creator:        info.Creator(),
+		TxID:           info.TxID,
+		Slot:           info.Slot,
+		TxTime:         info.TxTime,
+		Timestamp:      info.Timestamp,
+		Source:         info,
+	}
+
+	// Nil pointers would end up as non-nil interfaces
+	if info.Pumpfun != nil {
+		ev.Extras = append(ev.Extras, info.Pumpfun)
+	}
+	if info.Clmm != nil {
+		ev.Extras = append(ev.Extras, info.Clmm)
+	}
+
+	return ev
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
 	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
 	"github.com/fatih/color"
 	"github.com/gagliardetto/solana-go"
@@ -19,18 +21,50 @@ type RaydiumMetadata struct {
 	InitCoinAmount uint64 `json:"init_coin_amount"`
 }
 
+// PoolType is the Raydium program that created a pool.
+type PoolType string
+
+const (
+	PoolTypeAmmV4 PoolType = "AMM v4"
+	PoolTypeCpmm  PoolType = "CPMM"
+	PoolTypeClmm  PoolType = "CLMM"
+)
+
+// PoolTypeOf returns the pool type created by program, empty for other programs.
+func PoolTypeOf(program solana.PublicKey) PoolType {
+	switch program.String() {
+	case utils.RAYDIUM_PROGRAM_ID:
+		return PoolTypeAmmV4
+	case utils.RAYDIUM_CPMM_PROGRAM_ID:
+		return PoolTypeCpmm
+	case utils.RAYDIUM_CLMM_PROGRAM_ID:
+		return PoolTypeClmm
+	}
+	return ""
+}
+
+// Label names the pool type in notifications, empty for AMM v4 pools.
+func (t PoolType) Label() string {
+	if t == PoolTypeAmmV4 || t == "" {
+		return ""
+	}
+	return string(t)
+}
+
 type RaydiumInfo struct {
 	// Initialize Market Instruction Data
-	ProgramID            solana.PublicKey // always raydium
+	ProgramID            solana.PublicKey // raydium AMM v4, CPMM or CLMM
+	PoolType             PoolType
 	AmmID                solana.PublicKey // Amm ID (Pair Address)
 	AmmOpenOrders        solana.PublicKey // Amm Open Orders (PoolQuoteTokenAccount)
-	LPTokenAddress       solana.PublicKey // LPToken Address (PoolTokenMint)
+	LPTokenAddress       solana.PublicKey // LPToken Address (PoolTokenMint), the position NFT mint for CLMM
 	BaseMint             solana.PublicKey // base mint address (Token Address)
 	QuoteMint            solana.PublicKey // quote mint address (Currency Address)
 	PoolCoinTokenAccount solana.PublicKey // Amm Token Account (PoolCoinTokenAccount)
 	PoolPcTokenAccount   solana.PublicKey // Amm WSOL Token Account (PoolPcTokenAccount)
 	AmmTargetOrders      solana.PublicKey // Amm Target Orders
 	AmmLiquidityCreator  solana.PublicKey // Amm Liquidity Creator (aka account of LP creator that will receive LP tokens)
+	SerumMarket          solana.PublicKey // Openbook market the pool trades on, only set for AMM v4
 
 	BaseMintLiquidity  float64
 	QuoteMintLiquidity float64
@@ -44,21 +78,45 @@ type RaydiumInfo struct {
 	Swapped   bool             // Whether the pair was created in reverse order.
 
 	Metadata RaydiumMetadata
+
+	Clmm *ClmmInfo // Only set for CLMM pools
+
+	Origin  Origin       // Launchpad the pool was migrated from
+	Pumpfun *PumpfunInfo // Only set for pump.fun migrations, Caller is then the migration authority
 }
 
-func ProcessMessages(rChn <-chan solana.Signature, sendChn chan<- *RaydiumInfo) {
-	ctx := context.Background()
+// ProcessMessages parses every signature from rChn and forwards the detected pools to sendChn.
+// Once ctx is cancelled the remaining signatures are drained without being processed,
+// their number is returned.
+func ProcessMessages(ctx context.Context, rChn <-chan ingest.Event, sendChn chan<- *events.PoolEvent) int {
+	abandoned := 0
 
 	for msg := range rChn {
-		info := parseTransaction(ctx, msg)
+		if ctx.Err() != nil {
+			abandoned++
+			continue
+		}
+
+		info := parseEvent(ctx, msg)
 		if info == nil {
 			continue
 		}
 
-		sendChn <- info
+		sendChn <- info.PoolEvent()
 	}
 
 	fmt.Printf("Raydium processing out...\n")
+
+	return abandoned
+}
+
+// parseEvent uses the transaction delivered by the source, or fetches it if there is none.
+func parseEvent(ctx context.Context, ev ingest.Event) *RaydiumInfo {
+	if ev.Transaction == nil || ev.Tx == nil {
+		return parseTransaction(ctx, ev.Signature)
+	}
+
+	return parseResult(ctx, ev.Transaction, ev.Tx)
 }
 
 func parseTransaction(ctx context.Context, signature solana.Signature) *RaydiumInfo {
@@ -68,6 +126,10 @@ func parseTransaction(ctx context.Context, signature solana.Signature) *RaydiumI
 		return nil
 	}
 
+	return parseResult(ctx, rpcTx, tx)
+}
+
+func parseResult(ctx context.Context, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) *RaydiumInfo {
 	if rpcTx.Meta.Err != nil {
 		// fmt.Printf("Transaction failed: %v\nhttps://solscan.io/tx/%s\n", rpcTx.Meta.Err, signature)
 		return nil
@@ -79,18 +141,16 @@ func parseTransaction(ctx context.Context, signature solana.Signature) *RaydiumI
 			continue // Program account index out of range.
 		}
 
-		if program.String() != utils.RAYDIUM_PROGRAM_ID {
-			continue // Not called by raydium.
-		}
-
-		// Now we know this is a raydium market.
-
 		minfo := RaydiumInfo{
 			ProgramID: program,
 		}
-		wasFound := destructInfo(instr, rpcTx, tx, &minfo)
 
-		if wasFound {
+		switch program.String() {
+		case utils.RAYDIUM_PROGRAM_ID:
+			if !destructInfo(instr, rpcTx, tx, &minfo) {
+				continue
+			}
+
 			metadata := GetMetadata(rpcTx.Meta.LogMessages)
 			bytes, err := metadata.MarshalJSON()
 			if err != nil {
@@ -102,33 +162,49 @@ func parseTransaction(ctx context.Context, signature solana.Signature) *RaydiumI
 			if err != nil {
 				return nil
 			}
-
-			tsUnix := uint64(minfo.Timestamp.Unix())
-			if metadataStruct.OpenTime < tsUnix {
-				metadataStruct.OpenTime = tsUnix
+			minfo.Metadata = metadataStruct
+			if minfo.Swapped {
+				// Read after the pair was oriented
+				minfo.Metadata.InitCoinAmount, minfo.Metadata.InitPcAmount = minfo.Metadata.InitPcAmount, minfo.Metadata.InitCoinAmount
+			}
+		case utils.RAYDIUM_CPMM_PROGRAM_ID:
+			if !destructCpmmInfo(instr, rpcTx, tx, &minfo) {
+				continue
 			}
+		case utils.RAYDIUM_CLMM_PROGRAM_ID:
+			// Pool creation and the first position may be separate transactions
+			clmm := parseClmm(ctx, rpcTx, tx)
+			if clmm == nil {
+				return nil
+			}
+			minfo = *clmm
+		default:
+			continue // Not called by raydium.
+		}
 
-			minfo.Metadata = metadataStruct
+		// Now we know this is a raydium pool.
+
+		minfo.Origin = OriginOf(minfo.Caller)
+		if minfo.Origin == OriginPumpfun {
+			minfo.Pumpfun = pumpfunCurve(minfo.BaseMint)
+		}
 
-			return &minfo
+		tsUnix := uint64(minfo.Timestamp.Unix())
+		if minfo.Metadata.OpenTime < tsUnix {
+			minfo.Metadata.OpenTime = tsUnix
 		}
+
+		return &minfo
 	}
 
 	return nil
 }
 
 func destructInfo(instr solana.CompiledInstruction, rpcTx *rpc.GetTransactionResult, tx *solana.Transaction, info *RaydiumInfo) bool {
-	safeIndex := func(idx uint16) solana.PublicKey {
-		if idx >= uint16(len(tx.Message.AccountKeys)) {
-			return solana.PublicKey{}
-		}
-		return tx.Message.AccountKeys[idx]
-	}
-
 	const BaseMintIndex = 8
 	const QuoteMinIndex = 9
 
-	// if safeIndex(instr.Accounts[QuoteMinIndex]) != solana.WrappedSol && safeIndex(instr.Accounts[BaseMintIndex]) != solana.WrappedSol {
+	// if account(QuoteMinIndex) != solana.WrappedSol && account(BaseMintIndex) != solana.WrappedSol {
 	// 	color.New(color.FgYellow).Println("Raydium: found raydium market, but not with SOL currency")
 	// 	return false
 	// }
@@ -138,15 +214,23 @@ func destructInfo(instr solana.CompiledInstruction, rpcTx *rpc.GetTransactionRes
 		return false
 	}
 
-	info.AmmID = safeIndex(instr.Accounts[4])
-	info.AmmOpenOrders = safeIndex(instr.Accounts[6])
-	info.LPTokenAddress = safeIndex(instr.Accounts[7])
-	info.BaseMint = safeIndex(instr.Accounts[BaseMintIndex])
-	info.QuoteMint = safeIndex(instr.Accounts[QuoteMinIndex])
-	info.PoolCoinTokenAccount = safeIndex(instr.Accounts[10])
-	info.PoolPcTokenAccount = safeIndex(instr.Accounts[11])
-	info.AmmTargetOrders = safeIndex(instr.Accounts[12])
-	info.AmmLiquidityCreator = safeIndex(instr.Accounts[20])
+	// The market and vaults may be loaded from lookup tables
+	keys := utils.AccountKeys(rpcTx, tx)
+	account := func(i int) solana.PublicKey {
+		return utils.InstrAccount(keys, instr, i)
+	}
+
+	info.PoolType = PoolTypeAmmV4
+	info.AmmID = account(4)
+	info.AmmOpenOrders = account(6)
+	info.LPTokenAddress = account(7)
+	info.BaseMint = account(BaseMintIndex)
+	info.QuoteMint = account(QuoteMinIndex)
+	info.PoolCoinTokenAccount = account(10)
+	info.PoolPcTokenAccount = account(11)
+	info.AmmTargetOrders = account(12)
+	info.SerumMarket = account(16)
+	info.AmmLiquidityCreator = account(20)
 
 	// Loop through posttokenbalances, find where owner is the raydium auth (5Q544fKrFoe6tsEbD7S8EmxGTJYAKtTVhAW5Q5pge4j1) and get the amount
 	for _, postBalance := range rpcTx.Meta.PostTokenBalances {
@@ -165,24 +249,26 @@ func destructInfo(instr solana.CompiledInstruction, rpcTx *rpc.GetTransactionRes
 		return false
 	}
 
-	if info.BaseMint == solana.WrappedSol {
-		info.BaseMint, info.QuoteMint = info.QuoteMint, info.BaseMint
-		info.BaseMintLiquidity, info.QuoteMintLiquidity = info.QuoteMintLiquidity, info.BaseMintLiquidity
-		info.PoolCoinTokenAccount, info.PoolPcTokenAccount = info.PoolPcTokenAccount, info.PoolCoinTokenAccount
-		info.Swapped = true
-	} else if info.BaseMint == utils.USDC_MINT_PUBKEY {
-		info.BaseMint, info.QuoteMint = info.QuoteMint, info.BaseMint
-		info.BaseMintLiquidity, info.QuoteMintLiquidity = info.QuoteMintLiquidity, info.BaseMintLiquidity
-		info.PoolCoinTokenAccount, info.PoolPcTokenAccount = info.PoolPcTokenAccount, info.PoolCoinTokenAccount
-		info.Swapped = true
-	}
+	OrientPair(info)
 
 	info.Caller = tx.Message.AccountKeys[0] // Should be ok, but not sure.
 	info.TxID = tx.Signatures[0]
 
 	info.Slot = rpcTx.Slot
-	info.TxTime = rpcTx.BlockTime.Time()
+	info.TxTime = utils.BlockTime(rpcTx)
 	info.Timestamp = time.Now()
 
 	return true
 }
+
+// OrientPair makes SOL or USDC the quote of the pair, the liquidity, vaults and initial
+// amounts follow the mints.
+func OrientPair(info *RaydiumInfo) {
+	if utils.IsQuoteMint(info.BaseMint) {
+		info.BaseMint, info.QuoteMint = info.QuoteMint, info.BaseMint
+		info.BaseMintLiquidity, info.QuoteMintLiquidity = info.QuoteMintLiquidity, info.BaseMintLiquidity
+		info.PoolCoinTokenAccount, info.PoolPcTokenAccount = info.PoolPcTokenAccount, info.PoolCoinTokenAccount
+		info.Metadata.InitCoinAmount, info.Metadata.InitPcAmount = info.Metadata.InitPcAmount, info.Metadata.InitCoinAmount
+		info.Swapped = true
+	}
+}
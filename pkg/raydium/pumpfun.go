@@ -0,0 +1,144 @@
+package raydium
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Origin is the launchpad a pool was migrated from, empty for pools created directly.
+type Origin string
+
+const OriginPumpfun Origin = "pump.fun"
+
+// Curves created since the creator was added store it after the reserves, supply and complete flag.
+const (
+	bondingCurveCreatorOffset = 8 + 5*8 + 1
+	curveSignaturesPageSize   = 1000
+	curveSignaturesMaxPages   = 10
+)
+
+// PumpfunInfo describes the bonding curve a migrated pool graduated from.
+type PumpfunInfo struct {
+	BondingCurve   solana.PublicKey
+	Creator        solana.PublicKey // Creator of the token, zero when unknown
+	CreatedAt      time.Time        // Launch of the token, zero when unknown
+	TimeToGraduate time.Duration    // From launch to migration, zero when unknown
+}
+
+func (p *PumpfunInfo) Title() string {
+	return "Pump.fun"
+}
+
+func (p *PumpfunInfo) Fields() []events.Field {
+	fields := []events.Field{{
+		Name:  "Bonding Curve",
+		Value: p.BondingCurve.String(),
+		Link:  "https://solscan.io/account/" + p.BondingCurve.String(),
+	}}
+	if !p.CreatedAt.IsZero() {
+		fields = append(fields,
+			events.Field{Name: "Launched", Value: p.CreatedAt.UTC().Format("2006-01-02 15:04 UTC")},
+			events.Field{Name: "Graduated in", Value: utils.DurtS(p.TimeToGraduate)},
+		)
+	}
+	return fields
+}
+
+// OriginOf returns the origin of a pool created by caller.
+func OriginOf(caller solana.PublicKey) Origin {
+	if caller.String() == utils.PUMPFUN_MIGRATION_ID {
+		return OriginPumpfun
+	}
+	return ""
+}
+
+// Creator returns the creator of the token for migrated pools, the caller otherwise.
+func (info *RaydiumInfo) Creator() solana.PublicKey {
+	if info.Pumpfun != nil && !info.Pumpfun.Creator.IsZero() {
+		return info.Pumpfun.Creator
+	}
+	return info.Caller
+}
+
+// pumpfunCurve returns the bonding curve a migrated mint graduated from. The creator and
+// launch are left to ResolvePumpfun_S, walking the history of the curve is too slow for
+// the parser.
+func pumpfunCurve(mint solana.PublicKey) *PumpfunInfo {
+	curve, _, err := solana.FindProgramAddress([][]byte{[]byte("bonding-curve"), mint.Bytes()}, solana.MustPublicKeyFromBase58(utils.PUMPFUN_PROGRAM_ID))
+	if err != nil {
+		return nil
+	}
+	return &PumpfunInfo{BondingCurve: curve}
+}
+
+// ResolvePumpfun_S finds the creator and launch of the token of the bonding curve. What was
+// found is kept when the history cannot be read.
+func ResolvePumpfun_S(ctx context.Context, info *PumpfunInfo, migratedAt time.Time) error {
+	accounts, err := utils.GetMultipleAccounts_S(ctx, info.BondingCurve)
+	if err == nil && accounts[0] != nil {
+		if data := accounts[0].Data.GetBinary(); len(data) >= bondingCurveCreatorOffset+32 {
+			info.Creator = solana.PublicKeyFromBytes(data[bondingCurveCreatorOffset : bondingCurveCreatorOffset+32])
+		}
+	}
+
+	launch, err := findCurveCreation_S(ctx, info.BondingCurve)
+	if err != nil {
+		return fmt.Errorf("failed to find launch of %s: %w", info.BondingCurve, err)
+	}
+
+	if launch.BlockTime != nil {
+		info.CreatedAt = launch.BlockTime.Time()
+		if !migratedAt.IsZero() {
+			info.TimeToGraduate = migratedAt.Sub(info.CreatedAt)
+		}
+	}
+
+	// Older curves do not store the creator, it signed the launch
+	if info.Creator.IsZero() {
+		_, tx, err := utils.GetConfirmedTransaction_S(ctx, launch.Signature)
+		if err == nil && tx != nil && len(tx.Message.AccountKeys) > 0 {
+			info.Creator = tx.Message.AccountKeys[0]
+		}
+	}
+
+	return nil
+}
+
+// findCurveCreation_S pages back through the history of the bonding curve, the oldest
+// signature is the launch of the token.
+func findCurveCreation_S(ctx context.Context, curve solana.PublicKey) (*rpc.TransactionSignature, error) {
+	var oldest *rpc.TransactionSignature
+
+	for page := 0; page < curveSignaturesMaxPages; page++ {
+		var before solana.Signature
+		if oldest != nil {
+			before = oldest.Signature
+		}
+
+		signatures, err := utils.GetSignaturesForAddress_S(ctx, curve, before, curveSignaturesPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(signatures) == 0 {
+			if oldest == nil {
+				return nil, errors.New("no transactions")
+			}
+			return oldest, nil
+		}
+
+		oldest = signatures[len(signatures)-1]
+		if len(signatures) < curveSignaturesPageSize {
+			return oldest, nil
+		}
+	}
+
+	return nil, fmt.Errorf("history exceeds %d signatures", curveSignaturesMaxPages*curveSignaturesPageSize)
+}
@@ -2,17 +2,430 @@ package raydium
 
 import (
 	"context"
+	"math"
+	"os"
 	"testing"
+	"time"
 
+	"github.com/OnlyF0uR/solana-monitor/pkg/events"
 	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs"
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs/rpctest"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
 	"github.com/gagliardetto/solana-go"
 )
 
 func Test_parseTransaction(t *testing.T) {
 	ctx := context.Background()
 
+	rpctest.Use(t, "testdata/*.json")
+
+	tests := []struct {
+		fixture   string
+		signature string
+		want      *RaydiumInfo // nil when the transaction must be ignored
+	}{
+		{
+			fixture:   "sol_quote",
+			signature: "2sHeVzWgx4hqyNmheFBFcFirKxbhmmCrhG295mpVJ3qP5iUXFCcBeiGSvv789nm6G7Jg8sF7VbYPjh9ToJwUYC4o",
+			want: &RaydiumInfo{
+				BaseMint:           solana.MustPublicKeyFromBase58("ApgjNKpPxbDVfAMSDRR95MDccBpKG8eXMzYVwFPopGRp"),
+				QuoteMint:          solana.WrappedSol,
+				BaseMintLiquidity:  800_000_000,
+				QuoteMintLiquidity: 50,
+				Slot:               268_000_009,
+				Metadata:           RaydiumMetadata{Nonce: 254, InitPcAmount: 50_000_000_000, InitCoinAmount: 800_000_000_000_000},
+			},
+		},
+		{
+			fixture:   "sol_base_swapped",
+			signature: "2pubCrBoo58NtU2xQTWKeXgnRhmri4SwTHYZaPuJbUWMwS8T9LKuE6PZYqKoK67jcoMu4eZAXt52tM36cH6JRanW",
+			want: &RaydiumInfo{
+				BaseMint:           solana.MustPublicKeyFromBase58("H9CchCYDHVz9pphZdrxdNoK9BCRAiCHWJuCxyLnZCmM9"),
+				QuoteMint:          solana.WrappedSol,
+				BaseMintLiquidity:  1_000_000_000,
+				QuoteMintLiquidity: 20,
+				Slot:               268_000_016,
+				Swapped:            true,
+				Metadata:           RaydiumMetadata{Nonce: 254, InitPcAmount: 20_000_000_000, InitCoinAmount: 1_000_000_000_000_000_000},
+			},
+		},
+		{
+			fixture:   "usdc_quote",
+			signature: "3yKAgrG2gM2oyakQeJ3GUepJ834RaTczyadMCKepEav2aentZC5MK26or4YV3kwznqDF2zy7TAJ5SpCQ2xcHkdzz",
+			want: &RaydiumInfo{
+				BaseMint:           solana.MustPublicKeyFromBase58("8dMUMkhmTnJP6WBKTYbq3u4q67oKLeKWBTp1f3dQDBmX"),
+				QuoteMint:          utils.USDC_MINT_PUBKEY,
+				BaseMintLiquidity:  500_000,
+				QuoteMintLiquidity: 10_000,
+				Slot:               268_000_010,
+				Metadata:           RaydiumMetadata{Nonce: 254, InitPcAmount: 10_000_000_000, InitCoinAmount: 500_000_000_000},
+			},
+		},
+		{
+			fixture:   "usdc_base_swapped",
+			signature: "24zRcvfXNPTe5Dd4LF9mhZiRxtztVaTkbThjDNfVZpkqTUfPZ26u2jCpH6JuK1eBaQdbAia4s1TwWr8VbUkG6MUX",
+			want: &RaydiumInfo{
+				BaseMint:           solana.MustPublicKeyFromBase58("4R2PaWE7KCTMe2v6dsePoJb7osn4MgwnbmJA47ZDZY8X"),
+				QuoteMint:          utils.USDC_MINT_PUBKEY,
+				BaseMintLiquidity:  42_000,
+				QuoteMintLiquidity: 2_500,
+				Slot:               268_000_017,
+				Swapped:            true,
+				Metadata:           RaydiumMetadata{Nonce: 254, InitPcAmount: 2_500_000_000, InitCoinAmount: 42_000_000_000_000},
+			},
+		},
+		{
+			fixture:   "lookup_table",
+			signature: "5CdBkcspkNXcfqxCdA23BWDVgnM63q2GmAXJBdU1t4rgJUAvpVEzzvAKWcaRpRbUSSW4msieCZwkc79jdGZ5Rpfn",
+			want: &RaydiumInfo{
+				BaseMint:           solana.MustPublicKeyFromBase58("3hjw4uSRTwLc3XFsyMcGKJossAqfRupG7WmhAfDHMAdR"),
+				QuoteMint:          solana.WrappedSol,
+				BaseMintLiquidity:  1_000_000,
+				QuoteMintLiquidity: 85,
+				SerumMarket:        solana.MustPublicKeyFromBase58("J2rogVF69JkkzEt7tu2fcQCUbVM36rC47aXficitkw8S"), // Loaded from the lookup table
+				Slot:               268_000_012,
+				Metadata:           RaydiumMetadata{Nonce: 254, InitPcAmount: 85_000_000_000, InitCoinAmount: 1_000_000_000_000},
+			},
+		},
+		{
+			fixture:   "cpmm_token2022",
+			signature: "HeS8h2xS2vWkoouhTa6KUrSyCUUz8bmXvKbqDwNRfYkNtZp34HSvToggx3pRUP8mwCsBn1vE2nM1xBNFansh1Ww",
+			want: &RaydiumInfo{
+				PoolType:           PoolTypeCpmm,
+				BaseMint:           solana.MustPublicKeyFromBase58("5g9YUQCcvsnJ1bWwnoLfxmAZS7hwNGUah6ASvUBAVHpL"),
+				QuoteMint:          solana.WrappedSol,
+				BaseMintLiquidity:  1_000_000_000,
+				QuoteMintLiquidity: 25,
+				Slot:               268_000_020,
+				Swapped:            true,
+				Metadata:           RaydiumMetadata{InitPcAmount: 25_000_000_000, InitCoinAmount: 1_000_000_000_000_000},
+			},
+		},
+		{
+			fixture:   "failed",
+			signature: "2n9D6WxhQ5Yb8D7mZpcq6S2aR6NdopLwT9aeNCMMUPPRdQghdMyn3fPBJ26SjBySVvXvY5Ru627zjVgdrTFjVxmf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			signature := solana.MustSignatureFromBase58(tt.signature)
+			info := parseTransaction(ctx, signature)
+
+			if tt.want == nil {
+				if info != nil {
+					t.Fatalf("expected no pool, got %+v", info)
+				}
+				return
+			}
+			if info == nil {
+				t.Fatal("info is nil")
+			}
+
+			if info.BaseMint != tt.want.BaseMint || info.QuoteMint != tt.want.QuoteMint {
+				t.Errorf("mints: got %s/%s, want %s/%s", info.BaseMint, info.QuoteMint, tt.want.BaseMint, tt.want.QuoteMint)
+			}
+			if info.BaseMintLiquidity != tt.want.BaseMintLiquidity || info.QuoteMintLiquidity != tt.want.QuoteMintLiquidity {
+				t.Errorf("liquidity: got %v/%v, want %v/%v", info.BaseMintLiquidity, info.QuoteMintLiquidity, tt.want.BaseMintLiquidity, tt.want.QuoteMintLiquidity)
+			}
+			if !tt.want.SerumMarket.IsZero() && info.SerumMarket != tt.want.SerumMarket {
+				t.Errorf("serum market: got %s, want %s", info.SerumMarket, tt.want.SerumMarket)
+			}
+			if info.Swapped != tt.want.Swapped {
+				t.Errorf("swapped: got %v, want %v", info.Swapped, tt.want.Swapped)
+			}
+			if info.TxID != signature || info.Slot != tt.want.Slot {
+				t.Errorf("tx: got %s at %d, want %s at %d", info.TxID, info.Slot, signature, tt.want.Slot)
+			}
+			if !info.TxTime.Equal(time.Unix(1717000100, 0)) {
+				t.Errorf("tx time: got %v", info.TxTime)
+			}
+			wantType := tt.want.PoolType
+			if wantType == "" {
+				wantType = PoolTypeAmmV4
+			}
+			if info.PoolType != wantType || PoolTypeOf(info.ProgramID) != wantType {
+				t.Errorf("pool type: got %q (program %s), want %q", info.PoolType, info.ProgramID, wantType)
+			}
+			if info.AmmID.IsZero() || info.LPTokenAddress.IsZero() {
+				t.Errorf("pool accounts missing: %+v", info)
+			}
+
+			// The open time is raised to the discovery time when it lies in the past
+			want := tt.want.Metadata
+			got := info.Metadata
+			if got.Nonce != want.Nonce || got.InitPcAmount != want.InitPcAmount || got.InitCoinAmount != want.InitCoinAmount {
+				t.Errorf("metadata: got %+v, want %+v", got, want)
+			}
+			if got.OpenTime < uint64(info.TxTime.Unix()) {
+				t.Errorf("open time %d before the transaction", got.OpenTime)
+			}
+		})
+	}
+}
+
+func Test_destructInfo(t *testing.T) {
+	ctx := context.Background()
+
+	rpctest.Use(t, "testdata/sol_quote.json")
+
+	rpcTx, tx, err := utils.GetConfirmedTransaction_S(ctx, solana.MustSignatureFromBase58("2sHeVzWgx4hqyNmheFBFcFirKxbhmmCrhG295mpVJ3qP5iUXFCcBeiGSvv789nm6G7Jg8sF7VbYPjh9ToJwUYC4o"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instr := tx.Message.Instructions[1]
+
+	var info RaydiumInfo
+	if !destructInfo(instr, rpcTx, tx, &info) {
+		t.Fatal("instruction not recognised")
+	}
+
+	for _, acc := range []struct {
+		name  string
+		got   solana.PublicKey
+		index int
+	}{
+		{"AmmID", info.AmmID, 4},
+		{"AmmOpenOrders", info.AmmOpenOrders, 6},
+		{"LPTokenAddress", info.LPTokenAddress, 7},
+		{"PoolCoinTokenAccount", info.PoolCoinTokenAccount, 10},
+		{"PoolPcTokenAccount", info.PoolPcTokenAccount, 11},
+		{"AmmTargetOrders", info.AmmTargetOrders, 12},
+		{"SerumMarket", info.SerumMarket, 16},
+		{"AmmLiquidityCreator", info.AmmLiquidityCreator, 20},
+	} {
+		if want := tx.Message.AccountKeys[instr.Accounts[acc.index]]; acc.got != want {
+			t.Errorf("%s: got %s, want %s", acc.name, acc.got, want)
+		}
+	}
+
+	if info.Caller != tx.Message.AccountKeys[0] {
+		t.Errorf("caller: got %s", info.Caller)
+	}
+
+	// Too few accounts for initialize2
+	short := instr
+	short.Accounts = instr.Accounts[:20]
+	if destructInfo(short, rpcTx, tx, &RaydiumInfo{}) {
+		t.Error("expected short instruction to be rejected")
+	}
+}
+
+// Test_parseTransactionLive runs against mainnet, set INCLUDE_SOLANA_BETA_MAINNET_RPC=1 to enable it.
+func Test_parseTransactionLive(t *testing.T) {
+	if os.Getenv("INCLUDE_SOLANA_BETA_MAINNET_RPC") != "1" {
+		t.Skip("mainnet RPC not enabled")
+	}
+
+	ctx := context.Background()
+
 	rpcs.Initialise([]string{})
 
 	info := parseTransaction(ctx, solana.MustSignatureFromBase58("4iknGwBn1pxVgo5AMoRrgT4X4nnXoCcdrYYgkBypQkFqDtcthjbSnH1ijf8wwns95cCCzn8uY2VcE6sgWy8qbQf6"))
 	t.Logf("info: %#+v", info)
 }
+
+func Test_destructCpmmInfo(t *testing.T) {
+	ctx := context.Background()
+
+	rpctest.Use(t, "testdata/cpmm_token2022.json")
+
+	rpcTx, tx, err := utils.GetConfirmedTransaction_S(ctx, solana.MustSignatureFromBase58("HeS8h2xS2vWkoouhTa6KUrSyCUUz8bmXvKbqDwNRfYkNtZp34HSvToggx3pRUP8mwCsBn1vE2nM1xBNFansh1Ww"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instr := tx.Message.Instructions[1]
+
+	var info RaydiumInfo
+	if !destructCpmmInfo(instr, rpcTx, tx, &info) {
+		t.Fatal("instruction not recognised")
+	}
+
+	// token 0 is WSOL, so the vaults are swapped along with the mints
+	for _, acc := range []struct {
+		name string
+		got  solana.PublicKey
+		want string
+	}{
+		{"AmmID", info.AmmID, "AaQCcuBV4gEciXUfhMr3i2ULPMb4GpknudodLfR5gmFM"},
+		{"LPTokenAddress", info.LPTokenAddress, "2Nz2zvpQMW5MVx1HSYbHY8yeka9kFw8gxNrKQFhjyiWz"},
+		{"PoolCoinTokenAccount", info.PoolCoinTokenAccount, "FjnzzNGxU5ExLQRYX3q7J8NMvd2dJJRrUxU4p5NqVUKA"},
+		{"PoolPcTokenAccount", info.PoolPcTokenAccount, "6e4UjwKEkBZddb27WzSGEsMcq7s2pUV6pHkUiQuCUqfr"},
+		{"AmmLiquidityCreator", info.AmmLiquidityCreator, "ELQ1moh9ZTuDoVjw8wmy9bzrtyekA7XtZzxepeRJhzGE"},
+		{"Caller", info.Caller, "6kN9bF9g2fy7xi35QmJn3bTzFFYS6zFSTK2sRRtR9ScT"},
+	} {
+		if acc.got.String() != acc.want {
+			t.Errorf("%s: got %s, want %s", acc.name, acc.got, acc.want)
+		}
+	}
+	if !info.SerumMarket.IsZero() || info.Metadata.OpenTime != 1717000000 {
+		t.Errorf("unexpected market %s or open time %d", info.SerumMarket, info.Metadata.OpenTime)
+	}
+
+	// Other CPMM instructions, e.g. a swap, are not pools
+	swap := instr
+	swap.Data = append([]byte{0x8f, 0xbe, 0x5a, 0xda, 0xc4, 0x1e, 0x33, 0xde}, instr.Data[8:]...)
+	if destructCpmmInfo(swap, rpcTx, tx, &RaydiumInfo{}) {
+		t.Error("expected swap to be rejected")
+	}
+
+	if !cpmmLogFilter(rpcTx.Meta.LogMessages) || cpmmLogFilter([]string{"Program log: Instruction: InitializeAccount3"}) {
+		t.Error("unexpected log filter result")
+	}
+}
+
+func Test_parseClmm(t *testing.T) {
+	ctx := context.Background()
+
+	replay := rpctest.Use(t, "testdata/clmm_*.json")
+	t.Cleanup(func() {
+		clmmPendingMutex.Lock()
+		clear(clmmPending)
+		clmmPendingMutex.Unlock()
+	})
+
+	position := solana.MustSignatureFromBase58("4F9z2japHBhbtBkPrEj3AcjPiPQMs8r7zA5ayXC5pzoJbdwVCeFQFivyU5MwEUU67wQED6AHvStR8XKgFtzoHdvY")
+	openPositionLogs := []string{"Program log: Instruction: OpenPositionV2"}
+
+	// Positions on pools the monitor has not seen created are ignored
+	if clmmLogFilter(openPositionLogs) {
+		t.Error("open position passed the filter without pending pools")
+	}
+	if info := parseTransaction(ctx, position); info != nil {
+		t.Fatalf("expected no pool, got %+v", info)
+	}
+
+	// The pool waits for its first position
+	if info := parseTransaction(ctx, solana.MustSignatureFromBase58("5Eps6uYYVXciF6eTxSpdB8VyKCSbq8GB3fgVFeUf3yvXu8NAPp4hkozHFTWkrfoqbCT6nqppKaL7PHGWjGR2bFt2")); info != nil {
+		t.Fatalf("expected pending pool, got %+v", info)
+	}
+	if pendingClmmPools() != 1 || !clmmLogFilter(openPositionLogs) {
+		t.Fatalf("pool not pending (%d), misses: %v", pendingClmmPools(), replay.Misses())
+	}
+
+	info := parseTransaction(ctx, position)
+	if info == nil {
+		t.Fatal("info is nil")
+	}
+	if pendingClmmPools() != 0 {
+		t.Error("pool still pending")
+	}
+
+	if info.PoolType != PoolTypeClmm || info.AmmID.String() != "B2n9J386JaPLqG2Kmmpe4rJsg5x88v9ggfxjeAwxNaNp" {
+		t.Errorf("unexpected pool %s (%s)", info.AmmID, info.PoolType)
+	}
+	if info.BaseMint.String() != "Gd1R4G1CjMD2LpZZ4zN7DWXYwgvwaXT29csV8HbPoUz6" || info.QuoteMint != solana.WrappedSol || !info.Swapped {
+		t.Errorf("mints: got %s/%s", info.BaseMint, info.QuoteMint)
+	}
+	if info.BaseMintLiquidity != 100_000 || info.QuoteMintLiquidity != 10 {
+		t.Errorf("liquidity: got %v/%v", info.BaseMintLiquidity, info.QuoteMintLiquidity)
+	}
+	if info.LPTokenAddress.String() != "DRg6cgHVVaAP6qkV6AG2eb5TDD8pLu4AGjVrUynEvr2M" || info.AmmLiquidityCreator.String() != "3tXeAdQ4eakmvhgCuQNYpySwhe8AS4xKT6qZX2ZhjLXg" {
+		t.Errorf("position: got %s/%s", info.LPTokenAddress, info.AmmLiquidityCreator)
+	}
+	if info.Slot != 268_000_030 || info.Metadata.InitCoinAmount != 100_000_000_000 || info.Metadata.InitPcAmount != 10_000_000_000 {
+		t.Errorf("unexpected creation %d / %+v", info.Slot, info.Metadata)
+	}
+
+	clmm := info.Clmm
+	if clmm == nil {
+		t.Fatal("clmm info missing")
+	}
+	if clmm.TickSpacing != 60 || clmm.TradeFeeRate != 2500 || clmm.FeeTier() != "0.25%" {
+		t.Errorf("fee tier: got %d / %d", clmm.TickSpacing, clmm.TradeFeeRate)
+	}
+	if clmm.TickLower != -600 || clmm.TickUpper != 600 || clmm.PositionTxID != position {
+		t.Errorf("unexpected position %+v", clmm)
+	}
+	// 10 000 tokens per SOL
+	if math.Abs(clmm.Price-0.0001) > 1e-12 {
+		t.Errorf("price: got %v, want 0.0001", clmm.Price)
+	}
+}
+
+func Test_addPendingClmm(t *testing.T) {
+	t.Cleanup(func() {
+		clmmPendingMutex.Lock()
+		clear(clmmPending)
+		clmmPendingMutex.Unlock()
+	})
+
+	// As many pending pools as allowed, the first one waiting the longest
+	pools := make([]solana.PublicKey, clmmPendingMax)
+	clmmPendingMutex.Lock()
+	for i := range pools {
+		pools[i] = solana.NewWallet().PublicKey()
+		clmmPending[pools[i]] = pendingClmmPool{info: &RaydiumInfo{AmmID: pools[i]}, added: time.Now().Add(time.Duration(i-clmmPendingMax) * time.Second)}
+	}
+	clmmPendingMutex.Unlock()
+
+	fresh := solana.NewWallet().PublicKey()
+	addPendingClmm(&RaydiumInfo{AmmID: fresh})
+
+	if pendingClmmPools() != clmmPendingMax {
+		t.Errorf("pending: got %d, want %d", pendingClmmPools(), clmmPendingMax)
+	}
+	if claimPendingClmm(pools[0]) != nil {
+		t.Error("oldest pool kept")
+	}
+	if claimPendingClmm(fresh) == nil || claimPendingClmm(pools[1]) == nil {
+		t.Error("newer pool dropped")
+	}
+}
+
+func Test_parsePumpfunMigration(t *testing.T) {
+	ctx := context.Background()
+
+	replay := rpctest.Use(t, "testdata/pumpfun_migration.json")
+
+	info := parseTransaction(ctx, solana.MustSignatureFromBase58("5SvFuVWsjxuJ6nC3F4XEUjEa4vgSwaRmvUqxG2SMnxCH35Scr81PREH2TGnu3nUQ8AVHPBsjSXCoQTgjeLhouFKs"))
+	if info == nil {
+		t.Fatalf("info is nil, misses: %v", replay.Misses())
+	}
+
+	if info.Origin != OriginPumpfun || info.Pumpfun == nil {
+		t.Fatalf("origin: got %q, %+v", info.Origin, info.Pumpfun)
+	}
+	if info.Caller.String() != utils.PUMPFUN_MIGRATION_ID {
+		t.Errorf("caller: got %s", info.Caller)
+	}
+	if info.BaseMintLiquidity != 206_900_000 || info.QuoteMintLiquidity != 79.005359057 {
+		t.Errorf("liquidity: got %v/%v", info.BaseMintLiquidity, info.QuoteMintLiquidity)
+	}
+
+	pf := info.Pumpfun
+	if pf.BondingCurve != solana.MustPublicKeyFromBase58("9gwkPye1uetZ99JxDsvan2LM6CfUtDJVMj37cC3jZ9Be") {
+		t.Errorf("bonding curve: got %s", pf.BondingCurve)
+	}
+	// The history of the curve is only looked at during enrichment
+	if !pf.Creator.IsZero() || !pf.CreatedAt.IsZero() || info.Creator() != info.Caller {
+		t.Errorf("resolved while parsing: %+v", pf)
+	}
+
+	if err := ResolvePumpfun_S(ctx, pf, info.TxTime); err != nil {
+		t.Fatalf("resolve: %v, misses: %v", err, replay.Misses())
+	}
+
+	// The curve does not store the creator, so it is taken from the launch
+	creator := solana.MustPublicKeyFromBase58("85efuze4d7tvVLhHb71w67AV7mfBoWvRA1Be7LK585P2")
+	if pf.Creator != creator || info.Creator() != creator {
+		t.Errorf("creator: got %s (%s), want %s", pf.Creator, info.Creator(), creator)
+	}
+	if !pf.CreatedAt.Equal(time.Unix(1716992900, 0)) || pf.TimeToGraduate != 2*time.Hour {
+		t.Errorf("launch: got %v, graduated in %v", pf.CreatedAt, pf.TimeToGraduate)
+	}
+
+	ev := info.PoolEvent()
+	// AMM v4 pools are labelled with the venue only
+	if ev.Venue != events.VenueRaydium || ev.Label() != "Raydium" {
+		t.Errorf("label: got %q", ev.Label())
+	}
+	if ev.Origin != "pump.fun" || ev.Creator != creator || ev.Pool != info.AmmID || ev.BaseVault != info.PoolCoinTokenAccount {
+		t.Errorf("unexpected event %+v", ev)
+	}
+	if len(ev.Extras) != 1 || ev.Extras[0].Title() != "Pump.fun" || len(ev.Extras[0].Fields()) != 3 {
+		t.Errorf("extras: got %+v", ev.Extras)
+	}
+}
@@ -6,48 +6,45 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/OnlyF0uR/solana-monitor/pkg/ingest"
 	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
 	"github.com/gagliardetto/solana-go"
-	"github.com/gagliardetto/solana-go/rpc"
-	"github.com/gagliardetto/solana-go/rpc/ws"
 )
 
-func Start(ctx context.Context, wsUrl string, ch chan<- solana.Signature) error {
-	client, err := ws.Connect(ctx, wsUrl)
-	if err != nil {
-		return err
-	}
+// Programs the monitor subscribes to, each with the filter for its pool creation logs.
+var programs = []struct {
+	id     string
+	filter ingest.LogFilter
+}{
+	{utils.RAYDIUM_PROGRAM_ID, logFilter},
+	{utils.RAYDIUM_CPMM_PROGRAM_ID, cpmmLogFilter},
+	{utils.RAYDIUM_CLMM_PROGRAM_ID, clmmLogFilter},
+}
 
-	fmt.Printf("Starting Raydium monitor\n")
+// Start forwards every pool candidate from src to ch until ctx is cancelled or one of the
+// subscriptions fails, the others are then stopped as well.
+func Start(ctx context.Context, src ingest.Source, ch chan<- ingest.Event) error {
+	fmt.Printf("Starting Raydium monitor (%s)\n", src.Name())
 
-	raydium := solana.MustPublicKeyFromBase58(utils.RAYDIUM_PROGRAM_ID)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	sub, err := client.LogsSubscribeMentions(
-		raydium,
-		rpc.CommitmentConfirmed,
-	)
-	if err != nil {
-		return err
+	errs := make(chan error, len(programs))
+	for _, p := range programs {
+		go func() {
+			errs <- src.Subscribe(ctx, solana.MustPublicKeyFromBase58(p.id), p.filter, ch)
+		}()
 	}
-	defer sub.Unsubscribe()
-
-	var lastSignature string
-	for {
-		got, err := sub.Recv()
-		if err != nil {
-			return err
-		}
 
-		if got.Value.Signature.String() == lastSignature {
-			continue
-		}
-
-		lastSignature = got.Value.Signature.String()
-
-		if logFilter(got.Value.Logs) {
-			ch <- got.Value.Signature
+	err := <-errs
+	cancel()
+	for i := 1; i < len(programs); i++ {
+		if e := <-errs; err == nil {
+			err = e
 		}
 	}
+
+	return err
 }
 
 func logFilter(logs []string) bool {
@@ -3,6 +3,8 @@ package rpcs
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,41 +12,107 @@ import (
 	"golang.org/x/time/rate"
 )
 
-var rpcPool []*rpc.Client // Only reads, so thread safe?
-var rpcIndex int = 0
+var rpcPool []*Endpoint
 
 var mutex = &sync.Mutex{}
 
+// Initialise adds an endpoint per entry, formatted as "<url> [requests per second]".
+// Endpoints without a limit use RPC_RATE_LIMIT (default 4, 0 disables the limit).
 func Initialise(rpcStrings []string) {
+	defaultLimit := rate.Limit(4)
+	if v, err := strconv.ParseFloat(os.Getenv("RPC_RATE_LIMIT"), 64); err == nil && v >= 0 {
+		defaultLimit = limitOf(v)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
 	for _, rpcString := range rpcStrings {
-		client := rpc.NewWithCustomRPCClient(rpc.NewWithLimiter(
-			rpcString,
-			rate.Every(time.Second), // time frame
-			4,                       // limit of requests per time frame
-		))
-		rpcPool = append(rpcPool, client)
+		fields := strings.Fields(rpcString)
+		if len(fields) == 0 {
+			continue
+		}
+
+		limit := defaultLimit
+		if len(fields) > 1 {
+			v, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil || v < 0 {
+				fmt.Printf("Invalid rate limit %q for RPC %s, using %v\n", fields[1], fields[0], defaultLimit)
+			} else {
+				limit = limitOf(v)
+			}
+		}
+
+		rpcPool = append(rpcPool, newHTTPEndpoint(fields[0], limit))
 	}
 
 	if os.Getenv("INCLUDE_SOLANA_BETA_MAINNET_RPC") == "1" {
-		rpcPool = append(rpcPool, rpc.New(rpc.MainNetBeta_RPC))
+		rpcPool = append(rpcPool, newHTTPEndpoint(rpc.MainNetBeta_RPC, rate.Inf))
+	}
+
+	if v, err := strconv.ParseInt(os.Getenv("RPC_HEDGE_DELAY"), 10, 64); err == nil && v >= 0 {
+		HedgeDelay = time.Duration(v) * time.Millisecond
 	}
 
 	fmt.Printf("RPC pool(s) initialised (total: %d)\n", len(rpcPool))
 }
 
+func limitOf(v float64) rate.Limit {
+	if v == 0 {
+		return rate.Inf
+	}
+	return rate.Limit(v)
+}
+
+// SetClients replaces the pool, used by tests and the fixture recorder.
+func SetClients(clients ...*rpc.Client) {
+	endpoints := make([]*Endpoint, len(clients))
+	for i, client := range clients {
+		endpoints[i] = &Endpoint{Name: fmt.Sprintf("client %d", i+1), Client: client}
+	}
+	SetEndpoints(endpoints...)
+}
+
+// SetEndpoints replaces the pool.
+func SetEndpoints(endpoints ...*Endpoint) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	rpcPool = endpoints
+}
+
+// BorrowClient returns the client of a healthy endpoint, weighted toward the fastest
+// ones. Endpoints with an open circuit breaker are skipped.
 func BorrowClient() *rpc.Client {
-	if len(rpcPool) == 0 {
+	return borrow().Client
+}
+
+func borrow() *Endpoint {
+	pool := endpoints()
+	if len(pool) == 0 {
 		panic("no RPC clients configured")
 	}
+	if len(pool) == 1 {
+		return pool[0]
+	}
+
+	return pick(pool, time.Now())
+}
 
+func endpoints() []*Endpoint {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	if rpcIndex+1 == len(rpcPool) {
-		rpcIndex = 0
-	} else {
-		rpcIndex++
-	}
+	return rpcPool
+}
+
+// Stats returns the health of every endpoint in the pool.
+func Stats() []EndpointStats {
+	pool := endpoints()
 
-	return rpcPool[rpcIndex]
+	stats := make([]EndpointStats, len(pool))
+	for i, e := range pool {
+		stats[i] = e.Stats()
+	}
+	return stats
 }
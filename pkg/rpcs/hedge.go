@@ -0,0 +1,74 @@
+package rpcs
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// HedgeDelay enables hedged requests (RPC_HEDGE_DELAY in milliseconds), 0 disables them.
+var HedgeDelay time.Duration
+
+var hedgesFired, hedgesWon atomic.Uint64
+
+// Hedge calls fn with a borrowed client. When hedging is enabled and fn has not returned
+// after HedgeDelay, the same call is fired at another healthy endpoint. The first success
+// wins and the other call is cancelled, if both fail the last error is returned.
+func Hedge[T any](ctx context.Context, fn func(ctx context.Context, client *rpc.Client) (T, error)) (T, error) {
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel() // Cancels the loser
+
+	type result struct {
+		value  T
+		err    error
+		hedged bool
+	}
+	results := make(chan result, 2)
+	call := func(e *Endpoint, hedged bool) {
+		value, err := fn(hedgeCtx, e.Client)
+		results <- result{value, err, hedged}
+	}
+
+	first := borrow()
+	go call(first, false)
+
+	delay := HedgeDelay
+	if delay <= 0 {
+		r := <-results
+		return r.value, r.err
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	pending := 1
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				if r.hedged {
+					hedgesWon.Add(1)
+				}
+				return r.value, nil
+			}
+			if pending == 0 {
+				return r.value, r.err
+			}
+		case <-timer.C:
+			second := choose(endpoints(), first, true, time.Now())
+			if second == nil {
+				continue // No other healthy endpoint
+			}
+			hedgesFired.Add(1)
+			pending++
+			go call(second, true)
+		}
+	}
+}
+
+// HedgeStats returns how many hedged requests were fired and how many of them won.
+func HedgeStats() (fired uint64, won uint64) {
+	return hedgesFired.Load(), hedgesWon.Load()
+}
@@ -0,0 +1,76 @@
+package rpcs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/time/rate"
+)
+
+func Test_Hedge(t *testing.T) {
+	SetEndpoints(NewEndpoint("a", &fakeClient{}, rate.Inf), NewEndpoint("b", &fakeClient{}, rate.Inf))
+	HedgeDelay = 10 * time.Millisecond
+	t.Cleanup(func() {
+		SetEndpoints()
+		HedgeDelay = 0
+	})
+
+	// The first request hangs until it is cancelled, the hedged one answers
+	var mutex sync.Mutex
+	var clients []*rpc.Client
+	cancelled := make(chan struct{})
+	value, err := Hedge(context.Background(), func(ctx context.Context, client *rpc.Client) (string, error) {
+		mutex.Lock()
+		clients = append(clients, client)
+		n := len(clients)
+		mutex.Unlock()
+
+		if n == 1 {
+			<-ctx.Done()
+			close(cancelled)
+			return "", ctx.Err()
+		}
+		return "hedged", nil
+	})
+	if err != nil || value != "hedged" {
+		t.Fatalf("got %q, %v", value, err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("losing request was not cancelled")
+	}
+	if clients[0] == clients[1] {
+		t.Error("hedged request went to the same endpoint")
+	}
+	if fired, won := HedgeStats(); fired == 0 || won == 0 {
+		t.Errorf("hedge not counted: %d fired, %d won", fired, won)
+	}
+}
+
+func Test_HedgeWithoutHealthyEndpoint(t *testing.T) {
+	open := NewEndpoint("open", &fakeClient{}, rate.Inf)
+	open.openUntil = time.Now().Add(time.Hour)
+	healthy := NewEndpoint("healthy", &fakeClient{}, rate.Inf)
+
+	SetEndpoints(open, healthy)
+	HedgeDelay = time.Millisecond
+	t.Cleanup(func() {
+		SetEndpoints()
+		HedgeDelay = 0
+	})
+
+	calls := 0
+	value, err := Hedge(context.Background(), func(ctx context.Context, client *rpc.Client) (int, error) {
+		calls++
+		time.Sleep(20 * time.Millisecond)
+		return calls, nil
+	})
+	if err != nil || value != 1 || calls != 1 {
+		t.Errorf("got %d after %d call(s), %v", value, calls, err)
+	}
+}
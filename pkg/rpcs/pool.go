@@ -0,0 +1,334 @@
+package rpcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"golang.org/x/time/rate"
+)
+
+const (
+	breakerFailures    = 5 // Consecutive failures opening the breaker
+	breakerMinCooldown = 5 * time.Second
+	breakerMaxCooldown = time.Minute
+	probeTimeout       = 30 * time.Second // A borrowed probe that never reported back
+	defaultLatency     = 100 * time.Millisecond
+)
+
+// Endpoint is a single RPC endpoint of the pool. Calls through its Client are rate
+// limited and measured, failing endpoints open a circuit breaker and are skipped by
+// BorrowClient until a probe succeeds again.
+type Endpoint struct {
+	Name   string
+	Client *rpc.Client
+
+	inner   rpc.JSONRPCClient
+	limiter *rate.Limiter
+
+	mutex       sync.Mutex
+	requests    uint64
+	failures    uint64
+	rateLimited uint64
+	latency     time.Duration // Moving average of the successful calls
+	errorRate   float64       // Moving average, 0 healthy to 1 failing
+	consecutive int           // Failures in a row
+	openUntil   time.Time     // Zero while the breaker is closed
+	cooldown    time.Duration
+	probing     time.Time // Set while a half-open probe is borrowed
+}
+
+// EndpointStats is a snapshot of the health of an endpoint.
+type EndpointStats struct {
+	Name        string
+	Requests    uint64
+	Failures    uint64
+	RateLimited uint64
+	Latency     time.Duration
+	ErrorRate   float64
+	Open        bool // Circuit breaker open
+}
+
+// NewEndpoint wraps inner, calls are limited to limit per second (rate.Inf for no limit).
+func NewEndpoint(name string, inner rpc.JSONRPCClient, limit rate.Limit) *Endpoint {
+	burst := max(1, int(math.Ceil(float64(limit))))
+	if limit == rate.Inf {
+		burst = 0
+	}
+
+	e := &Endpoint{
+		Name:     name,
+		inner:    inner,
+		limiter:  rate.NewLimiter(limit, burst),
+		cooldown: breakerMinCooldown,
+	}
+	e.Client = rpc.NewWithCustomRPCClient(e)
+
+	return e
+}
+
+// newHTTPEndpoint connects to an RPC url. The name is the host only, urls often carry an api key.
+func newHTTPEndpoint(rpcUrl string, limit rate.Limit) *Endpoint {
+	name := rpcUrl
+	if u, err := url.Parse(rpcUrl); err == nil && u.Host != "" {
+		name = u.Host
+	}
+
+	inner := jsonrpc.NewClientWithOpts(rpcUrl, &jsonrpc.RPCClientOpts{
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				MaxIdleConnsPerHost: 16,
+				IdleConnTimeout:     90 * time.Second,
+				ForceAttemptHTTP2:   true,
+			},
+		},
+	})
+
+	return NewEndpoint(name, inner, limit)
+}
+
+func (e *Endpoint) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	return e.call(ctx, func() error {
+		return e.inner.CallForInto(ctx, out, method, params)
+	})
+}
+
+func (e *Endpoint) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return e.call(ctx, func() error {
+		return e.inner.CallWithCallback(ctx, method, params, callback)
+	})
+}
+
+func (e *Endpoint) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	var responses jsonrpc.RPCResponses
+	err := e.call(ctx, func() error {
+		var err error
+		responses, err = e.inner.CallBatch(ctx, requests)
+		return err
+	})
+	return responses, err
+}
+
+func (e *Endpoint) call(ctx context.Context, fn func() error) error {
+	if err := e.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := fn()
+	e.record(classify(ctx, err), time.Since(start), err)
+
+	return err
+}
+
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	outcomeRateLimited
+	outcomeIgnored // Cancelled by the caller, says nothing about the endpoint
+)
+
+// classify tells endpoint failures apart from errors the endpoint answered with.
+func classify(ctx context.Context, err error) outcome {
+	if err == nil {
+		return outcomeSuccess
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return outcomeIgnored
+	}
+
+	var httpErr *jsonrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.Code == http.StatusTooManyRequests {
+			return outcomeRateLimited
+		}
+		return outcomeFailure
+	}
+
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case http.StatusTooManyRequests:
+			return outcomeRateLimited
+		case -32005: // Node is behind
+			return outcomeFailure
+		}
+		return outcomeSuccess
+	}
+
+	return outcomeFailure // Network errors and timeouts
+}
+
+func (e *Endpoint) record(o outcome, latency time.Duration, err error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if o == outcomeIgnored {
+		e.probing = time.Time{}
+		return
+	}
+
+	e.requests++
+
+	if o == outcomeSuccess {
+		if e.latency == 0 {
+			e.latency = latency
+		} else {
+			e.latency += (latency - e.latency) / 8
+		}
+		e.errorRate *= 0.9
+		e.consecutive = 0
+
+		if !e.openUntil.IsZero() {
+			e.openUntil = time.Time{}
+			e.probing = time.Time{}
+			e.cooldown = breakerMinCooldown
+			color.New(color.FgGreen).Printf("RPC %s recovered\n", e.Name)
+		}
+		return
+	}
+
+	e.failures++
+	if o == outcomeRateLimited {
+		e.rateLimited++
+	}
+	e.errorRate = e.errorRate*0.9 + 0.1
+	e.consecutive++
+
+	switch {
+	case !e.probing.IsZero():
+		// The half-open probe failed, back off further
+		e.cooldown = min(e.cooldown*2, breakerMaxCooldown)
+	case e.openUntil.IsZero() && (e.consecutive >= breakerFailures || o == outcomeRateLimited):
+		e.cooldown = breakerMinCooldown
+	default:
+		return
+	}
+
+	e.openUntil = time.Now().Add(e.cooldown)
+	e.probing = time.Time{}
+	color.New(color.FgYellow).Printf("RPC %s unavailable for %v: %v\n", e.Name, e.cooldown, err)
+}
+
+// available reports whether the endpoint can be borrowed. Once the breaker cooled
+// down a single probe is let through.
+func (e *Endpoint) available(now time.Time) bool {
+	if e.openUntil.IsZero() {
+		return true
+	}
+	if now.Before(e.openUntil) {
+		return false
+	}
+	return e.probing.IsZero() || now.Sub(e.probing) > probeTimeout
+}
+
+// weight favours fast endpoints without errors.
+func (e *Endpoint) weight() float64 {
+	latency := e.latency
+	if latency == 0 {
+		latency = defaultLatency
+	}
+	health := 1 - e.errorRate
+	return health * health / max(latency, time.Millisecond).Seconds()
+}
+
+// Stats returns a snapshot of the health of the endpoint.
+func (e *Endpoint) Stats() EndpointStats {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return EndpointStats{
+		Name:        e.Name,
+		Requests:    e.requests,
+		Failures:    e.failures,
+		RateLimited: e.rateLimited,
+		Latency:     e.latency,
+		ErrorRate:   e.errorRate,
+		Open:        !e.openUntil.IsZero(),
+	}
+}
+
+func (s EndpointStats) String() string {
+	state := "closed"
+	if s.Open {
+		state = "open"
+	}
+	return fmt.Sprintf("%s: %d request(s), %d failed, %d rate limited, latency %v, error rate %.0f%%, breaker %s",
+		s.Name, s.Requests, s.Failures, s.RateLimited, s.Latency.Round(time.Millisecond), s.ErrorRate*100, state)
+}
+
+// pick chooses an available endpoint weighted by health. When every breaker is open
+// the endpoint closest to its probe is returned, so callers keep retrying.
+func pick(endpoints []*Endpoint, now time.Time) *Endpoint {
+	if chosen := choose(endpoints, nil, false, now); chosen != nil {
+		return chosen
+	}
+
+	var soonest *Endpoint
+	var soonestAt time.Time
+	for _, e := range endpoints {
+		e.mutex.Lock()
+		openUntil := e.openUntil
+		e.mutex.Unlock()
+
+		if soonest == nil || openUntil.Before(soonestAt) {
+			soonest, soonestAt = e, openUntil
+		}
+	}
+	return soonest
+}
+
+// choose picks an endpoint other than exclude weighted by health, nil if none is available.
+// With closedOnly endpoints waiting for a probe are skipped as well.
+func choose(endpoints []*Endpoint, exclude *Endpoint, closedOnly bool, now time.Time) *Endpoint {
+	var total float64
+	weights := make([]float64, len(endpoints))
+	for i, e := range endpoints {
+		if e == exclude {
+			continue
+		}
+		e.mutex.Lock()
+		if e.available(now) && (!closedOnly || e.openUntil.IsZero()) {
+			weights[i] = max(e.weight(), 1e-6)
+			total += weights[i]
+		}
+		e.mutex.Unlock()
+	}
+	if total == 0 {
+		return nil
+	}
+
+	var chosen *Endpoint
+	r := rand.Float64() * total
+	for i, w := range weights {
+		if w == 0 {
+			continue
+		}
+		chosen = endpoints[i]
+		if r < w {
+			break
+		}
+		r -= w
+	}
+
+	chosen.mutex.Lock()
+	if !chosen.openUntil.IsZero() {
+		chosen.probing = now
+	}
+	chosen.mutex.Unlock()
+
+	return chosen
+}
@@ -0,0 +1,139 @@
+package rpcs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"golang.org/x/time/rate"
+)
+
+// fakeClient answers every call with err.
+type fakeClient struct {
+	err error
+}
+
+func (c *fakeClient) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	return c.err
+}
+
+func (c *fakeClient) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return c.err
+}
+
+func (c *fakeClient) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, c.err
+}
+
+func call(e *Endpoint) error {
+	var out any
+	return e.CallForInto(context.Background(), &out, "getSlot", nil)
+}
+
+func Test_CircuitBreaker(t *testing.T) {
+	failing := &fakeClient{err: errors.New("connection refused")}
+	bad := NewEndpoint("bad", failing, rate.Inf)
+	good := NewEndpoint("good", &fakeClient{}, rate.Inf)
+
+	SetEndpoints(bad, good)
+	t.Cleanup(func() { SetEndpoints() })
+
+	for i := 0; i < breakerFailures; i++ {
+		call(bad)
+	}
+	if stats := bad.Stats(); !stats.Open || stats.Failures != breakerFailures {
+		t.Fatalf("breaker not open: %+v", stats)
+	}
+
+	for i := 0; i < 100; i++ {
+		if BorrowClient() != good.Client {
+			t.Fatal("borrowed an endpoint with an open breaker")
+		}
+	}
+
+	// Once cooled down a single probe is let through, its failure backs off further
+	bad.mutex.Lock()
+	bad.openUntil = time.Now().Add(-time.Second)
+	bad.mutex.Unlock()
+
+	if pick([]*Endpoint{bad}, time.Now()) != bad {
+		t.Fatal("cooled down endpoint was not probed")
+	}
+	for i := 0; i < 100; i++ {
+		if BorrowClient() != good.Client {
+			t.Fatal("borrowed an endpoint while its probe is in flight")
+		}
+	}
+	call(bad)
+	if bad.cooldown != 2*breakerMinCooldown || !bad.Stats().Open {
+		t.Errorf("failed probe did not back off: cooldown %v", bad.cooldown)
+	}
+
+	// A successful probe closes the breaker
+	bad.openUntil = time.Now().Add(-time.Second)
+	failing.err = nil
+	pick([]*Endpoint{bad}, time.Now())
+	call(bad)
+	if stats := bad.Stats(); stats.Open || bad.cooldown != breakerMinCooldown {
+		t.Errorf("breaker not closed: %+v", stats)
+	}
+}
+
+func Test_classify(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want outcome
+	}{
+		{"success", context.Background(), nil, outcomeSuccess},
+		{"rate limited", context.Background(), jsonrpc.NewHTTPError(429, errors.New("too many requests")), outcomeRateLimited},
+		{"rate limited body", context.Background(), &jsonrpc.RPCError{Code: 429}, outcomeRateLimited},
+		{"server error", context.Background(), jsonrpc.NewHTTPError(502, errors.New("bad gateway")), outcomeFailure},
+		{"node behind", context.Background(), &jsonrpc.RPCError{Code: -32005}, outcomeFailure},
+		{"answered", context.Background(), &jsonrpc.RPCError{Code: -32602, Message: "Invalid param"}, outcomeSuccess},
+		{"network", context.Background(), errors.New("connection reset"), outcomeFailure},
+		{"cancelled", cancelled, context.Canceled, outcomeIgnored},
+	}
+
+	for _, tt := range tests {
+		if got := classify(tt.ctx, tt.err); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func Test_RateLimitOpensBreaker(t *testing.T) {
+	e := NewEndpoint("limited", &fakeClient{err: jsonrpc.NewHTTPError(429, errors.New("too many requests"))}, rate.Inf)
+
+	call(e)
+	if stats := e.Stats(); !stats.Open || stats.RateLimited != 1 {
+		t.Errorf("429 did not open the breaker: %+v", stats)
+	}
+}
+
+func Test_Initialise(t *testing.T) {
+	t.Setenv("RPC_RATE_LIMIT", "10")
+	t.Setenv("INCLUDE_SOLANA_BETA_MAINNET_RPC", "0")
+	SetEndpoints()
+	t.Cleanup(func() { SetEndpoints() })
+
+	Initialise([]string{"https://rpc.example.com/?api-key=secret 25", "https://other.example.com", ""})
+
+	stats := Stats()
+	if len(stats) != 2 || stats[0].Name != "rpc.example.com" || stats[1].Name != "other.example.com" {
+		t.Fatalf("unexpected pool %+v", stats)
+	}
+	if limit := rpcPool[0].limiter.Limit(); limit != 25 {
+		t.Errorf("limit %v, want 25", limit)
+	}
+	if limit := rpcPool[1].limiter.Limit(); limit != 10 {
+		t.Errorf("limit %v, want 10", limit)
+	}
+}
@@ -0,0 +1,70 @@
+// Package rpctest records RPC responses into JSON fixtures and replays them,
+// so parsers can be tested without a mainnet connection.
+package rpctest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// Fixture is the content of a single file under testdata/.
+type Fixture struct {
+	Description string                     `json:"description"`
+	Calls       []Call                     `json:"calls"`
+	Documents   map[string]json.RawMessage `json:"documents,omitempty"` // Off-chain metadata by URI
+}
+
+// Call is one JSON-RPC request with either its result or its error.
+type Call struct {
+	Method string            `json:"method"`
+	Params json.RawMessage   `json:"params"`
+	Result json.RawMessage   `json:"result,omitempty"`
+	Error  *jsonrpc.RPCError `json:"error,omitempty"`
+}
+
+// Load reads a fixture file.
+func Load(path string) (*Fixture, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(raw, &fixture); err != nil {
+		return nil, err
+	}
+	return &fixture, nil
+}
+
+// Save writes the fixture as indented JSON, creating the directory if needed.
+func (f *Fixture) Save(path string) error {
+	raw, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(raw, '\n'), 0o644)
+}
+
+// callKey identifies a request by method and compacted params.
+func callKey(method string, params []byte) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, params); err != nil {
+		return method + " " + string(params)
+	}
+	return method + " " + buf.String()
+}
+
+func marshalParams(params interface{}) ([]byte, error) {
+	if params == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(params)
+}
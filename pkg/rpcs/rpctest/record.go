@@ -0,0 +1,160 @@
+package rpctest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// Recorder forwards requests to a real endpoint and keeps every response.
+// Retries of the same request are only recorded once.
+type Recorder struct {
+	inner     rpc.JSONRPCClient
+	transport http.RoundTripper
+
+	mutex   sync.Mutex
+	fixture Fixture
+	seen    map[string]bool
+}
+
+// NewRecorder wraps inner, documents are downloaded with transport (http.DefaultTransport if nil).
+func NewRecorder(inner rpc.JSONRPCClient, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &Recorder{
+		inner:     inner,
+		transport: transport,
+		fixture:   Fixture{Documents: make(map[string]json.RawMessage)},
+		seen:      make(map[string]bool),
+	}
+}
+
+// Client returns an RPC client that records through r.
+func (r *Recorder) Client() *rpc.Client {
+	return rpc.NewWithCustomRPCClient(r)
+}
+
+// HTTPClient returns an HTTP client that records the documents it downloads.
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Fixture returns a copy of everything recorded so far.
+func (r *Recorder) Fixture() *Fixture {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	f := Fixture{
+		Description: r.fixture.Description,
+		Calls:       append([]Call(nil), r.fixture.Calls...),
+		Documents:   make(map[string]json.RawMessage, len(r.fixture.Documents)),
+	}
+	for uri, doc := range r.fixture.Documents {
+		f.Documents[uri] = doc
+	}
+	return &f
+}
+
+func (r *Recorder) record(method string, params interface{}, result json.RawMessage, rpcErr *jsonrpc.RPCError) {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return
+	}
+
+	key := callKey(method, raw)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.seen[key] {
+		return
+	}
+	r.seen[key] = true
+
+	r.fixture.Calls = append(r.fixture.Calls, Call{
+		Method: method,
+		Params: raw,
+		Result: result,
+		Error:  rpcErr,
+	})
+}
+
+func (r *Recorder) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	var result json.RawMessage
+	err := r.inner.CallForInto(ctx, &result, method, params)
+
+	// Transport errors are not recorded, they say nothing about the chain
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		r.record(method, params, nil, rpcErr)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	r.record(method, params, result, nil)
+
+	return (&jsonrpc.RPCResponse{Result: result}).GetObject(out)
+}
+
+func (r *Recorder) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return r.inner.CallWithCallback(ctx, method, params, callback)
+}
+
+func (r *Recorder) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	responses, err := r.inner.CallBatch(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := responses.AsMap()
+	for _, req := range requests {
+		if res, ok := byID[req.ID]; ok {
+			r.record(req.Method, req.Params, res.Result, res.Error)
+		}
+	}
+	return responses, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusOK && json.Valid(body) {
+		r.mutex.Lock()
+		r.fixture.Documents[req.URL.String()] = body
+		r.mutex.Unlock()
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+// Close closes the wrapped client when it supports it.
+func (r *Recorder) Close() error {
+	if c, ok := r.inner.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
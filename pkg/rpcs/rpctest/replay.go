@@ -0,0 +1,192 @@
+package rpctest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// ErrNoFixture is returned for requests that were never recorded.
+var ErrNoFixture = errors.New("rpctest: no fixture for request")
+
+// Replay answers JSON-RPC requests and metadata downloads from fixtures.
+// It implements rpc.JSONRPCClient and http.RoundTripper.
+type Replay struct {
+	calls     map[string]Call
+	documents map[string]json.RawMessage
+
+	mutex  sync.Mutex
+	misses []string
+}
+
+// NewReplay merges the fixtures, later fixtures override identical requests.
+func NewReplay(fixtures ...*Fixture) *Replay {
+	r := &Replay{
+		calls:     make(map[string]Call),
+		documents: make(map[string]json.RawMessage),
+	}
+
+	for _, f := range fixtures {
+		for _, c := range f.Calls {
+			r.calls[callKey(c.Method, c.Params)] = c
+		}
+		for uri, doc := range f.Documents {
+			r.documents[uri] = doc
+		}
+	}
+
+	return r
+}
+
+// LoadReplay loads every fixture matching the glob patterns.
+func LoadReplay(patterns ...string) (*Replay, error) {
+	var fixtures []*Fixture
+	for _, pattern := range patterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("rpctest: no fixtures match %s", pattern)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			f, err := Load(path)
+			if err != nil {
+				return nil, fmt.Errorf("rpctest: %s: %w", path, err)
+			}
+			fixtures = append(fixtures, f)
+		}
+	}
+	return NewReplay(fixtures...), nil
+}
+
+// Client returns an RPC client backed by the replay.
+func (r *Replay) Client() *rpc.Client {
+	return rpc.NewWithCustomRPCClient(r)
+}
+
+// HTTPClient returns an HTTP client serving the recorded documents.
+func (r *Replay) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Misses lists the requests that had no fixture, useful when a test fails.
+func (r *Replay) Misses() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.misses...)
+}
+
+func (r *Replay) lookup(method string, params interface{}) (Call, error) {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return Call{}, err
+	}
+
+	key := callKey(method, raw)
+	c, ok := r.calls[key]
+	if !ok {
+		r.mutex.Lock()
+		r.misses = append(r.misses, key)
+		r.mutex.Unlock()
+		return Call{}, fmt.Errorf("%w: %s", ErrNoFixture, key)
+	}
+	return c, nil
+}
+
+func (r *Replay) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c, err := r.lookup(method, params)
+	if err != nil {
+		return err
+	}
+	if c.Error != nil {
+		return c.Error
+	}
+
+	return (&jsonrpc.RPCResponse{Result: c.Result}).GetObject(out)
+}
+
+func (r *Replay) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return errors.New("rpctest: CallWithCallback is not supported")
+}
+
+func (r *Replay) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	responses := make(jsonrpc.RPCResponses, 0, len(requests))
+	for _, req := range requests {
+		c, err := r.lookup(req.Method, req.Params)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, &jsonrpc.RPCResponse{
+			JSONRPC: "2.0",
+			Result:  c.Result,
+			Error:   c.Error,
+			ID:      req.ID,
+		})
+	}
+	return responses, nil
+}
+
+func (r *Replay) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	status := http.StatusOK
+	body, ok := r.documents[req.URL.String()]
+	if !ok {
+		status = http.StatusNotFound
+		body = json.RawMessage(`{"error":"not found"}`)
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(status),
+		StatusCode:    status,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Use loads the fixtures into the RPC pool for the duration of the test.
+func Use(t testing.TB, patterns ...string) *Replay {
+	t.Helper()
+
+	replay, err := LoadReplay(patterns...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rpcs.SetClients(replay.Client())
+	t.Cleanup(func() {
+		rpcs.SetClients()
+		if misses := replay.Misses(); len(misses) > 0 && t.Failed() {
+			t.Logf("requests without fixture: %v", misses)
+		}
+	})
+
+	return replay
+}
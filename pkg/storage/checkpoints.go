@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Checkpoint returns the slot the stream was processed up to, 0 if it was never saved.
+func (s *Store) Checkpoint(ctx context.Context, stream string) (uint64, error) {
+	var slot int64
+	err := s.db.QueryRowContext(ctx, `SELECT slot FROM checkpoints WHERE stream = ?`, stream).Scan(&slot)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return uint64(slot), err
+}
+
+// SaveCheckpoint records the slot the stream was processed up to, it never moves backwards.
+func (s *Store) SaveCheckpoint(ctx context.Context, stream string, slot uint64) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO checkpoints (stream, slot, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (stream) DO UPDATE SET slot = excluded.slot, updated_at = excluded.updated_at
+		WHERE excluded.slot > checkpoints.slot`,
+		stream, int64(slot), unixMilli(time.Now()),
+	)
+	return err
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/openbook"
+	"github.com/gagliardetto/solana-go"
+)
+
+// SaveMarket stores a detected market, it returns false if the signature was already stored.
+func (s *Store) SaveMarket(ctx context.Context, info *openbook.OpenbookInfo) (bool, error) {
+	params, err := nullJSON(info.Params)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO markets (
+			signature, program_id, market, event_queue, bids, asks, base_mint, quote_mint,
+			base_vault, quote_vault, vault_signer, caller, slot, tx_time, discovered_at, swapped, costs, params
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		info.TxID.String(), info.ProgramID.String(), info.Market.String(), info.EventQueue.String(),
+		info.Bids.String(), info.Asks.String(), info.BaseMint.String(), info.QuoteMint.String(),
+		info.BaseVault.String(), info.QuoteVault.String(), info.VaultSigner.String(), info.Caller.String(),
+		info.Slot, unix(info.TxTime), unixMilli(info.Timestamp), boolInt(info.Swapped), info.Costs, params,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// MarketByMint returns the most recent market with the given base mint.
+func (s *Store) MarketByMint(ctx context.Context, mint solana.PublicKey) (*openbook.OpenbookInfo, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT signature, program_id, market, event_queue, bids, asks, base_mint, quote_mint,
+			base_vault, quote_vault, vault_signer, caller, slot, tx_time, discovered_at, swapped, costs, params
+		FROM markets WHERE base_mint = ? ORDER BY slot DESC LIMIT 1`, mint.String())
+
+	info, err := scanMarket(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return info, err
+}
+
+func scanMarket(row *sql.Row) (*openbook.OpenbookInfo, error) {
+	var (
+		signature                                               string
+		programID, market, eventQueue, bids, asks               string
+		baseMint, quoteMint, baseVault, quoteVault, vaultSigner string
+		caller                                                  string
+		txTime, discoveredAt                                    int64
+		swapped                                                 int
+		params                                                  sql.NullString
+		info                                                    openbook.OpenbookInfo
+	)
+
+	err := row.Scan(&signature, &programID, &market, &eventQueue, &bids, &asks, &baseMint, &quoteMint,
+		&baseVault, &quoteVault, &vaultSigner, &caller, &info.Slot, &txTime, &discoveredAt, &swapped, &info.Costs, &params)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.TxID, err = solana.SignatureFromBase58(signature); err != nil {
+		return nil, err
+	}
+
+	keys := []struct {
+		dst *solana.PublicKey
+		src string
+	}{
+		{&info.ProgramID, programID}, {&info.Market, market}, {&info.EventQueue, eventQueue},
+		{&info.Bids, bids}, {&info.Asks, asks}, {&info.BaseMint, baseMint}, {&info.QuoteMint, quoteMint},
+		{&info.BaseVault, baseVault}, {&info.QuoteVault, quoteVault}, {&info.VaultSigner, vaultSigner},
+		{&info.Caller, caller},
+	}
+	for _, k := range keys {
+		if *k.dst, err = solana.PublicKeyFromBase58(k.src); err != nil {
+			return nil, err
+		}
+	}
+
+	info.TxTime = fromUnix(txTime)
+	info.Timestamp = fromUnixMilli(discoveredAt)
+	info.Swapped = swapped != 0
+	info.Version = openbook.VersionOf(info.ProgramID)
+
+	if params.Valid {
+		if err := json.Unmarshal([]byte(params.String), &info.Params); err != nil {
+			return nil, err
+		}
+	}
+
+	return &info, nil
+}
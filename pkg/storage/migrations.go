@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// migrations are applied in order and never edited once released,
+// schema changes are appended as a new entry.
+var migrations = []string{
+	// 1: markets, pools, enrichment snapshots and notification outcomes
+	`
+	CREATE TABLE markets (
+		signature       TEXT PRIMARY KEY,
+		program_id      TEXT NOT NULL,
+		market          TEXT NOT NULL,
+		event_queue     TEXT NOT NULL,
+		bids            TEXT NOT NULL,
+		asks            TEXT NOT NULL,
+		base_mint       TEXT NOT NULL,
+		quote_mint      TEXT NOT NULL,
+		base_vault      TEXT NOT NULL,
+		quote_vault     TEXT NOT NULL,
+		vault_signer    TEXT NOT NULL,
+		caller          TEXT NOT NULL,
+		slot            INTEGER NOT NULL,
+		tx_time         INTEGER NOT NULL,
+		discovered_at   INTEGER NOT NULL,
+		swapped         INTEGER NOT NULL,
+		costs           REAL NOT NULL
+	);
+	CREATE INDEX markets_base_mint ON markets (base_mint);
+	CREATE INDEX markets_market ON markets (market);
+
+	CREATE TABLE pools (
+		signature               TEXT PRIMARY KEY,
+		program_id              TEXT NOT NULL,
+		amm_id                  TEXT NOT NULL,
+		amm_open_orders         TEXT NOT NULL,
+		lp_mint                 TEXT NOT NULL,
+		base_mint               TEXT NOT NULL,
+		quote_mint              TEXT NOT NULL,
+		pool_coin_token_account TEXT NOT NULL,
+		pool_pc_token_account   TEXT NOT NULL,
+		amm_target_orders       TEXT NOT NULL,
+		amm_liquidity_creator   TEXT NOT NULL,
+		base_liquidity          REAL NOT NULL,
+		quote_liquidity         REAL NOT NULL,
+		caller                  TEXT NOT NULL,
+		slot                    INTEGER NOT NULL,
+		tx_time                 INTEGER NOT NULL,
+		discovered_at           INTEGER NOT NULL,
+		swapped                 INTEGER NOT NULL,
+		nonce                   INTEGER NOT NULL,
+		open_time               INTEGER NOT NULL,
+		init_pc_amount          TEXT NOT NULL,
+		init_coin_amount        TEXT NOT NULL
+	);
+	CREATE INDEX pools_base_mint ON pools (base_mint);
+	CREATE INDEX pools_amm_id ON pools (amm_id);
+
+	CREATE TABLE enrichments (
+		id                       INTEGER PRIMARY KEY AUTOINCREMENT,
+		signature                TEXT NOT NULL,
+		mint                     TEXT NOT NULL,
+		captured_at              INTEGER NOT NULL,
+		supply                   TEXT,
+		decimals                 INTEGER,
+		mint_authority_enabled   INTEGER NOT NULL,
+		freeze_authority_enabled INTEGER NOT NULL,
+		creator_balance          REAL NOT NULL,
+		token_data               TEXT,
+		token_meta               TEXT,
+		top_holders              TEXT,
+		errors                   TEXT
+	);
+	CREATE INDEX enrichments_signature ON enrichments (signature);
+	CREATE INDEX enrichments_mint ON enrichments (mint);
+
+	CREATE TABLE notifications (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		signature  TEXT NOT NULL,
+		kind       TEXT NOT NULL,
+		hook       TEXT NOT NULL,
+		status     TEXT NOT NULL,
+		error      TEXT,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX notifications_signature ON notifications (signature);
+	`,
+	// 2: openbook market of a pool, NULL for pools stored before
+	`
+	ALTER TABLE pools ADD COLUMN serum_market TEXT;
+	`,
+	// 3: slot every ingestion stream was processed up to
+	`
+	CREATE TABLE checkpoints (
+		stream     TEXT PRIMARY KEY,
+		slot       INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+	`,
+	// 4: market parameters as JSON, NULL for markets stored before
+	`
+	ALTER TABLE markets ADD COLUMN params TEXT;
+	`,
+}
+
+func (s *Store) migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			applied_at INTEGER NOT NULL
+		)`)
+	if err != nil {
+		return err
+	}
+
+	version, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, i+1, time.Now().Unix()); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SchemaVersion returns the number of applied migrations.
+func (s *Store) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Pool is a detected pool of any venue. Accounts a venue does not have are zero.
+type Pool struct {
+	TxID         solana.Signature
+	ProgramID    solana.PublicKey
+	Pool         solana.PublicKey
+	OpenOrders   solana.PublicKey // Raydium AMM v4 only
+	LPMint       solana.PublicKey // LP mint, or position NFT of concentrated liquidity pools
+	BaseMint     solana.PublicKey
+	QuoteMint    solana.PublicKey
+	BaseVault    solana.PublicKey
+	QuoteVault   solana.PublicKey
+	TargetOrders solana.PublicKey // Raydium AMM v4 only
+	LPAccount    solana.PublicKey
+	SerumMarket  solana.PublicKey // Raydium AMM v4 only
+
+	BaseLiquidity   float64
+	QuoteLiquidity  float64
+	InitBaseAmount  uint64
+	InitQuoteAmount uint64
+	Nonce           uint64
+	OpenTime        uint64
+
+	Caller    solana.PublicKey
+	Slot      uint64
+	TxTime    time.Time
+	Timestamp time.Time
+	Swapped   bool
+}
+
+// SavePool stores a detected pool, it returns false if the signature was already stored.
+func (s *Store) SavePool(ctx context.Context, pool *Pool) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO pools (
+			signature, program_id, amm_id, amm_open_orders, lp_mint, base_mint, quote_mint,
+			pool_coin_token_account, pool_pc_token_account, amm_target_orders, amm_liquidity_creator,
+			base_liquidity, quote_liquidity, caller, slot, tx_time, discovered_at, swapped,
+			nonce, open_time, init_pc_amount, init_coin_amount, serum_market
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		pool.TxID.String(), pool.ProgramID.String(), pool.Pool.String(), pool.OpenOrders.String(),
+		pool.LPMint.String(), pool.BaseMint.String(), pool.QuoteMint.String(),
+		pool.BaseVault.String(), pool.QuoteVault.String(), pool.TargetOrders.String(),
+		pool.LPAccount.String(), pool.BaseLiquidity, pool.QuoteLiquidity, pool.Caller.String(),
+		pool.Slot, unix(pool.TxTime), unixMilli(pool.Timestamp), boolInt(pool.Swapped),
+		// Amounts are u64 and may not fit an SQLite integer
+		int64(pool.Nonce), int64(pool.OpenTime),
+		strconv.FormatUint(pool.InitQuoteAmount, 10), strconv.FormatUint(pool.InitBaseAmount, 10),
+		pool.SerumMarket.String(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// PoolsByMint returns every pool with the given base mint, newest first.
+func (s *Store) PoolsByMint(ctx context.Context, mint solana.PublicKey) ([]*Pool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT signature, program_id, amm_id, amm_open_orders, lp_mint, base_mint, quote_mint,
+			pool_coin_token_account, pool_pc_token_account, amm_target_orders, amm_liquidity_creator,
+			base_liquidity, quote_liquidity, caller, slot, tx_time, discovered_at, swapped,
+			nonce, open_time, init_pc_amount, init_coin_amount, serum_market
+		FROM pools WHERE base_mint = ? ORDER BY slot DESC`, mint.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pools []*Pool
+	for rows.Next() {
+		pool, err := scanPool(rows)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, pool)
+	}
+	return pools, rows.Err()
+}
+
+func scanPool(rows *sql.Rows) (*Pool, error) {
+	var (
+		signature, programID, poolID, openOrders, lpMint, baseMint, quoteMint string
+		baseVault, quoteVault, targetOrders, lpAccount, caller                string
+		txTime, discoveredAt, nonce, openTime                                 int64
+		swapped                                                               int
+		initQuote, initBase                                                   string
+		serumMarket                                                           sql.NullString
+		pool                                                                  Pool
+	)
+
+	err := rows.Scan(&signature, &programID, &poolID, &openOrders, &lpMint, &baseMint, &quoteMint,
+		&baseVault, &quoteVault, &targetOrders, &lpAccount, &pool.BaseLiquidity, &pool.QuoteLiquidity,
+		&caller, &pool.Slot, &txTime, &discoveredAt, &swapped, &nonce, &openTime, &initQuote, &initBase, &serumMarket)
+	if err != nil {
+		return nil, err
+	}
+
+	if pool.TxID, err = solana.SignatureFromBase58(signature); err != nil {
+		return nil, err
+	}
+
+	keys := []struct {
+		dst *solana.PublicKey
+		src string
+	}{
+		{&pool.ProgramID, programID}, {&pool.Pool, poolID}, {&pool.OpenOrders, openOrders},
+		{&pool.LPMint, lpMint}, {&pool.BaseMint, baseMint}, {&pool.QuoteMint, quoteMint},
+		{&pool.BaseVault, baseVault}, {&pool.QuoteVault, quoteVault},
+		{&pool.TargetOrders, targetOrders}, {&pool.LPAccount, lpAccount}, {&pool.Caller, caller},
+	}
+	for _, k := range keys {
+		if *k.dst, err = solana.PublicKeyFromBase58(k.src); err != nil {
+			return nil, err
+		}
+	}
+
+	if serumMarket.Valid {
+		if pool.SerumMarket, err = solana.PublicKeyFromBase58(serumMarket.String); err != nil {
+			return nil, err
+		}
+	}
+
+	if pool.InitQuoteAmount, err = strconv.ParseUint(initQuote, 10, 64); err != nil {
+		return nil, err
+	}
+	if pool.InitBaseAmount, err = strconv.ParseUint(initBase, 10, 64); err != nil {
+		return nil, err
+	}
+
+	pool.TxTime = fromUnix(txTime)
+	pool.Timestamp = fromUnixMilli(discoveredAt)
+	pool.Swapped = swapped != 0
+	pool.Nonce = uint64(nonce)
+	pool.OpenTime = uint64(openTime)
+
+	return &pool, nil
+}
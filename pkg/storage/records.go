@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+)
+
+// Enrichment is the token information gathered for a pool at a point in time.
+type Enrichment struct {
+	Signature  string
+	Mint       string
+	CapturedAt time.Time
+
+	TokenData              *utils.TokenData
+	TokenMeta              *utils.TokenMeta
+	MintAuthorityEnabled   bool
+	FreezeAuthorityEnabled bool
+	CreatorBalance         float64
+	TopHolders             []utils.TopHolder
+	Errors                 map[string]string // Step name to error message
+}
+
+// SaveEnrichment appends a snapshot, a pool can have several over time.
+func (s *Store) SaveEnrichment(ctx context.Context, e Enrichment) error {
+	var supply sql.NullString
+	var decimals sql.NullInt64
+	if e.TokenData != nil {
+		supply = sql.NullString{String: strconv.FormatUint(e.TokenData.Supply, 10), Valid: true}
+		decimals = sql.NullInt64{Int64: int64(e.TokenData.Decimals), Valid: true}
+	}
+
+	tokenData, err := nullJSON(e.TokenData)
+	if err != nil {
+		return err
+	}
+	tokenMeta, err := nullJSON(e.TokenMeta)
+	if err != nil {
+		return err
+	}
+	topHolders, err := nullJSON(e.TopHolders)
+	if err != nil {
+		return err
+	}
+	errs, err := nullJSON(e.Errors)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO enrichments (
+			signature, mint, captured_at, supply, decimals, mint_authority_enabled, freeze_authority_enabled,
+			creator_balance, token_data, token_meta, top_holders, errors
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Signature, e.Mint, unixMilli(e.CapturedAt), supply, decimals,
+		boolInt(e.MintAuthorityEnabled), boolInt(e.FreezeAuthorityEnabled), e.CreatorBalance,
+		tokenData, tokenMeta, topHolders, errs,
+	)
+	return err
+}
+
+// LatestEnrichment returns the newest snapshot for a mint.
+func (s *Store) LatestEnrichment(ctx context.Context, mint string) (*Enrichment, error) {
+	var (
+		e                                        Enrichment
+		capturedAt                               int64
+		mintAuthority, freezeAuthority           int
+		tokenData, tokenMeta, topHolders, errors sql.NullString
+	)
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT signature, mint, captured_at, mint_authority_enabled, freeze_authority_enabled,
+			creator_balance, token_data, token_meta, top_holders, errors
+		FROM enrichments WHERE mint = ? ORDER BY captured_at DESC, id DESC LIMIT 1`, mint).Scan(
+		&e.Signature, &e.Mint, &capturedAt, &mintAuthority, &freezeAuthority,
+		&e.CreatorBalance, &tokenData, &tokenMeta, &topHolders, &errors,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e.CapturedAt = fromUnixMilli(capturedAt)
+	e.MintAuthorityEnabled = mintAuthority != 0
+	e.FreezeAuthorityEnabled = freezeAuthority != 0
+
+	for _, col := range []struct {
+		src sql.NullString
+		dst any
+	}{
+		{tokenData, &e.TokenData}, {tokenMeta, &e.TokenMeta}, {topHolders, &e.TopHolders}, {errors, &e.Errors},
+	} {
+		if !col.src.Valid {
+			continue
+		}
+		if err := json.Unmarshal([]byte(col.src.String), col.dst); err != nil {
+			return nil, err
+		}
+	}
+
+	return &e, nil
+}
+
+// Notification is the outcome of handing a market or pool to a hook.
+type Notification struct {
+	Signature string
+	Kind      string // pool, market or update
+	Hook      string
+	Status    string
+	Error     string
+	CreatedAt time.Time
+}
+
+func (s *Store) SaveNotification(ctx context.Context, n Notification) error {
+	var errMsg sql.NullString
+	if n.Error != "" {
+		errMsg = sql.NullString{String: n.Error, Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO notifications (signature, kind, hook, status, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		n.Signature, n.Kind, n.Hook, n.Status, errMsg, unixMilli(n.CreatedAt),
+	)
+	return err
+}
+
+// Notifications returns the outcomes recorded for a signature, oldest first.
+func (s *Store) Notifications(ctx context.Context, signature string) ([]Notification, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT signature, kind, hook, status, error, created_at
+		FROM notifications WHERE signature = ? ORDER BY id`, signature)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Notification
+	for rows.Next() {
+		var n Notification
+		var errMsg sql.NullString
+		var createdAt int64
+		if err := rows.Scan(&n.Signature, &n.Kind, &n.Hook, &n.Status, &errMsg, &createdAt); err != nil {
+			return nil, err
+		}
+		n.Error = errMsg.String
+		n.CreatedAt = fromUnixMilli(createdAt)
+		list = append(list, n)
+	}
+	return list, rows.Err()
+}
+
+// nullJSON encodes v, nil values are stored as NULL.
+func nullJSON(v any) (sql.NullString, error) {
+	raw, err := json.Marshal(v)
+	if err != nil || string(raw) == "null" {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(raw), Valid: true}, nil
+}
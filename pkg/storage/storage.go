@@ -0,0 +1,110 @@
+// Package storage keeps every detected market and pool, the enrichment snapshots
+// and the notification outcomes in an embedded SQLite database.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrNotFound is returned by lookups without result.
+var ErrNotFound = errors.New("not found")
+
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens (or creates) the database at path and applies pending migrations.
+// Use ":memory:" for a throwaway database.
+func Open(ctx context.Context, path string) (*Store, error) {
+	dsn := "file:" + path + "?" + url.Values{
+		"_pragma": []string{"busy_timeout(5000)", "journal_mode(WAL)", "synchronous(NORMAL)"},
+	}.Encode()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite has a single writer, serialising on one connection avoids busy errors
+	// and keeps in-memory databases alive.
+	db.SetMaxOpenConns(1)
+
+	s := &Store{db: db}
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// HasSignature reports whether a market or pool with this signature was stored.
+func (s *Store) HasSignature(ctx context.Context, signature string) (bool, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM markets WHERE signature = ?1)
+		     + (SELECT COUNT(*) FROM pools WHERE signature = ?1)`, signature).Scan(&n)
+	return n > 0, err
+}
+
+// LastSlot returns the highest slot of any stored market or pool, 0 if there is none.
+func (s *Store) LastSlot(ctx context.Context) (uint64, error) {
+	var slot sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT MAX(slot) FROM (
+			SELECT MAX(slot) AS slot FROM markets
+			UNION ALL
+			SELECT MAX(slot) AS slot FROM pools
+		)`).Scan(&slot)
+	if err != nil || !slot.Valid {
+		return 0, err
+	}
+	return uint64(slot.Int64), nil
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// unix is unixMilli in seconds.
+func unix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func fromUnix(s int64) time.Time {
+	if s == 0 {
+		return time.Time{}
+	}
+	return time.Unix(s, 0)
+}
+
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func fromUnixMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/openbook"
+	"github.com/OnlyF0uR/solana-monitor/pkg/utils"
+	"github.com/gagliardetto/solana-go"
+)
+
+func openTestStore(t *testing.T) (*Store, string) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	store, err := Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return store, path
+}
+
+func newSignature() solana.Signature {
+	var sig solana.Signature
+	copy(sig[:], solana.NewWallet().PrivateKey)
+	return sig
+}
+
+func Test_migrate(t *testing.T) {
+	ctx := context.Background()
+	store, path := openTestStore(t)
+
+	version, err := store.SchemaVersion(ctx)
+	if err != nil || version != len(migrations) {
+		t.Fatalf("version %d (%v), want %d", version, err, len(migrations))
+	}
+	store.Close()
+
+	// Reopening must not apply the migrations again
+	store, err = Open(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if version, _ := store.SchemaVersion(ctx); version != len(migrations) {
+		t.Errorf("version %d after reopen", version)
+	}
+}
+
+func Test_SaveMarket(t *testing.T) {
+	ctx := context.Background()
+	store, _ := openTestStore(t)
+
+	info := &openbook.OpenbookInfo{
+		ProgramID:   solana.MustPublicKeyFromBase58(utils.OPENBOOK_PRGRAM_ID),
+		Version:     openbook.VersionV3,
+		Market:      solana.NewWallet().PublicKey(),
+		EventQueue:  solana.NewWallet().PublicKey(),
+		Bids:        solana.NewWallet().PublicKey(),
+		Asks:        solana.NewWallet().PublicKey(),
+		BaseMint:    solana.NewWallet().PublicKey(),
+		QuoteMint:   solana.WrappedSol,
+		BaseVault:   solana.NewWallet().PublicKey(),
+		QuoteVault:  solana.NewWallet().PublicKey(),
+		VaultSigner: solana.NewWallet().PublicKey(),
+		Caller:      solana.NewWallet().PublicKey(),
+		TxID:        newSignature(),
+		Slot:        250_000_000,
+		TxTime:      time.Unix(1716990000, 0),
+		Timestamp:   time.UnixMilli(1716990001234),
+		Swapped:     true,
+		Costs:       2.8,
+		Params:      openbook.MarketParams{BaseLotSize: 100_000, QuoteLotSize: 10, FeeRateBps: 25},
+	}
+
+	inserted, err := store.SaveMarket(ctx, info)
+	if err != nil || !inserted {
+		t.Fatalf("first save: %v %v", inserted, err)
+	}
+	if inserted, _ := store.SaveMarket(ctx, info); inserted {
+		t.Error("duplicate signature was inserted")
+	}
+
+	got, err := store.MarketByMint(ctx, info.BaseMint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *info {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", got, info)
+	}
+
+	if _, err := store.MarketByMint(ctx, solana.NewWallet().PublicKey()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if ok, _ := store.HasSignature(ctx, info.TxID.String()); !ok {
+		t.Error("signature not found")
+	}
+}
+
+func Test_SavePool(t *testing.T) {
+	ctx := context.Background()
+	store, _ := openTestStore(t)
+
+	mint := solana.NewWallet().PublicKey()
+	newPool := func(slot uint64) *Pool {
+		return &Pool{
+			TxID:            newSignature(),
+			ProgramID:       solana.MustPublicKeyFromBase58(utils.RAYDIUM_PROGRAM_ID),
+			Pool:            solana.NewWallet().PublicKey(),
+			OpenOrders:      solana.NewWallet().PublicKey(),
+			LPMint:          solana.NewWallet().PublicKey(),
+			BaseMint:        mint,
+			QuoteMint:       solana.WrappedSol,
+			BaseVault:       solana.NewWallet().PublicKey(),
+			QuoteVault:      solana.NewWallet().PublicKey(),
+			TargetOrders:    solana.NewWallet().PublicKey(),
+			LPAccount:       solana.NewWallet().PublicKey(),
+			SerumMarket:     solana.NewWallet().PublicKey(),
+			BaseLiquidity:   800_000_000.5,
+			QuoteLiquidity:  50,
+			InitBaseAmount:  18_000_000_000_000_000_000, // Does not fit an int64
+			InitQuoteAmount: 50_000_000_000,
+			Nonce:           254,
+			OpenTime:        1717000000,
+			Caller:          solana.NewWallet().PublicKey(),
+			Slot:            slot,
+			TxTime:          time.Unix(1717000100, 0),
+			Timestamp:       time.UnixMilli(1717000101500),
+		}
+	}
+
+	older, newer := newPool(100), newPool(200)
+	for _, info := range []*Pool{older, newer} {
+		if inserted, err := store.SavePool(ctx, info); err != nil || !inserted {
+			t.Fatalf("save: %v %v", inserted, err)
+		}
+	}
+	if inserted, _ := store.SavePool(ctx, older); inserted {
+		t.Error("duplicate signature was inserted")
+	}
+
+	pools, err := store.PoolsByMint(ctx, mint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 2 || *pools[0] != *newer || *pools[1] != *older {
+		t.Errorf("unexpected pools: %+v", pools)
+	}
+
+	if slot, err := store.LastSlot(ctx); err != nil || slot != 200 {
+		t.Errorf("last slot %d (%v), want 200", slot, err)
+	}
+}
+
+func Test_SaveEnrichment(t *testing.T) {
+	ctx := context.Background()
+	store, _ := openTestStore(t)
+
+	mint := solana.NewWallet().PublicKey()
+	if _, err := store.LatestEnrichment(ctx, mint.String()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	// A failed enrichment still leaves a snapshot behind
+	first := Enrichment{
+		Signature:  "first",
+		Mint:       mint.String(),
+		CapturedAt: time.UnixMilli(1000),
+		Errors:     map[string]string{"token": "failed to get mint account data"},
+	}
+	second := Enrichment{
+		Signature:            "second",
+		Mint:                 mint.String(),
+		CapturedAt:           time.UnixMilli(2000),
+		TokenData:            &utils.TokenData{Supply: 1_000_000, Decimals: 6, Mint: &mint, Data: utils.Data{Name: "Fixture"}},
+		TokenMeta:            &utils.TokenMeta{Name: "Fixture", Twitter: "https://x.com/fixture"},
+		MintAuthorityEnabled: true,
+		CreatorBalance:       12.5,
+		TopHolders:           []utils.TopHolder{{PublicKey: mint, Amount: 10}},
+	}
+	for _, e := range []Enrichment{first, second} {
+		if err := store.SaveEnrichment(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := store.LatestEnrichment(ctx, mint.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Signature != "second" || !got.MintAuthorityEnabled || got.FreezeAuthorityEnabled || got.CreatorBalance != 12.5 {
+		t.Errorf("unexpected snapshot %+v", got)
+	}
+	if got.TokenData == nil || got.TokenData.Supply != 1_000_000 || got.TokenData.Data.Name != "Fixture" || *got.TokenData.Mint != mint {
+		t.Errorf("token data not restored: %+v", got.TokenData)
+	}
+	if got.TokenMeta == nil || got.TokenMeta.Twitter != "https://x.com/fixture" {
+		t.Errorf("token meta not restored: %+v", got.TokenMeta)
+	}
+	if len(got.TopHolders) != 1 || got.TopHolders[0].PublicKey != mint || got.Errors != nil {
+		t.Errorf("holders or errors not restored: %+v %+v", got.TopHolders, got.Errors)
+	}
+}
+
+func Test_SaveNotification(t *testing.T) {
+	ctx := context.Background()
+	store, _ := openTestStore(t)
+
+	sig := newSignature().String()
+	for _, n := range []Notification{
+		{Signature: sig, Kind: "raydium", Hook: "discord", Status: "sent", CreatedAt: time.UnixMilli(1)},
+		{Signature: sig, Kind: "raydium", Hook: "telegram", Status: "failed", Error: "bad request", CreatedAt: time.UnixMilli(2)},
+		{Signature: "other", Kind: "openbook", Hook: "discord", Status: "sent", CreatedAt: time.UnixMilli(3)},
+	} {
+		if err := store.SaveNotification(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := store.Notifications(ctx, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Hook != "discord" || list[1].Status != "failed" || list[1].Error != "bad request" || list[0].Error != "" {
+		t.Errorf("unexpected notifications %+v", list)
+	}
+}
+
+func Test_SaveCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	store, _ := openTestStore(t)
+
+	if slot, err := store.Checkpoint(ctx, "raydium"); err != nil || slot != 0 {
+		t.Fatalf("unsaved checkpoint: %d (%v)", slot, err)
+	}
+
+	for _, slot := range []uint64{100, 300, 200} {
+		if err := store.SaveCheckpoint(ctx, "raydium", slot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.SaveCheckpoint(ctx, "openbook", 50)
+
+	if slot, _ := store.Checkpoint(ctx, "raydium"); slot != 300 {
+		t.Errorf("checkpoint moved backwards to %d", slot)
+	}
+	if slot, _ := store.Checkpoint(ctx, "openbook"); slot != 50 {
+		t.Errorf("openbook checkpoint %d, want 50", slot)
+	}
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs"
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// Most RPC providers reject getMultipleAccounts with more keys
+const maxMultipleAccounts = 100
+
+// CallBatch_S sends the requests as a single JSON-RPC batch, so they cost one round trip.
+// Only transport errors are retried, the responses may carry their own errors.
+// The responses are in the order of the requests.
+func CallBatch_S(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	for i, req := range requests {
+		req.ID = i
+		req.JSONRPC = "2.0"
+	}
+
+	var err error
+	for i := 0; i < 5; i++ {
+		wrapped_ctx, wrapped_cancel := context.WithTimeout(ctx, 8*time.Second)
+		var responses jsonrpc.RPCResponses
+		responses, err = rpcs.Hedge(wrapped_ctx, func(ctx context.Context, client *rpc.Client) (jsonrpc.RPCResponses, error) {
+			// The client writes to the requests, a hedged call gets its own copy
+			batch := make(jsonrpc.RPCRequests, len(requests))
+			for j, req := range requests {
+				copied := *req
+				batch[j] = &copied
+			}
+			return client.RPCCallBatch(ctx, batch)
+		})
+		wrapped_cancel()
+
+		if err == nil {
+			ordered := make(jsonrpc.RPCResponses, len(requests))
+			for j := range requests {
+				ordered[j] = responses.GetByID(j)
+				if ordered[j] == nil {
+					err = fmt.Errorf("no response to %s", requests[j].Method)
+					break
+				}
+			}
+			if err == nil {
+				return ordered, nil
+			}
+		}
+
+		if os.Getenv("DEBUG") == "1" {
+			color.New(color.FgYellow).Printf("CallBatch_S -> Failed to call batch, retrying (%d): %v\n", i+1, err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("failed to call batch: %w", err)
+}
+
+// GetMultipleAccounts_S returns the accounts in the order of keys, nil for accounts
+// that do not exist. More than 100 keys are split into several requests.
+func GetMultipleAccounts_S(ctx context.Context, keys ...solana.PublicKey) ([]*rpc.Account, error) {
+	accounts := make([]*rpc.Account, 0, len(keys))
+
+	for start := 0; start < len(keys); start += maxMultipleAccounts {
+		chunk := keys[start:min(start+maxMultipleAccounts, len(keys))]
+
+		var err error
+		var result *rpc.GetMultipleAccountsResult
+		for i := 0; i < 5; i++ {
+			wrapped_ctx, wrapped_cancel := context.WithTimeout(ctx, 5*time.Second)
+			result, err = rpcs.Hedge(wrapped_ctx, func(ctx context.Context, client *rpc.Client) (*rpc.GetMultipleAccountsResult, error) {
+				return client.GetMultipleAccountsWithOpts(ctx, chunk, &rpc.GetMultipleAccountsOpts{Encoding: solana.EncodingBase64})
+			})
+			wrapped_cancel()
+
+			if err == nil && len(result.Value) != len(chunk) {
+				err = fmt.Errorf("got %d accounts, want %d", len(result.Value), len(chunk))
+			}
+			if err == nil {
+				break
+			}
+
+			if os.Getenv("DEBUG") == "1" {
+				color.New(color.FgYellow).Printf("GetMultipleAccounts_S -> Failed to get accounts, retrying (%d): %v\n", i+1, err)
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get accounts: %w", err)
+		}
+
+		accounts = append(accounts, result.Value...)
+	}
+
+	return accounts, nil
+}
+
+// TokenAccounts is what the enrichment needs from the chain about a token and the
+// creator of its pool.
+type TokenAccounts struct {
+	TokenData      *TokenData
+	CreatorBalance float64      // SOL
+	TopHolders     *[]TopHolder // nil when the largest accounts could not be fetched
+	HoldersErr     error        // Why TopHolders is nil
+}
+
+// GetTokenAccounts_S fetches the mint, its metadata account, the creator account and
+// the largest token accounts in a single batch.
+func GetTokenAccounts_S(ctx context.Context, mint solana.PublicKey, creator solana.PublicKey) (*TokenAccounts, error) {
+	metadataAccount, _, err := solana.FindTokenMetadataAddress(mint)
+	if err != nil {
+		return nil, err
+	}
+
+	responses, err := CallBatch_S(ctx, jsonrpc.RPCRequests{
+		jsonrpc.NewRequest("getMultipleAccounts",
+			[]solana.PublicKey{mint, metadataAccount, creator},
+			rpc.M{"encoding": solana.EncodingBase64},
+		),
+		jsonrpc.NewRequest("getTokenLargestAccounts", mint, rpc.M{"commitment": rpc.CommitmentConfirmed}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if responses[0].Error != nil {
+		return nil, responses[0].Error
+	}
+	var result *rpc.GetMultipleAccountsResult
+	if err := responses[0].GetObject(&result); err != nil {
+		return nil, err
+	}
+	if result == nil || len(result.Value) != 3 {
+		return nil, errors.New("unexpected getMultipleAccounts result")
+	}
+
+	mintAccount, metadata, creatorAccount := result.Value[0], result.Value[1], result.Value[2]
+	if mintAccount == nil {
+		return nil, errors.New("failed to get mint account data")
+	}
+	mintData, extensions, err := decodeMint(mintAccount.Owner, mintAccount.Data.GetBinary())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode mint: %w", err)
+	}
+
+	// The token metadata extension takes precedence, like in GetTokendata
+	var metadataData []byte
+	if metadata != nil && (extensions == nil || extensions.TokenMetadata == nil) {
+		metadataData = metadata.Data.GetBinary()
+	}
+
+	accounts := &TokenAccounts{}
+	accounts.TokenData, err = tokenDataFrom(mint, mintData, extensions, metadataData)
+	if err != nil {
+		return nil, err
+	}
+	if creatorAccount != nil {
+		accounts.CreatorBalance = float64(creatorAccount.Lamports) / float64(solana.LAMPORTS_PER_SOL)
+	}
+
+	var holders *rpc.GetTokenLargestAccountsResult
+	if responses[1].Error != nil {
+		accounts.HoldersErr = responses[1].Error
+	} else if err := responses[1].GetObject(&holders); err != nil || holders == nil {
+		accounts.HoldersErr = fmt.Errorf("failed to decode largest accounts: %v", err)
+	} else {
+		topHolders := topHoldersFrom(holders)
+		accounts.TopHolders = &topHolders
+	}
+
+	return accounts, nil
+}
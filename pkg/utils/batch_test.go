@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs/rpctest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func Test_GetTokenAccounts(t *testing.T) {
+	ctx := context.Background()
+	replay := rpctest.Use(t, "testdata/token_accounts.json")
+
+	accounts, err := GetTokenAccounts_S(ctx, renouncedMint, solana.MustPublicKeyFromBase58("61UnMyUWYFEiKT93da35tyBBah5LpBNep85ciuLMGThS"))
+	if err != nil {
+		t.Fatalf("%v, misses: %v", err, replay.Misses())
+	}
+
+	td := accounts.TokenData
+	if td.Supply != 1_000_000_000_000_000 || td.Decimals != 6 || td.MintAuthority != nil || td.Data.Name != "Renounced Fixture" {
+		t.Errorf("unexpected token %+v", td)
+	}
+	if accounts.CreatorBalance != 2.5 {
+		t.Errorf("creator balance: got %v, want 2.5", accounts.CreatorBalance)
+	}
+	if accounts.HoldersErr != nil || accounts.TopHolders == nil || len(*accounts.TopHolders) != 4 || (*accounts.TopHolders)[0].Amount != 250_000_000 {
+		t.Errorf("unexpected holders %v, %v", accounts.TopHolders, accounts.HoldersErr)
+	}
+
+	// A failing getTokenLargestAccounts does not fail the batch
+	accounts, err = GetTokenAccounts_S(ctx, mintableMint, solana.MustPublicKeyFromBase58("AmsDWwmjS85PdmHFvFD6vsytcNWybB3DC9Yb8nR49acw"))
+	if err != nil {
+		t.Fatalf("%v, misses: %v", err, replay.Misses())
+	}
+	if accounts.TokenData.MintAuthority == nil || accounts.CreatorBalance != 0 {
+		t.Errorf("unexpected accounts %+v", accounts)
+	}
+	if accounts.TopHolders != nil || accounts.HoldersErr == nil {
+		t.Errorf("expected holders error, got %v", accounts.TopHolders)
+	}
+
+	if _, err := GetTokenAccounts_S(ctx, noMetadataMint, solana.MustPublicKeyFromBase58("AmsDWwmjS85PdmHFvFD6vsytcNWybB3DC9Yb8nR49acw")); err == nil {
+		t.Error("expected error without fixture")
+	}
+}
@@ -5,14 +5,30 @@ import "github.com/gagliardetto/solana-go"
 var USDC_MINT_PUBKEY = solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
 
 const (
-	RAYDIUM_PROGRAM_ID   = "675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8"
-	OPENBOOK_PRGRAM_ID   = "srmqPvymJeFKQ4zGQed1GFppgkRHL9kaELCbyksJtPX"
-	TOKEN_PROGRAM_ID     = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
-	RAYDIUM_AUTHORITY_ID = "5Q544fKrFoe6tsEbD7S8EmxGTJYAKtTVhAW5Q5pge4j1"
+	RAYDIUM_PROGRAM_ID      = "675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8"
+	OPENBOOK_PRGRAM_ID      = "srmqPvymJeFKQ4zGQed1GFppgkRHL9kaELCbyksJtPX"
+	OPENBOOK_V2_PROGRAM_ID  = "opnb2LAfJYbRMAHHvqjCwQxanZn7ReEHp1k81EohpZb"
+	TOKEN_PROGRAM_ID        = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
+	TOKEN_2022_PROGRAM_ID   = "TokenzQdBNbLqP5VEhdkAS6EPFLC1PCnBqCXEpPxuEb"
+	RAYDIUM_AUTHORITY_ID    = "5Q544fKrFoe6tsEbD7S8EmxGTJYAKtTVhAW5Q5pge4j1"
+	RAYDIUM_CPMM_PROGRAM_ID = "CPMMoo8L3F4NbTegBCKVNunggL7H1ZpdTHKxQB5qKP1C"
+	RAYDIUM_CLMM_PROGRAM_ID = "CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK"
+	PUMPFUN_PROGRAM_ID      = "6EF8rrecthR5Dkzon8Nwu78hRvfCKubJ14M5uBEwF6P"
+	METEORA_DLMM_PROGRAM_ID = "LBUZKhRxPF3XUpBCjp4YzTKgLccjZhTSDM9t6CbFHSo"
+	METEORA_AMM_PROGRAM_ID  = "Eo7WjKq67rjJQSZxS6z3YkapzY3eMj6Xy8X5EQVn5UaB"
+	WHIRLPOOL_PROGRAM_ID    = "whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc"
+	PUMPFUN_MIGRATION_ID    = "39azUYFWPz3VHgKCf3VChUwbpURdCHRxjWVowf5jUJjg"
+	STREAMFLOW_PROGRAM_ID   = "strmRqUCoQUgGUan5YhzUZa6KqdzwX5L6FpUxfmKg5m"
 
-	RAYDIUM_IDENTIFIER   = "initialize2"
-	OPENBOOK_IDENTIFIER  = "Program srmqPvymJeFKQ4zGQed1GFppgkRHL9kaELCbyksJtPX success"
-	TOKENMINT_IDENTIFIER = "InitializeMint"
+	RAYDIUM_IDENTIFIER      = "initialize2"
+	RAYDIUM_CPMM_IDENTIFIER = "Program log: Instruction: Initialize"
+	RAYDIUM_CLMM_IDENTIFIER = "Program log: Instruction: CreatePool"
+	METEORA_DLMM_IDENTIFIER = "Program log: Instruction: InitializeLbPair"
+	METEORA_AMM_IDENTIFIER  = "Program log: Instruction: InitializePermissionless"
+	WHIRLPOOL_IDENTIFIER    = "Program log: Instruction: InitializePool"
+	OPENBOOK_IDENTIFIER     = "Program srmqPvymJeFKQ4zGQed1GFppgkRHL9kaELCbyksJtPX success"
+	OPENBOOK_V2_IDENTIFIER  = "Program log: Instruction: CreateMarket"
+	TOKENMINT_IDENTIFIER    = "InitializeMint"
 
 	WRAPPED_SOL_MINT = "So11111111111111111111111111111111111111112"
 
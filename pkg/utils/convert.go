@@ -3,6 +3,7 @@ package utils
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gagliardetto/solana-go"
 )
@@ -22,6 +23,33 @@ func I64tS(i int64) string {
 	return strconv.FormatInt(i, 10)
 }
 
+// DurtS converts a duration to its largest unit and the next one, e.g. 2d 5h or 13m 20s
+func DurtS(d time.Duration) string {
+	d = d.Round(time.Second)
+	units := []struct {
+		size   time.Duration
+		suffix string
+	}{
+		{24 * time.Hour, "d"}, {time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"},
+	}
+
+	for i, unit := range units {
+		if d < unit.size && unit.size != time.Second {
+			continue
+		}
+
+		str := I64tS(int64(d/unit.size)) + unit.suffix
+		if i+1 < len(units) {
+			if next := (d % unit.size) / units[i+1].size; next > 0 {
+				str += " " + I64tS(int64(next)) + units[i+1].suffix
+			}
+		}
+		return str
+	}
+
+	return "0s"
+}
+
 // SocialstS converts token meta socials to a formatted form
 // and returns the string representation
 func SocialstS(twitter string, telegram string, website string) string {
@@ -73,3 +101,8 @@ func TokenToSymbol(token solana.PublicKey) string {
 		return "N/A"
 	}
 }
+
+// IsQuoteMint reports whether pairs against token are quoted in it, i.e. it is SOL or USDC.
+func IsQuoteMint(token solana.PublicKey) bool {
+	return token == solana.WrappedSol || token == USDC_MINT_PUBKEY
+}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FetchPolicy controls how GetConfirmedTransaction_S waits for transactions the RPC
+// node has not indexed yet. Not found is retried until VisibilityDeadline, other
+// errors MaxErrors times, both with exponential backoff and jitter.
+type FetchPolicy struct {
+	VisibilityDeadline time.Duration
+	InitialBackoff     time.Duration
+	MaxBackoff         time.Duration
+	MaxErrors          int
+}
+
+var TransactionFetchPolicy = FetchPolicy{
+	VisibilityDeadline: 20 * time.Second,
+	InitialBackoff:     200 * time.Millisecond,
+	MaxBackoff:         2 * time.Second,
+	MaxErrors:          5,
+}
+
+// backoff returns the wait before the next attempt, between half and the full
+// exponential delay so concurrent fetches do not retry in lockstep.
+func (p FetchPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 0; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, p.MaxBackoff)
+	if d <= 0 {
+		return 0
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// Outcomes of a transaction fetch.
+const (
+	FetchFound     = "found"
+	FetchNotFound  = "not found" // Not visible before the deadline
+	FetchFailed    = "failed"
+	FetchCancelled = "cancelled"
+)
+
+// Upper bounds of the latency buckets, slower fetches land in the last bucket.
+var latencyBuckets = []time.Duration{
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	20 * time.Second,
+}
+
+// LatencyHistogram counts latencies per bucket of latencyBuckets.
+type LatencyHistogram struct {
+	Outcome string
+	Counts  []uint64 // One per bucket plus one for anything slower
+	Count   uint64
+	Sum     time.Duration
+}
+
+func (h *LatencyHistogram) observe(latency time.Duration) {
+	if h.Counts == nil {
+		h.Counts = make([]uint64, len(latencyBuckets)+1)
+	}
+
+	i := sort.Search(len(latencyBuckets), func(i int) bool { return latency <= latencyBuckets[i] })
+	h.Counts[i]++
+	h.Count++
+	h.Sum += latency
+}
+
+// Quantile returns the upper bound of the bucket the q-th latency falls in,
+// -1 if it is slower than the last bucket.
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	rank := uint64(q * float64(h.Count))
+	if rank >= h.Count {
+		rank = h.Count - 1
+	}
+
+	var seen uint64
+	for i, count := range h.Counts {
+		seen += count
+		if seen > rank {
+			if i == len(latencyBuckets) {
+				return -1
+			}
+			return latencyBuckets[i]
+		}
+	}
+	return -1
+}
+
+func (h LatencyHistogram) String() string {
+	if h.Count == 0 {
+		return fmt.Sprintf("%s: 0", h.Outcome)
+	}
+
+	quantile := func(q float64) string {
+		if d := h.Quantile(q); d >= 0 {
+			return "≤" + d.String()
+		}
+		return ">" + latencyBuckets[len(latencyBuckets)-1].String()
+	}
+
+	parts := []string{
+		fmt.Sprintf("%s: %d", h.Outcome, h.Count),
+		"avg " + (h.Sum / time.Duration(h.Count)).Round(time.Millisecond).String(),
+		"p50 " + quantile(0.5),
+		"p90 " + quantile(0.9),
+		"p99 " + quantile(0.99),
+	}
+	return strings.Join(parts, ", ")
+}
+
+var fetchHistograms = make(map[string]*LatencyHistogram)
+var fetchHistogramsMutex = &sync.Mutex{}
+
+func observeFetch(outcome string, latency time.Duration) {
+	fetchHistogramsMutex.Lock()
+	defer fetchHistogramsMutex.Unlock()
+
+	h, ok := fetchHistograms[outcome]
+	if !ok {
+		h = &LatencyHistogram{Outcome: outcome}
+		fetchHistograms[outcome] = h
+	}
+	h.observe(latency)
+}
+
+// TransactionFetchStats returns the latency histogram of every fetch outcome, sorted by outcome.
+func TransactionFetchStats() []LatencyHistogram {
+	fetchHistogramsMutex.Lock()
+	defer fetchHistogramsMutex.Unlock()
+
+	list := make([]LatencyHistogram, 0, len(fetchHistograms))
+	for _, h := range fetchHistograms {
+		snapshot := *h
+		snapshot.Counts = append([]uint64(nil), h.Counts...)
+		list = append(list, snapshot)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Outcome < list[j].Outcome })
+
+	return list
+}
+
+// sleepCtx waits for d, it returns false if ctx was cancelled first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
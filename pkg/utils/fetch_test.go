@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"golang.org/x/time/rate"
+)
+
+// lateClient answers getTransaction with null until the transaction becomes visible,
+// or with err if set.
+type lateClient struct {
+	mutex   sync.Mutex
+	calls   int
+	visible int // Call from which the transaction is returned
+	err     error
+	result  json.RawMessage
+}
+
+func (c *lateClient) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.calls++
+	if c.err != nil {
+		return c.err
+	}
+	if c.visible == 0 || c.calls < c.visible {
+		return nil
+	}
+	return json.Unmarshal(c.result, out)
+}
+
+func (c *lateClient) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return errors.New("not implemented")
+}
+
+func (c *lateClient) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, errors.New("not implemented")
+}
+
+func useLateClient(t *testing.T, client *lateClient) {
+	tx := &solana.Transaction{
+		Signatures: []solana.Signature{{1}},
+		Message: solana.Message{
+			AccountKeys: solana.PublicKeySlice{solana.NewWallet().PublicKey()},
+			Header:      solana.MessageHeader{NumRequiredSignatures: 1},
+		},
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.result, _ = json.Marshal(map[string]any{
+		"slot":        1,
+		"meta":        map[string]any{"err": nil, "fee": 5000, "preBalances": []uint64{1}, "postBalances": []uint64{1}},
+		"transaction": []string{base64.StdEncoding.EncodeToString(raw), "base64"},
+	})
+
+	policy := TransactionFetchPolicy
+	TransactionFetchPolicy = FetchPolicy{
+		VisibilityDeadline: 200 * time.Millisecond,
+		InitialBackoff:     5 * time.Millisecond,
+		MaxBackoff:         20 * time.Millisecond,
+		MaxErrors:          3,
+	}
+	rpcs.SetEndpoints(rpcs.NewEndpoint("late", client, rate.Inf))
+	t.Cleanup(func() {
+		TransactionFetchPolicy = policy
+		rpcs.SetEndpoints()
+	})
+}
+
+func fetchCount(outcome string) uint64 {
+	for _, h := range TransactionFetchStats() {
+		if h.Outcome == outcome {
+			return h.Count
+		}
+	}
+	return 0
+}
+
+func Test_GetConfirmedTransactionVisibility(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("visible after retries", func(t *testing.T) {
+		client := &lateClient{visible: 3}
+		useLateClient(t, client)
+		found := fetchCount(FetchFound)
+
+		rpcTx, tx, err := GetConfirmedTransaction_S(ctx, solana.Signature{1})
+		if err != nil || rpcTx == nil || tx == nil {
+			t.Fatalf("transaction not fetched: %v", err)
+		}
+		if client.calls != 3 {
+			t.Errorf("%d calls, want 3", client.calls)
+		}
+		if fetchCount(FetchFound) != found+1 {
+			t.Error("fetch not counted as found")
+		}
+	})
+
+	t.Run("never visible", func(t *testing.T) {
+		client := &lateClient{}
+		useLateClient(t, client)
+		notFound := fetchCount(FetchNotFound)
+
+		start := time.Now()
+		_, _, err := GetConfirmedTransaction_S(ctx, solana.Signature{1})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		// Not found does not use up the error retries
+		if client.calls <= TransactionFetchPolicy.MaxErrors {
+			t.Errorf("gave up after %d calls", client.calls)
+		}
+		if elapsed := time.Since(start); elapsed > TransactionFetchPolicy.VisibilityDeadline+100*time.Millisecond {
+			t.Errorf("waited %v past the deadline", elapsed)
+		}
+		if fetchCount(FetchNotFound) != notFound+1 {
+			t.Error("fetch not counted as not found")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		client := &lateClient{err: errors.New("connection reset")}
+		useLateClient(t, client)
+
+		if _, _, err := GetConfirmedTransaction_S(ctx, solana.Signature{1}); err == nil {
+			t.Fatal("expected an error")
+		}
+		if client.calls != TransactionFetchPolicy.MaxErrors {
+			t.Errorf("%d calls, want %d", client.calls, TransactionFetchPolicy.MaxErrors)
+		}
+	})
+}
+
+func Test_LatencyHistogram(t *testing.T) {
+	h := LatencyHistogram{Outcome: FetchFound}
+	for _, latency := range []time.Duration{50 * time.Millisecond, 80 * time.Millisecond, 300 * time.Millisecond, 30 * time.Second} {
+		h.observe(latency)
+	}
+
+	if h.Count != 4 || h.Counts[0] != 2 || h.Counts[2] != 1 || h.Counts[len(latencyBuckets)] != 1 {
+		t.Fatalf("unexpected buckets %v", h.Counts)
+	}
+	if q := h.Quantile(0.5); q != 500*time.Millisecond {
+		t.Errorf("p50 %v, want 500ms", q)
+	}
+	if q := h.Quantile(0.25); q != 100*time.Millisecond {
+		t.Errorf("p25 %v, want 100ms", q)
+	}
+	if q := h.Quantile(0.99); q != -1 {
+		t.Errorf("p99 %v, want beyond the last bucket", q)
+	}
+}
+
+func Test_backoff(t *testing.T) {
+	policy := FetchPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		want *= time.Millisecond
+		if d := policy.backoff(attempt); d < want/2 || d > want {
+			t.Errorf("attempt %d: %v outside [%v, %v]", attempt, d, want/2, want)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+)
+
+// Token-2022 mints start with the legacy layout, extensions follow after the
+// account type at offset 165 (the size of a token account) as type-length-value entries.
+const (
+	mintSize             = 82
+	extensionsOffset     = 165
+	accountTypeMint      = 1
+	extensionHeaderSize  = 4
+	pubkeySize           = 32
+	transferFeeSize      = 18 // epoch, maximum fee, basis points
+	transferFeeConfigLen = 2*pubkeySize + 8 + 2*transferFeeSize
+)
+
+// Token-2022 extension types that matter for a new token.
+const (
+	extensionTransferFeeConfig     = 1
+	extensionNonTransferable       = 9
+	extensionInterestBearingConfig = 10
+	extensionPermanentDelegate     = 12
+	extensionTransferHook          = 14
+	extensionMetadataPointer       = 18
+	extensionTokenMetadata         = 19
+)
+
+type TransferFee struct {
+	Epoch       uint64
+	MaximumFee  uint64
+	BasisPoints uint16
+}
+
+type TransferFeeConfig struct {
+	Authority         *solana.PublicKey
+	WithdrawAuthority *solana.PublicKey
+	WithheldAmount    uint64
+	Older             TransferFee
+	Newer             TransferFee // Applies from Newer.Epoch on
+}
+
+type InterestBearingConfig struct {
+	RateAuthority *solana.PublicKey
+	CurrentRate   int16 // Basis points per year
+}
+
+type TransferHook struct {
+	Authority *solana.PublicKey
+	ProgramID *solana.PublicKey // Invoked on every transfer when set
+}
+
+type MetadataPointer struct {
+	Authority       *solana.PublicKey
+	MetadataAddress *solana.PublicKey
+}
+
+type TokenMetadata struct {
+	UpdateAuthority    *solana.PublicKey
+	Mint               solana.PublicKey
+	Name               string
+	Symbol             string
+	Uri                string
+	AdditionalMetadata [][2]string
+}
+
+// TokenExtensions are the Token-2022 extensions of a mint, nil fields are absent.
+type TokenExtensions struct {
+	TransferFee       *TransferFeeConfig
+	PermanentDelegate *solana.PublicKey
+	NonTransferable   bool
+	InterestBearing   *InterestBearingConfig
+	MetadataPointer   *MetadataPointer
+	TokenMetadata     *TokenMetadata
+	TransferHook      *TransferHook
+}
+
+// Warnings describes the extensions that let the issuer take or lock tokens of holders.
+func (e *TokenExtensions) Warnings() []string {
+	if e == nil {
+		return nil
+	}
+
+	var warnings []string
+	if e.PermanentDelegate != nil {
+		warnings = append(warnings, "Permanent delegate: "+e.PermanentDelegate.String())
+	}
+	if e.TransferHook != nil && e.TransferHook.ProgramID != nil {
+		warnings = append(warnings, "Transfer hook: "+e.TransferHook.ProgramID.String())
+	}
+	if e.TransferFee != nil {
+		fee := max(e.TransferFee.Older.BasisPoints, e.TransferFee.Newer.BasisPoints)
+		if fee > 0 || e.TransferFee.Authority != nil {
+			warnings = append(warnings, "Transfer fee: "+strconv.FormatFloat(float64(fee)/100, 'f', 2, 64)+"%")
+		}
+	}
+	if e.NonTransferable {
+		warnings = append(warnings, "Non-transferable")
+	}
+	if e.InterestBearing != nil {
+		warnings = append(warnings, "Interest bearing: "+strconv.FormatFloat(float64(e.InterestBearing.CurrentRate)/100, 'f', 2, 64)+"%")
+	}
+
+	return warnings
+}
+
+// decodeMint decodes a legacy or Token-2022 mint account, extensions is nil for legacy mints.
+func decodeMint(owner solana.PublicKey, data []byte) (*token.Mint, *TokenExtensions, error) {
+	var mint token.Mint
+	if err := bin.NewBinDecoder(data).Decode(&mint); err != nil {
+		return nil, nil, err
+	}
+
+	if owner.String() != TOKEN_2022_PROGRAM_ID {
+		return &mint, nil, nil
+	}
+
+	extensions, err := decodeExtensions(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode token-2022 extensions: %w", err)
+	}
+	return &mint, extensions, nil
+}
+
+func decodeExtensions(data []byte) (*TokenExtensions, error) {
+	extensions := &TokenExtensions{}
+	if len(data) <= mintSize {
+		return extensions, nil
+	}
+	if len(data) <= extensionsOffset || data[extensionsOffset] != accountTypeMint {
+		return nil, errors.New("not a mint account")
+	}
+
+	rest := data[extensionsOffset+1:]
+	for len(rest) >= extensionHeaderSize {
+		kind := binary.LittleEndian.Uint16(rest)
+		length := int(binary.LittleEndian.Uint16(rest[2:]))
+		if kind == 0 {
+			break // Uninitialized, the remainder is padding
+		}
+		if len(rest) < extensionHeaderSize+length {
+			return nil, fmt.Errorf("extension %d truncated", kind)
+		}
+		value := rest[extensionHeaderSize : extensionHeaderSize+length]
+		rest = rest[extensionHeaderSize+length:]
+
+		var err error
+		switch kind {
+		case extensionTransferFeeConfig:
+			extensions.TransferFee, err = decodeTransferFeeConfig(value)
+		case extensionNonTransferable:
+			extensions.NonTransferable = true
+		case extensionInterestBearingConfig:
+			if len(value) < pubkeySize+8+2+8+2 {
+				err = errors.New("interest bearing config truncated")
+				break
+			}
+			extensions.InterestBearing = &InterestBearingConfig{
+				RateAuthority: optionalPubkey(value),
+				CurrentRate:   int16(binary.LittleEndian.Uint16(value[pubkeySize+8+2+8:])),
+			}
+		case extensionPermanentDelegate:
+			if len(value) < pubkeySize {
+				err = errors.New("permanent delegate truncated")
+				break
+			}
+			extensions.PermanentDelegate = optionalPubkey(value)
+		case extensionTransferHook:
+			if len(value) < 2*pubkeySize {
+				err = errors.New("transfer hook truncated")
+				break
+			}
+			extensions.TransferHook = &TransferHook{
+				Authority: optionalPubkey(value),
+				ProgramID: optionalPubkey(value[pubkeySize:]),
+			}
+		case extensionMetadataPointer:
+			if len(value) < 2*pubkeySize {
+				err = errors.New("metadata pointer truncated")
+				break
+			}
+			extensions.MetadataPointer = &MetadataPointer{
+				Authority:       optionalPubkey(value),
+				MetadataAddress: optionalPubkey(value[pubkeySize:]),
+			}
+		case extensionTokenMetadata:
+			extensions.TokenMetadata, err = decodeTokenMetadata(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return extensions, nil
+}
+
+func decodeTransferFeeConfig(value []byte) (*TransferFeeConfig, error) {
+	if len(value) < transferFeeConfigLen {
+		return nil, errors.New("transfer fee config truncated")
+	}
+
+	fee := func(b []byte) TransferFee {
+		return TransferFee{
+			Epoch:       binary.LittleEndian.Uint64(b),
+			MaximumFee:  binary.LittleEndian.Uint64(b[8:]),
+			BasisPoints: binary.LittleEndian.Uint16(b[16:]),
+		}
+	}
+
+	return &TransferFeeConfig{
+		Authority:         optionalPubkey(value),
+		WithdrawAuthority: optionalPubkey(value[pubkeySize:]),
+		WithheldAmount:    binary.LittleEndian.Uint64(value[2*pubkeySize:]),
+		Older:             fee(value[2*pubkeySize+8:]),
+		Newer:             fee(value[2*pubkeySize+8+transferFeeSize:]),
+	}, nil
+}
+
+func decodeTokenMetadata(value []byte) (*TokenMetadata, error) {
+	var raw struct {
+		UpdateAuthority    solana.PublicKey
+		Mint               solana.PublicKey
+		Name               string
+		Symbol             string
+		Uri                string
+		AdditionalMetadata [][2]string
+	}
+	if err := bin.NewBorshDecoder(value).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("token metadata: %w", err)
+	}
+
+	return &TokenMetadata{
+		UpdateAuthority:    optionalPubkey(raw.UpdateAuthority[:]),
+		Mint:               raw.Mint,
+		Name:               raw.Name,
+		Symbol:             raw.Symbol,
+		Uri:                raw.Uri,
+		AdditionalMetadata: raw.AdditionalMetadata,
+	}, nil
+}
+
+// optionalPubkey reads an OptionalNonZeroPubkey, all zeroes means none.
+func optionalPubkey(b []byte) *solana.PublicKey {
+	key := solana.PublicKeyFromBytes(b[:pubkeySize])
+	if key.IsZero() {
+		return nil
+	}
+	return &key
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -49,50 +50,23 @@ type TokenData struct {
 	PrimarySaleHappened bool
 	IsMutable           bool
 	EditionNonce        *uint8
+
+	// Token-2022 extensions, nil for mints of the legacy token program.
+	Extensions *TokenExtensions
 	// TokenStandard       uint8 // borsh.Enum
 	// Collection          *Collection
 	// Uses                *Uses
 	// CollectionDetails   *CollectionDetails
 }
 
-func getAccountData_S(ctx context.Context, tokenKey solana.PublicKey) *token.Mint {
-	var mint *token.Mint
-
-	for i := 0; i < 5; i++ {
-		client := rpcs.BorrowClient()
-
-		wrapped_ctx, wrapped_cancel := context.WithTimeout(ctx, 8*time.Second)
-		err := client.GetAccountDataInto(wrapped_ctx, tokenKey, &mint)
-		wrapped_cancel()
-
-		if err != nil {
-			if os.Getenv("DEBUG") == "1" {
-				color.New(color.FgYellow).Printf("getAccountData -> Failed to get account data, retrying (%d): %v\n", i+1, err)
-			}
-			continue
-		}
-
-		break
-	}
-
-	if mint == nil {
-		if os.Getenv("DEBUG") == "1" {
-			color.New(color.FgYellow).Printf("getAccountData -> Failed to get account data after 5 attempts\n")
-		}
-		return nil
-	}
-
-	return mint
-}
-
 func getAccountInfo_S(ctx context.Context, metadataAccount solana.PublicKey) *rpc.GetAccountInfoResult {
 	var accountInfo *rpc.GetAccountInfoResult
 
 	for i := 0; i < 5; i++ {
-		client := rpcs.BorrowClient()
-
 		wrapped_ctx, wrapped_cancel := context.WithTimeout(ctx, 5*time.Second)
-		tmp_accountInfo, err := client.GetAccountInfo(wrapped_ctx, metadataAccount)
+		tmp_accountInfo, err := rpcs.Hedge(wrapped_ctx, func(ctx context.Context, client *rpc.Client) (*rpc.GetAccountInfoResult, error) {
+			return client.GetAccountInfo(ctx, metadataAccount)
+		})
 		wrapped_cancel()
 
 		if err != nil {
@@ -117,83 +91,117 @@ func getAccountInfo_S(ctx context.Context, metadataAccount solana.PublicKey) *rp
 }
 
 func GetTokendata(ctx context.Context, tokenKey solana.PublicKey, mayFail bool) (*TokenData, error) {
-	var mint *token.Mint
-	var metadataAccount solana.PublicKey
-	var accountInfo *rpc.GetAccountInfoResult
+	var client *rpc.Client
+	var mintAccount *rpc.Account
 
 	if mayFail {
 		// No retries, just return error if failed
-		client := rpcs.BorrowClient()
+		client = rpcs.BorrowClient()
 
 		wrapped_ctx, wrapped_cancel := context.WithTimeout(ctx, 5*time.Second)
-		err := client.GetAccountDataInto(wrapped_ctx, tokenKey, &mint)
+		accountInfo, err := client.GetAccountInfo(wrapped_ctx, tokenKey)
 		wrapped_cancel()
 
 		if err != nil {
 			return nil, err
 		}
-
-		metadataAccountTmp, _, err := solana.FindTokenMetadataAddress(tokenKey)
-		if err != nil {
-			return nil, err
-		}
-
-		metadataAccount = metadataAccountTmp
-
-		accountInfo, err = client.GetAccountInfo(ctx, metadataAccount)
-		if err != nil {
-			return nil, err
-		}
+		mintAccount = accountInfo.Value
 	} else {
 		// Includes 5 retries
-		mint = getAccountData_S(ctx, tokenKey)
-		if mint == nil {
+		accountInfo := getAccountInfo_S(ctx, tokenKey)
+		if accountInfo == nil {
 			return nil, errors.New("failed to get mint account data")
 		}
+		mintAccount = accountInfo.Value
+	}
+
+	mint, extensions, err := decodeMint(mintAccount.Owner, mintAccount.Data.GetBinary())
+	if err != nil {
+		return nil, err
+	}
+
+	// Token-2022 mints can carry their metadata themselves
+	if extensions != nil && extensions.TokenMetadata != nil {
+		return tokenDataFrom(tokenKey, mint, extensions, nil)
+	}
+
+	metadataAccount, _, err := solana.FindTokenMetadataAddress(tokenKey)
+	if err != nil {
+		return nil, err
+	}
 
-		metadataAccountTmp, _, err := solana.FindTokenMetadataAddress(tokenKey)
+	var accountInfo *rpc.GetAccountInfoResult
+	if mayFail {
+		accountInfo, err = client.GetAccountInfo(ctx, metadataAccount)
 		if err != nil {
 			return nil, err
 		}
-		metadataAccount = metadataAccountTmp
-
+	} else {
 		accountInfo = getAccountInfo_S(ctx, metadataAccount)
 		if accountInfo == nil {
 			return nil, errors.New("failed to get metadata account info")
 		}
 	}
 
-	data, err := token_metadata.MetadataDeserialize(accountInfo.Value.Data.GetBinary())
-	if err != nil {
-		return nil, err
-	}
-
-	// mintAddress := solana.MustPublicKeyFromBase58(data.Mint.String())
-	updateAuthority := solana.MustPublicKeyFromBase58(data.UpdateAuthority.String())
+	return tokenDataFrom(tokenKey, mint, extensions, accountInfo.Value.Data.GetBinary())
+}
 
+// tokenDataFrom combines the mint with its metaplex metadata account data, or with the
+// token metadata extension when metadata is nil.
+func tokenDataFrom(tokenKey solana.PublicKey, mint *token.Mint, extensions *TokenExtensions, metadata []byte) (*TokenData, error) {
 	tokenData := TokenData{
 		MintAuthority:   mint.MintAuthority,
 		Supply:          mint.Supply,
 		Decimals:        mint.Decimals,
 		IsInitialized:   mint.IsInitialized,
 		FreezeAuthority: mint.FreezeAuthority,
-		Key:             uint8(data.Key),
-		UpdateAuthority: &updateAuthority,
 		Mint:            &tokenKey,
-		Data: Data{
-			Name:   data.Data.Name,
-			Symbol: data.Data.Symbol,
-			Uri:    data.Data.Uri,
-		},
-		PrimarySaleHappened: data.PrimarySaleHappened,
-		IsMutable:           data.IsMutable,
-		EditionNonce:        data.EditionNonce,
-		// TokenStandard:       uint8(*data.TokenStandard), nil check required
+		Extensions:      extensions,
+	}
+
+	if metadata == nil {
+		if extensions == nil || extensions.TokenMetadata == nil {
+			return nil, errors.New("failed to get metadata account info")
+		}
+
+		embedded := extensions.TokenMetadata
+		tokenData.UpdateAuthority = embedded.UpdateAuthority
+		tokenData.Data = Data{
+			Name:   embedded.Name,
+			Symbol: embedded.Symbol,
+			Uri:    embedded.Uri,
+		}
+		// Without an update authority the metadata can no longer change
+		tokenData.IsMutable = embedded.UpdateAuthority != nil
+		return &tokenData, nil
+	}
+
+	data, err := token_metadata.MetadataDeserialize(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	// mintAddress := solana.MustPublicKeyFromBase58(data.Mint.String())
+	updateAuthority := solana.MustPublicKeyFromBase58(data.UpdateAuthority.String())
+
+	tokenData.Key = uint8(data.Key)
+	tokenData.UpdateAuthority = &updateAuthority
+	tokenData.Data = Data{
+		Name:   data.Data.Name,
+		Symbol: data.Data.Symbol,
+		Uri:    data.Data.Uri,
 	}
+	tokenData.PrimarySaleHappened = data.PrimarySaleHappened
+	tokenData.IsMutable = data.IsMutable
+	tokenData.EditionNonce = data.EditionNonce
+	// TokenStandard:       uint8(*data.TokenStandard), nil check required
 
 	return &tokenData, nil
 }
 
+// MetadataClient fetches the off-chain token metadata, tests replace it with a fixture transport.
+var MetadataClient = http.DefaultClient
+
 type TokenMetaExtensions struct {
 	Website  string `json:"website"`
 	Twitter  string `json:"twitter"`
@@ -213,7 +221,7 @@ type TokenMeta struct {
 	Extensions  TokenMetaExtensions `json:"extensions"`
 }
 
-func FetchTokenMeta(uri string) (*TokenMeta, error) {
+func FetchTokenMeta(ctx context.Context, uri string) (*TokenMeta, error) {
 	if strings.Contains(uri, "ipfs.nftstorage.link") {
 		uri = strings.Split(uri, "https://")[1]
 		uri = strings.Split(uri, ".ipfs.nftstorage.link")[0]
@@ -223,17 +231,21 @@ func FetchTokenMeta(uri string) (*TokenMeta, error) {
 
 	var meta TokenMeta
 
-	req, err := http.NewRequest("GET", uri, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := MetadataClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d fetching token meta", resp.StatusCode)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
@@ -288,10 +300,10 @@ func GetTopHolders_S(ctx context.Context, mint solana.PublicKey) *[]TopHolder {
 	var rpcAccounts *rpc.GetTokenLargestAccountsResult
 
 	for i := 0; i < 5; i++ {
-		client := rpcs.BorrowClient()
-
 		wrapped_ctx, wrapped_cancel := context.WithTimeout(ctx, 5*time.Second)
-		tmp_accounts, err := client.GetTokenLargestAccounts(wrapped_ctx, mint, rpc.CommitmentConfirmed)
+		tmp_accounts, err := rpcs.Hedge(wrapped_ctx, func(ctx context.Context, client *rpc.Client) (*rpc.GetTokenLargestAccountsResult, error) {
+			return client.GetTokenLargestAccounts(ctx, mint, rpc.CommitmentConfirmed)
+		})
 		wrapped_cancel()
 
 		if err != nil {
@@ -303,12 +315,18 @@ func GetTopHolders_S(ctx context.Context, mint solana.PublicKey) *[]TopHolder {
 		break
 	}
 
-	var topHolders []TopHolder
 	if rpcAccounts == nil {
 		color.New(color.FgRed).Printf("GetTopHolders_S -> Failed to get account info after 5 attempts\n")
-		return &topHolders
+		return &[]TopHolder{}
 	}
 
+	topHolders := topHoldersFrom(rpcAccounts)
+	return &topHolders
+}
+
+// topHoldersFrom sorts the largest accounts by amount, accounts without uiAmount are skipped.
+func topHoldersFrom(rpcAccounts *rpc.GetTokenLargestAccountsResult) []TopHolder {
+	var topHolders []TopHolder
 	for _, account := range rpcAccounts.Value {
 		if account.UiTokenAmount.UiAmount == nil {
 			continue
@@ -329,7 +347,7 @@ func GetTopHolders_S(ctx context.Context, mint solana.PublicKey) *[]TopHolder {
 		}
 	}
 
-	return &topHolders
+	return topHolders
 }
 
 func TokenHelper(ctx context.Context, token solana.PublicKey) (*TokenData, *TokenMeta) {
@@ -345,17 +363,27 @@ func TokenHelper(ctx context.Context, token solana.PublicKey) (*TokenData, *Toke
 		return nil, nil
 	}
 
+	btm := TokenMetaHelper(ctx, btd)
+	if btm == nil {
+		return nil, nil
+	}
+
+	return btd, btm
+}
+
+// TokenMetaHelper fetches the off-chain metadata of btd, nil if it has none or it is unavailable.
+func TokenMetaHelper(ctx context.Context, btd *TokenData) *TokenMeta {
 	if btd.Data.Uri == "" {
 		color.New(color.FgYellow).Printf("Token (%s) data had no metadata URI, skipping it.\n", btd.Mint.String())
-		return nil, nil
+		return nil
 	}
 
-	btm, err := FetchTokenMeta(btd.Data.Uri)
+	btm, err := FetchTokenMeta(ctx, btd.Data.Uri)
 	if err != nil {
 		if os.Getenv("DEBUG") == "1" {
 			color.New(color.FgYellow).Printf("Error fetching token meta (URI: %s): %v\n", btd.Data.Uri, err)
 		}
-		return nil, nil
+		return nil
 	}
 
 	if btm.Description == "" {
@@ -366,5 +394,5 @@ func TokenHelper(ctx context.Context, token solana.PublicKey) (*TokenData, *Toke
 		btm.Description = btm.Description[:600] + "..."
 	}
 
-	return btd, btm
+	return btm
 }
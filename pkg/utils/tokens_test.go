@@ -2,58 +2,292 @@ package utils
 
 import (
 	"context"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs"
+	"github.com/OnlyF0uR/solana-monitor/pkg/rpcs/rpctest"
 	"github.com/gagliardetto/solana-go"
 )
 
+// Fixture mints, see testdata/token_*.json
+var (
+	renouncedMint        = solana.MustPublicKeyFromBase58("B7oxK39hNEp72zhVnKAvMMNJWYN3xpCypus6wdLQpTiQ")
+	mintableMint         = solana.MustPublicKeyFromBase58("EnnakCwrRgq6QYqWLZAPcug8ogUDDkapn9TXtWMSdsXm")
+	emptyDescriptionMint = solana.MustPublicKeyFromBase58("35xLjQNs3z391fLg8zgBg1k1Xp3NkE6WbnUh5gExyTPy")
+	noUriMint            = solana.MustPublicKeyFromBase58("2X84gqsADHEseCtoj4FJA5KqknozVuMWUmTYWpxEr9zV")
+	noMetadataMint       = solana.MustPublicKeyFromBase58("CT8xBrRGDdTnLUscGXjfn4iTPXwv7guff6bEFAdMTX9g")
+	metaUnavailableMint  = solana.MustPublicKeyFromBase58("DuNhVGxag29inxLSSXPs5gFW1RUQfGGTgTxgiS8QGkD8")
+	notAMint             = solana.MustPublicKeyFromBase58("9obVj2LPuX3VuAQsM2twqATkLFkRzkDb32pHQBvELTWq")
+	token2022Mint        = solana.MustPublicKeyFromBase58("BJKY4u6E8thX3ZuQEAc3RfHJVijCkGShgUTtDECgAKns")
+)
+
+func useFixtures(t *testing.T, patterns ...string) {
+	replay := rpctest.Use(t, patterns...)
+
+	client := MetadataClient
+	MetadataClient = replay.HTTPClient()
+	t.Cleanup(func() { MetadataClient = client })
+}
+
 func Test_GetTokendata(t *testing.T) {
 	ctx := context.Background()
-	rpcs.Initialise([]string{})
+	useFixtures(t, "testdata/token_*.json")
 
-	tokenData, err := GetTokendata(ctx, solana.MustPublicKeyFromBase58("5dJyaVfERNXJ5PWxFfCsL2KsuqUZ9wUhxCr21ifxGMVi"), false)
-	if err != nil {
-		t.Error(err)
+	tests := []struct {
+		name    string
+		mint    solana.PublicKey
+		wantErr bool
+		check   func(t *testing.T, td *TokenData)
+	}{
+		{
+			name: "renounced",
+			mint: renouncedMint,
+			check: func(t *testing.T, td *TokenData) {
+				if td.MintAuthority != nil || td.FreezeAuthority != nil {
+					t.Errorf("expected revoked authorities, got %v/%v", td.MintAuthority, td.FreezeAuthority)
+				}
+				if td.Supply != 1_000_000_000_000_000 || td.Decimals != 6 || !td.IsInitialized {
+					t.Errorf("unexpected mint %+v", td)
+				}
+				if td.Data.Name != "Renounced Fixture" || td.Data.Symbol != "RNCD" || td.Data.Uri != "https://example.com/metadata/renounced.json" {
+					t.Errorf("unexpected metadata %+v", td.Data)
+				}
+				if td.IsMutable || *td.Mint != renouncedMint || td.Extensions != nil {
+					t.Errorf("unexpected metadata flags %+v", td)
+				}
+			},
+		},
+		{
+			name: "mintable",
+			mint: mintableMint,
+			check: func(t *testing.T, td *TokenData) {
+				if td.MintAuthority == nil || td.FreezeAuthority == nil {
+					t.Error("expected enabled authorities")
+				}
+				if td.Supply != 420_000_000_000_000_000 || td.Decimals != 9 || !td.IsMutable {
+					t.Errorf("unexpected mint %+v", td)
+				}
+			},
+		},
+		{
+			name: "no_uri",
+			mint: noUriMint,
+			check: func(t *testing.T, td *TokenData) {
+				if td.Data.Uri != "" || td.Data.Name != "No Uri Fixture" {
+					t.Errorf("unexpected metadata %+v", td.Data)
+				}
+			},
+		},
+		{
+			name: "token_2022",
+			mint: token2022Mint,
+			check: func(t *testing.T, td *TokenData) {
+				if td.Supply != 1_000_000_000_000_000 || td.Decimals != 6 || td.MintAuthority != nil {
+					t.Errorf("unexpected mint %+v", td)
+				}
+				// Metadata from the extension, without a metaplex account
+				if td.Data.Name != "Hooked Fixture" || td.Data.Symbol != "HOOK" || td.Data.Uri != "https://example.com/metadata/hooked.json" || td.IsMutable {
+					t.Errorf("unexpected metadata %+v", td.Data)
+				}
+
+				ext := td.Extensions
+				if ext == nil {
+					t.Fatal("extensions missing")
+				}
+				if ext.TransferFee == nil || ext.TransferFee.Older.BasisPoints != 100 || ext.TransferFee.Newer.BasisPoints != 250 || ext.TransferFee.Newer.Epoch != 600 {
+					t.Errorf("unexpected transfer fee %+v", ext.TransferFee)
+				}
+				if ext.PermanentDelegate == nil || ext.PermanentDelegate.String() != "EjkYHTXqPFyFES6Ko9oRCXA1rPVp8HWgxDgT6N46cQUq" {
+					t.Errorf("unexpected permanent delegate %v", ext.PermanentDelegate)
+				}
+				if ext.TransferHook == nil || ext.TransferHook.ProgramID.String() != "2LvH3YP1Yocmn1ovwconz4TgfiNoQtNTAapH67B3egdJ" {
+					t.Errorf("unexpected transfer hook %+v", ext.TransferHook)
+				}
+				if ext.MetadataPointer == nil || *ext.MetadataPointer.MetadataAddress != token2022Mint {
+					t.Errorf("unexpected metadata pointer %+v", ext.MetadataPointer)
+				}
+				if ext.InterestBearing == nil || ext.InterestBearing.CurrentRate != 500 || !ext.NonTransferable {
+					t.Errorf("unexpected extensions %+v", ext)
+				}
+				if len(ext.TokenMetadata.AdditionalMetadata) != 1 || ext.TokenMetadata.AdditionalMetadata[0] != [2]string{"site", "https://hooked.example.com"} {
+					t.Errorf("unexpected additional metadata %v", ext.TokenMetadata.AdditionalMetadata)
+				}
+
+				want := []string{
+					"Permanent delegate: EjkYHTXqPFyFES6Ko9oRCXA1rPVp8HWgxDgT6N46cQUq",
+					"Transfer hook: 2LvH3YP1Yocmn1ovwconz4TgfiNoQtNTAapH67B3egdJ",
+					"Transfer fee: 2.50%",
+					"Non-transferable",
+					"Interest bearing: 5.00%",
+				}
+				if got := ext.Warnings(); strings.Join(got, "|") != strings.Join(want, "|") {
+					t.Errorf("warnings: got %q, want %q", got, want)
+				}
+			},
+		},
+		{name: "no_metadata", mint: noMetadataMint, wantErr: true},
+		{name: "not_a_mint", mint: notAMint, wantErr: true},
 	}
 
-	t.Logf("token: %#+v", tokenData)
+	for _, tt := range tests {
+		// Both the single attempt and the retrying path read the same accounts
+		for _, mayFail := range []bool{true, false} {
+			tokenData, err := GetTokendata(ctx, tt.mint, mayFail)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("%s (mayFail %v): expected error", tt.name, mayFail)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s (mayFail %v): %v", tt.name, mayFail, err)
+				continue
+			}
+			tt.check(t, tokenData)
+		}
+	}
 }
 
 func Test_FetchTokenMeta(t *testing.T) {
-	res, err := FetchTokenMeta("https://bafybeicw3txn5yu3oscu4o5xkvzoajkydzom5zityuk7rxncmduodlj5m4.ipfs.cf-ipfs.com/")
+	useFixtures(t, "testdata/token_mintable.json")
+
+	// nftstorage links are rewritten to the IPFS gateway
+	res, err := FetchTokenMeta(context.Background(), "https://fixture.ipfs.nftstorage.link/")
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
+	}
+
+	if res.Name != "Mintable Fixture" || res.Website != "https://mintable.example.com" || res.Twitter != "https://x.com/mintable" || res.Telegram != "https://t.me/mintable" {
+		t.Errorf("unexpected meta %+v", res)
 	}
 
-	t.Logf("res: %#+v", res)
+	if _, err := FetchTokenMeta(context.Background(), "https://example.com/metadata/gone.json"); err == nil {
+		t.Error("expected error for missing document")
+	}
 }
 
 func Test_GetTopHolders(t *testing.T) {
 	ctx := context.Background()
-	rpcs.Initialise([]string{})
+	useFixtures(t, "testdata/holders.json")
 
-	holders := GetTopHolders_S(ctx, solana.MustPublicKeyFromBase58("EoptP6e22xWGNYJCTGNS2A1S29Z3CKNPJJ6ASGq8yft6"))
+	holders := GetTopHolders_S(ctx, renouncedMint)
 	if holders == nil {
-		t.Error("holders is nil")
+		t.Fatal("holders is nil")
+	}
+
+	// Sorted descending, the account without uiAmount is skipped
+	want := []float64{250_000_000, 90_000_000, 12_000, 3}
+	if len(*holders) != len(want) {
+		t.Fatalf("expected %d holders, got %d", len(want), len(*holders))
+	}
+	for i, h := range *holders {
+		if h.Amount != want[i] {
+			t.Errorf("holder %d: got %v, want %v", i, h.Amount, want[i])
+		}
 	}
-	t.Logf("holders: %#+v", holders)
 }
 
 func Test_TokenHelper(t *testing.T) {
+	ctx := context.Background()
+	useFixtures(t, "testdata/token_*.json")
+
+	tests := []struct {
+		name      string
+		mint      solana.PublicKey
+		wantNil   bool
+		truncated bool // Description cut to 600 characters
+		wantMeta  TokenMeta
+	}{
+		{
+			name: "renounced",
+			mint: renouncedMint,
+			wantMeta: TokenMeta{
+				Name:        "Renounced Fixture",
+				Description: "A token with revoked authorities.",
+				Twitter:     "https://x.com/renounced",
+				Telegram:    "https://t.me/renounced",
+				Website:     "https://renounced.example.com",
+			},
+		},
+		{
+			name:      "mintable",
+			mint:      mintableMint,
+			truncated: true,
+			wantMeta: TokenMeta{
+				Name:     "Mintable Fixture",
+				Twitter:  "https://x.com/mintable",
+				Telegram: "https://t.me/mintable",
+				Website:  "https://mintable.example.com",
+			},
+		},
+		{
+			name:     "empty_description",
+			mint:     emptyDescriptionMint,
+			wantMeta: TokenMeta{Name: "Quiet Fixture", Description: "None"},
+		},
+		{name: "no_uri", mint: noUriMint, wantNil: true},
+		{name: "no_metadata", mint: noMetadataMint, wantNil: true},
+		{name: "meta_unavailable", mint: metaUnavailableMint, wantNil: true},
+		{name: "not_a_mint", mint: notAMint, wantNil: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseTokenData, baseTokenMeta := TokenHelper(ctx, tt.mint)
+
+			if tt.wantNil {
+				if baseTokenData != nil || baseTokenMeta != nil {
+					t.Errorf("expected nil, got %+v / %+v", baseTokenData, baseTokenMeta)
+				}
+				return
+			}
+
+			if baseTokenData == nil || baseTokenMeta == nil {
+				t.Fatalf("expected token, got %+v / %+v", baseTokenData, baseTokenMeta)
+			}
+
+			got := *baseTokenMeta
+			if got.Name != tt.wantMeta.Name || got.Twitter != tt.wantMeta.Twitter || got.Telegram != tt.wantMeta.Telegram || got.Website != tt.wantMeta.Website {
+				t.Errorf("unexpected meta %+v", got)
+			}
+
+			if tt.wantMeta.Description != "" && got.Description != tt.wantMeta.Description {
+				t.Errorf("description: got %q, want %q", got.Description, tt.wantMeta.Description)
+			}
+			if tt.truncated && (len(got.Description) != 603 || !strings.HasSuffix(got.Description, "...")) {
+				t.Errorf("description not truncated: %d", len(got.Description))
+			}
+		})
+	}
+}
+
+// Test_TokenHelperLive runs against mainnet, set INCLUDE_SOLANA_BETA_MAINNET_RPC=1 to enable it.
+func Test_TokenHelperLive(t *testing.T) {
+	if os.Getenv("INCLUDE_SOLANA_BETA_MAINNET_RPC") != "1" {
+		t.Skip("mainnet RPC not enabled")
+	}
+
 	ctx := context.Background()
 	rpcs.Initialise([]string{})
 
-	baseTokenData, baseTokenMeta := TokenHelper(ctx, solana.MustPublicKeyFromBase58("EoptP6e22xWGNYJCTGNS2A1S29Z3CKNPJJ6ASGq8yft6"))
+	tokenData, err := GetTokendata(ctx, solana.MustPublicKeyFromBase58("5dJyaVfERNXJ5PWxFfCsL2KsuqUZ9wUhxCr21ifxGMVi"), false)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Logf("token: %#+v", tokenData)
 
+	baseTokenData, baseTokenMeta := TokenHelper(ctx, solana.MustPublicKeyFromBase58("EoptP6e22xWGNYJCTGNS2A1S29Z3CKNPJJ6ASGq8yft6"))
 	if baseTokenData == nil {
 		t.Error("baseTokenData is nil")
 	}
-
 	if baseTokenMeta == nil {
 		t.Error("baseTokenMeta is nil")
 	}
 
-	t.Logf("baseTokenData: %#+v", baseTokenData)
-	t.Logf("baseTokenMeta: %#+v", baseTokenMeta)
+	holders := GetTopHolders_S(ctx, solana.MustPublicKeyFromBase58("EoptP6e22xWGNYJCTGNS2A1S29Z3CKNPJJ6ASGq8yft6"))
+	if holders == nil {
+		t.Error("holders is nil")
+	}
 }
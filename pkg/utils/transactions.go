@@ -3,6 +3,7 @@ package utils
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"time"
@@ -21,38 +22,70 @@ type FetchTransactionResponse struct {
 	Id      int `json:"id"`
 }
 
-// Get a confirmed transaction, will retry with mainnet beta rpc if failed, includes logging by default
+// Get a confirmed transaction, includes logging by default. A transaction the node has
+// not indexed yet is retried until it becomes visible, see TransactionFetchPolicy.
 func GetConfirmedTransaction_S(ctx context.Context, signature solana.Signature) (*rpc.GetTransactionResult, *solana.Transaction, error) {
-	var rpcTx *rpc.GetTransactionResult
+	policy := TransactionFetchPolicy
+	start := time.Now()
+	deadline := start.Add(policy.VisibilityDeadline)
 
-	for i := 0; i < 5; i++ {
-		client := rpcs.BorrowClient()
+	var rpcTx *rpc.GetTransactionResult
+	var lastErr error
+	errorsLeft := policy.MaxErrors
 
+	for attempt := 0; ; attempt++ {
 		wrapped_ctx, wrapped_cancel := context.WithTimeout(ctx, 5*time.Second)
-		tmp_rpcTx, err := client.GetTransaction(wrapped_ctx, signature, &rpc.GetTransactionOpts{
-			MaxSupportedTransactionVersion: &Max_Transaction_Version,
-			Commitment:                     rpc.CommitmentConfirmed,
-			Encoding:                       solana.EncodingBase64,
+		tmp_rpcTx, err := rpcs.Hedge(wrapped_ctx, func(ctx context.Context, client *rpc.Client) (*rpc.GetTransactionResult, error) {
+			return client.GetTransaction(ctx, signature, &rpc.GetTransactionOpts{
+				MaxSupportedTransactionVersion: &Max_Transaction_Version,
+				Commitment:                     rpc.CommitmentConfirmed,
+				Encoding:                       solana.EncodingBase64,
+			})
 		})
 		wrapped_cancel()
 
-		if err != nil {
-			if !strings.Contains(err.Error(), "context deadline exceeded") {
-				if os.Getenv("DEBUG") == "1" {
-					color.New(color.FgYellow).Printf("GetConfirmedTransaction_S -> Failed to get transaction, retrying (%d): %v\n", i+1, err)
-				}
+		if err == nil {
+			rpcTx = tmp_rpcTx
+			break
+		}
+		if ctx.Err() != nil {
+			observeFetch(FetchCancelled, time.Since(start))
+			return nil, nil, ctx.Err()
+		}
+
+		lastErr = err
+		if !errors.Is(err, rpc.ErrNotFound) {
+			errorsLeft--
+			if os.Getenv("DEBUG") == "1" && !strings.Contains(err.Error(), "context deadline exceeded") {
+				color.New(color.FgYellow).Printf("GetConfirmedTransaction_S -> Failed to get transaction, retrying (%d): %v\n", policy.MaxErrors-errorsLeft, err)
+			}
+			if errorsLeft <= 0 {
+				break
 			}
-			continue
 		}
 
-		rpcTx = tmp_rpcTx
-		break
+		wait := policy.backoff(attempt)
+		if time.Now().Add(wait).After(deadline) {
+			break
+		}
+		if !sleepCtx(ctx, wait) {
+			observeFetch(FetchCancelled, time.Since(start))
+			return nil, nil, ctx.Err()
+		}
 	}
 
 	if rpcTx == nil {
-		color.New(color.FgRed).Printf("GetConfirmedTransaction_S -> Failed to get transaction after 5 attempts\n")
-		return nil, nil, errors.New("failed to get transaction after 5 attempts")
+		if errors.Is(lastErr, rpc.ErrNotFound) {
+			observeFetch(FetchNotFound, time.Since(start))
+			color.New(color.FgRed).Printf("GetConfirmedTransaction_S -> Transaction %s not visible after %v\n", signature, policy.VisibilityDeadline)
+			return nil, nil, fmt.Errorf("transaction not visible after %v: %w", policy.VisibilityDeadline, lastErr)
+		}
+
+		observeFetch(FetchFailed, time.Since(start))
+		color.New(color.FgRed).Printf("GetConfirmedTransaction_S -> Failed to get transaction: %v\n", lastErr)
+		return nil, nil, fmt.Errorf("failed to get transaction: %w", lastErr)
 	}
+	observeFetch(FetchFound, time.Since(start))
 
 	tx, err := rpcTx.Transaction.GetTransaction()
 	if err != nil {
@@ -63,6 +96,39 @@ func GetConfirmedTransaction_S(ctx context.Context, signature solana.Signature)
 	return rpcTx, tx, nil
 }
 
+// GetSignaturesForAddress_S returns up to limit signatures of address, newest first, starting
+// before the given signature (zero for the newest).
+func GetSignaturesForAddress_S(ctx context.Context, address solana.PublicKey, before solana.Signature, limit int) ([]*rpc.TransactionSignature, error) {
+	var err error
+
+	for i := 0; i < 5; i++ {
+		client := rpcs.BorrowClient()
+
+		wrapped_ctx, wrapped_cancel := context.WithTimeout(ctx, 10*time.Second)
+		var signatures []*rpc.TransactionSignature
+		signatures, err = client.GetSignaturesForAddressWithOpts(wrapped_ctx, address, &rpc.GetSignaturesForAddressOpts{
+			Limit:      &limit,
+			Before:     before,
+			Commitment: rpc.CommitmentConfirmed,
+		})
+		wrapped_cancel()
+
+		if err != nil {
+			if os.Getenv("DEBUG") == "1" {
+				color.New(color.FgYellow).Printf("GetSignaturesForAddress_S -> Failed to get signatures, retrying (%d): %v\n", i+1, err)
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		return signatures, nil
+	}
+
+	return nil, fmt.Errorf("failed to get signatures: %w", err)
+}
+
 func GetBalance_S(ctx context.Context, account solana.PublicKey) float64 {
 	for i := 0; i < 5; i++ {
 		client := rpcs.BorrowClient()
@@ -81,3 +147,30 @@ func GetBalance_S(ctx context.Context, account solana.PublicKey) float64 {
 	// If we fail to get the balance after 5 attempts, return 0
 	return 0
 }
+
+// BlockTime returns the block time of the transaction, zero when it is unknown.
+func BlockTime(rpcTx *rpc.GetTransactionResult) time.Time {
+	if rpcTx.BlockTime == nil {
+		return time.Time{}
+	}
+	return rpcTx.BlockTime.Time()
+}
+
+// AccountKeys returns the static account keys followed by the ones loaded from lookup tables,
+// in the order instructions index them.
+func AccountKeys(rpcTx *rpc.GetTransactionResult, tx *solana.Transaction) solana.PublicKeySlice {
+	keys := append(solana.PublicKeySlice{}, tx.Message.AccountKeys...)
+	if rpcTx.Meta != nil {
+		keys = append(keys, rpcTx.Meta.LoadedAddresses.Writable...)
+		keys = append(keys, rpcTx.Meta.LoadedAddresses.ReadOnly...)
+	}
+	return keys
+}
+
+// InstrAccount returns the i-th account of instr, zero if it is out of range.
+func InstrAccount(keys solana.PublicKeySlice, instr solana.CompiledInstruction, i int) solana.PublicKey {
+	if i >= len(instr.Accounts) || int(instr.Accounts[i]) >= len(keys) {
+		return solana.PublicKey{}
+	}
+	return keys[instr.Accounts[i]]
+}